@@ -10,6 +10,8 @@ import (
 	"github.com/felipemonteiro/mintlog/internal/alerting"
 	"github.com/felipemonteiro/mintlog/internal/bus"
 	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/storage/filestore"
 	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
@@ -49,17 +51,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	// OpenSearch
-	osClient, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
-	if err != nil {
-		slog.Error("opensearch connect failed", "error", err)
+	// Log store
+	var logs logstore.LogStore
+	switch cfg.LogStore.Backend {
+	case "opensearch":
+		osClient, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
+		if err != nil {
+			slog.Error("opensearch connect failed", "error", err)
+			os.Exit(1)
+		}
+		logs = osstore.NewStore(osClient, nil)
+	case "local":
+		logs, err = filestore.NewStore(cfg.LogStore.Dir)
+		if err != nil {
+			slog.Error("failed to open log store", "error", err)
+			os.Exit(1)
+		}
+	default:
+		slog.Error("unknown log store backend", "backend", cfg.LogStore.Backend)
 		os.Exit(1)
 	}
 
-	searcher := osstore.NewSearcher(osClient)
-	pub := bus.NewPublisher(js)
+	pub := bus.NewPublisher(nc, js)
 
-	evaluator := alerting.NewEvaluator(q, searcher, pub)
+	evaluator := alerting.NewEvaluator(q, logs, pub, cfg.Pipeline.MetricFlush)
 	if err := evaluator.Start(); err != nil {
 		slog.Error("failed to start evaluator", "error", err)
 		os.Exit(1)
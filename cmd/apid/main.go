@@ -16,15 +16,29 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/nats-io/nats.go"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 
 	"github.com/felipemonteiro/mintlog/internal/alerting"
+	"github.com/felipemonteiro/mintlog/internal/archive"
 	"github.com/felipemonteiro/mintlog/internal/auth"
 	"github.com/felipemonteiro/mintlog/internal/bus"
 	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/dlq"
+	"github.com/felipemonteiro/mintlog/internal/egress"
+	"github.com/felipemonteiro/mintlog/internal/fields"
 	"github.com/felipemonteiro/mintlog/internal/incident"
+	"github.com/felipemonteiro/mintlog/internal/lifecycle"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/lookup"
+	"github.com/felipemonteiro/mintlog/internal/metrics"
 	mw "github.com/felipemonteiro/mintlog/internal/middleware"
 	"github.com/felipemonteiro/mintlog/internal/notification"
+	"github.com/felipemonteiro/mintlog/internal/pipeline"
+	"github.com/felipemonteiro/mintlog/internal/rehydrate"
+	"github.com/felipemonteiro/mintlog/internal/rules"
 	"github.com/felipemonteiro/mintlog/internal/search"
+	"github.com/felipemonteiro/mintlog/internal/storage/filestore"
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
 	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
@@ -78,31 +92,75 @@ func main() {
 		os.Exit(1)
 	}
 
-	// OpenSearch
-	osClient, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
-	if err != nil {
-		slog.Error("opensearch connect failed", "error", err)
-		os.Exit(1)
-	}
+	pub := bus.NewPublisher(nc, js)
 
-	if err := osstore.EnsureIndexTemplate(ctx, osClient); err != nil {
-		slog.Warn("failed to ensure index template (may already exist)", "error", err)
-	}
+	// Log store: OpenSearch, fed by the indexer, or local files fed by a
+	// log store writer
+	var (
+		logs     logstore.LogStore
+		osClient *opensearchapi.Client
+		indexer  *osstore.Indexer
+	)
+	switch cfg.LogStore.Backend {
+	case "opensearch":
+		osClient, err = osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
+		if err != nil {
+			slog.Error("opensearch connect failed", "error", err)
+			os.Exit(1)
+		}
+
+		if err := osstore.EnsureIndexTemplate(ctx, osClient); err != nil {
+			slog.Warn("failed to ensure index template (may already exist)", "error", err)
+		}
+
+		// Start OpenSearch indexer (consumes logs.parsed from NATS)
+		placement := tenantPlacement(q, cfg.Indexer.SharedPlans)
+		indexer = osstore.NewIndexer(osClient, nc, js, pub, cfg.Indexer.Workers, cfg.Indexer.MinBatchMB<<20, cfg.Indexer.MaxBatchMB<<20, cfg.Indexer.Flush, cfg.Indexer.MaxPending, placement)
+		if err := indexer.Start(ctx); err != nil {
+			slog.Error("failed to start indexer", "error", err)
+			os.Exit(1)
+		}
+		defer indexer.Stop()
+		logs = osstore.NewStore(osClient, placement)
+
+	case "local":
+		files, err := filestore.NewStore(cfg.LogStore.Dir)
+		if err != nil {
+			slog.Error("failed to open log store", "error", err)
+			os.Exit(1)
+		}
+
+		// Start log store writer (consumes logs.parsed from NATS)
+		writer := logstore.NewWriter(files, js, pub, cfg.LogStore.BatchSize, cfg.Indexer.Flush, cfg.Indexer.MaxPending)
+		if err := writer.Start(ctx); err != nil {
+			slog.Error("failed to start log store writer", "error", err)
+			os.Exit(1)
+		}
+		defer writer.Stop()
+		logs = files
 
-	// Start OpenSearch indexer (consumes logs.parsed from NATS)
-	indexer := osstore.NewIndexer(osClient, js)
-	if err := indexer.Start(ctx); err != nil {
-		slog.Error("failed to start indexer", "error", err)
+	default:
+		slog.Error("unknown log store backend", "backend", cfg.LogStore.Backend)
 		os.Exit(1)
 	}
-	defer indexer.Stop()
 
 	// Auth
 	resolver := auth.NewKeyResolver(q, cache)
 
+	// MinIO (archive, for cold search)
+	store, err := minio.NewClient(cfg.MinIO.Endpoint, cfg.MinIO.AccessKey, cfg.MinIO.SecretKey, cfg.MinIO.UseSSL, "")
+	if err != nil {
+		slog.Error("minio connect failed", "error", err)
+		os.Exit(1)
+	}
+
+	// Field types, for validating search queries and simulating the pipeline
+	fieldRegistry := fields.NewRegistryPreview(pool, cfg.Pipeline.FieldMax, cfg.Pipeline.FieldRefresh)
+
 	// Search
-	searcher := osstore.NewSearcher(osClient)
-	searchHandler := search.NewHandler(searcher)
+	coldResults := redisstore.NewResultStore(rdb, "coldsearch", cfg.Archive.ColdResultsTTL)
+	coldSearcher := search.NewColdSearcher(archive.NewReader(store, cfg.Archive.Bucket), coldResults)
+	searchHandler := search.NewHandler(logs, coldSearcher, fieldRegistry)
 
 	// Alerting
 	alertHandler := alerting.NewHandler(q)
@@ -114,6 +172,65 @@ func main() {
 	incidentSvc := incident.NewService(q)
 	incidentHandler := incident.NewHandler(incidentSvc)
 
+	// Lookup tables
+	lookupHandler := lookup.NewHandler(q)
+	lookups := lookup.NewCache(q, cfg.Pipeline.LookupRefresh)
+	if err := lookups.Start(ctx); err != nil {
+		slog.Error("failed to load lookup tables", "error", err)
+		os.Exit(1)
+	}
+	defer lookups.Stop()
+
+	rulesHandler := rules.NewHandler(q)
+	pipelineRules := rules.NewCache(q, cfg.Pipeline.RulesRefresh)
+	if err := pipelineRules.Start(ctx); err != nil {
+		slog.Error("failed to load pipeline rules", "error", err)
+		os.Exit(1)
+	}
+	defer pipelineRules.Stop()
+
+	// Egress destinations
+	egressHosts, err := egress.NewHosts(cfg.Egress.AllowedHosts)
+	if err != nil {
+		slog.Error("invalid egress config", "error", err)
+		os.Exit(1)
+	}
+	egressHandler := egress.NewHandler(q, js, egressHosts)
+
+	// Index storage and rehydration, with OpenSearch only
+	var (
+		storageHandler   *lifecycle.Handler
+		rehydrateHandler *rehydrate.Handler
+	)
+	if osClient != nil {
+		storageHandler = lifecycle.NewHandler(q, osClient)
+		rehydrateHandler = rehydrate.NewHandler(q, osClient)
+	}
+
+	// Log-derived metrics
+	metricsHandler := metrics.NewHandler(q)
+
+	// Pipeline simulation
+	fieldsHandler := fields.NewHandler(q, fieldRegistry)
+
+	pipelineDeps := pipeline.Deps{
+		Lookups:  lookups,
+		Rules:    pipelineRules,
+		Fields:   pipeline.NewFieldGuard(fieldRegistry, nil, cfg.Pipeline.FieldMaxDepth),
+		Patterns: pipeline.NewPatternPreview(q, cfg.Pipeline.PatternMax, cfg.Pipeline.PatternFlush),
+	}
+	if cfg.Pipeline.UserAgentField != "" {
+		pipelineDeps.UserAgent, err = pipeline.NewUserAgentParser(cfg.Pipeline.UserAgentField)
+		if err != nil {
+			slog.Error("failed to load user-agent parser", "error", err)
+			os.Exit(1)
+		}
+	}
+	pipelineHandler := pipeline.NewHandler(pipelineDeps)
+
+	// Dead-letter queue
+	dlqHandler := dlq.NewHandler(dlq.NewStore(js, pub))
+
 	// Start incident auto-creator (consumes incidents.events from NATS)
 	go startIncidentConsumer(js, incidentSvc)
 
@@ -134,8 +251,11 @@ func main() {
 
 		// Search
 		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Post("/logs/search", searchHandler.Search)
+		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Get("/logs/search/cold/{id}", searchHandler.ColdResults)
+		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Delete("/logs/search/cold/{id}", searchHandler.CancelCold)
 		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Post("/logs/tail", searchHandler.Tail)
 		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Post("/logs/aggregate", searchHandler.Aggregate)
+		r.With(auth.RequireScope(auth.ScopeSearchLogs)).Post("/logs/patterns", searchHandler.Patterns)
 
 		// Alert Rules
 		r.Route("/alerts/rules", func(r chi.Router) {
@@ -163,11 +283,86 @@ func main() {
 			r.With(auth.RequireScope(auth.ScopeIncidentWrite)).Post("/{id}/timeline", incidentHandler.AddTimeline)
 		})
 
+		// Pipeline
+		r.Route("/pipeline", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopePipelineRead)).Post("/simulate", pipelineHandler.Simulate)
+			r.With(auth.RequireScope(auth.ScopePipelineRead)).Get("/rules", rulesHandler.List)
+			r.With(auth.RequireScope(auth.ScopePipelineWrite)).Post("/rules", rulesHandler.Create)
+			r.With(auth.RequireScope(auth.ScopePipelineRead)).Get("/rules/{id}", rulesHandler.Get)
+			r.With(auth.RequireScope(auth.ScopePipelineWrite)).Put("/rules/{id}", rulesHandler.Update)
+			r.With(auth.RequireScope(auth.ScopePipelineWrite)).Delete("/rules/{id}", rulesHandler.Delete)
+		})
+
+		// Lookup Tables
+		r.Route("/lookups", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopeLookupRead)).Get("/", lookupHandler.List)
+			r.With(auth.RequireScope(auth.ScopeLookupRead)).Get("/{name}", lookupHandler.Get)
+			r.With(auth.RequireScope(auth.ScopeLookupWrite)).Put("/{name}", lookupHandler.Upload)
+			r.With(auth.RequireScope(auth.ScopeLookupWrite)).Delete("/{name}", lookupHandler.Delete)
+		})
+
+		// Fields
+		r.Route("/fields", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopeFieldRead)).Get("/catalog", fieldsHandler.Catalog)
+			r.With(auth.RequireScope(auth.ScopeFieldRead)).Get("/types", fieldsHandler.ListTypes)
+			r.With(auth.RequireScope(auth.ScopeFieldWrite)).Put("/types/{name}", fieldsHandler.SetType)
+			r.With(auth.RequireScope(auth.ScopeFieldWrite)).Delete("/types/{name}", fieldsHandler.DeleteType)
+		})
+
+		// Metrics
+		r.Route("/metrics", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopeMetricRead)).Get("/", metricsHandler.List)
+			r.With(auth.RequireScope(auth.ScopeMetricWrite)).Post("/", metricsHandler.Create)
+			r.With(auth.RequireScope(auth.ScopeMetricRead)).Post("/query", metricsHandler.Query)
+			r.With(auth.RequireScope(auth.ScopeMetricRead)).Get("/{id}", metricsHandler.Get)
+			r.With(auth.RequireScope(auth.ScopeMetricWrite)).Put("/{id}", metricsHandler.Update)
+			r.With(auth.RequireScope(auth.ScopeMetricWrite)).Delete("/{id}", metricsHandler.Delete)
+		})
+
+		// Egress
+		r.Route("/egress/destinations", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopeEgressRead)).Get("/", egressHandler.List)
+			r.With(auth.RequireScope(auth.ScopeEgressWrite)).Post("/", egressHandler.Create)
+			r.With(auth.RequireScope(auth.ScopeEgressRead)).Get("/{id}", egressHandler.Get)
+			r.With(auth.RequireScope(auth.ScopeEgressWrite)).Put("/{id}", egressHandler.Update)
+			r.With(auth.RequireScope(auth.ScopeEgressWrite)).Delete("/{id}", egressHandler.Delete)
+		})
+
+		// Storage and rehydration manage OpenSearch indices
+		if osClient != nil {
+			r.With(auth.RequireScope(auth.ScopeStorageRead)).Get("/storage", storageHandler.Usage)
+
+			r.Route("/rehydrate", func(r chi.Router) {
+				r.With(auth.RequireScope(auth.ScopeArchiveRead)).Get("/", rehydrateHandler.List)
+				r.With(auth.RequireScope(auth.ScopeArchiveWrite)).Post("/", rehydrateHandler.Create)
+				r.With(auth.RequireScope(auth.ScopeArchiveRead)).Get("/{id}", rehydrateHandler.Get)
+				r.With(auth.RequireScope(auth.ScopeArchiveWrite)).Delete("/{id}", rehydrateHandler.Delete)
+			})
+		}
+
+		// Dead-letter queue
+		r.Route("/dlq", func(r chi.Router) {
+			r.With(auth.RequireScope(auth.ScopeDLQRead)).Get("/", dlqHandler.List)
+			r.With(auth.RequireScope(auth.ScopeDLQWrite)).Delete("/", dlqHandler.Purge)
+			r.With(auth.RequireScope(auth.ScopeDLQWrite)).Post("/replay", dlqHandler.ReplayAll)
+			r.With(auth.RequireScope(auth.ScopeDLQRead)).Get("/{seq}", dlqHandler.Get)
+			r.With(auth.RequireScope(auth.ScopeDLQWrite)).Delete("/{seq}", dlqHandler.Delete)
+			r.With(auth.RequireScope(auth.ScopeDLQWrite)).Post("/{seq}/replay", dlqHandler.Replay)
+		})
+
 		// Admin
 		r.Route("/admin", func(r chi.Router) {
 			r.Use(auth.RequireScope(auth.ScopeAdmin))
 			r.Post("/tenants", adminCreateTenant(q))
 			r.Post("/tenants/{id}/keys", adminCreateKey(q))
+			if osClient != nil {
+				r.Get("/storage", storageHandler.AdminUsage)
+				r.Get("/indexer", adminIndexerStats(indexer))
+				r.Get("/tenants/{id}/index-settings", storageHandler.GetIndexSettings)
+				r.Put("/tenants/{id}/index-settings", storageHandler.PutIndexSettings)
+				r.Delete("/tenants/{id}/index-settings", storageHandler.DeleteIndexSettings)
+				r.Get("/templates/drift", storageHandler.Drift)
+			}
 		})
 	})
 
@@ -197,6 +392,21 @@ func main() {
 	}
 }
 
+// tenantPlacement places tenants on the shared indices by plan.
+func tenantPlacement(q *queries.Queries, sharedPlans []string) osstore.PlacementFunc {
+	return func(ctx context.Context, tenantID string) (osstore.Placement, error) {
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return "", err
+		}
+		t, err := q.GetTenant(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return osstore.PlacementFor(t.Plan, sharedPlans), nil
+	}
+}
+
 // startIncidentConsumer listens on incidents.events and auto-creates incidents from alerts.
 func startIncidentConsumer(js nats.JetStreamContext, svc *incident.Service) {
 	_, err := js.QueueSubscribe(
@@ -288,6 +498,14 @@ type createKeyResp struct {
 	ID     string `json:"id"`
 }
 
+// adminIndexerStats reports this process's indexing outcomes per tenant.
+func adminIndexerStats(indexer *osstore.Indexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(indexer.Stats())
+	}
+}
+
 func adminCreateKey(q *queries.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantIDStr := chi.URLParam(r, "id")
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/felipemonteiro/mintlog/internal/archive"
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// NATS
+	nc, js, err := bus.Connect(cfg.NATS.URL)
+	if err != nil {
+		slog.Error("nats connect failed", "error", err)
+		os.Exit(1)
+	}
+	defer nc.Close()
+
+	if err := bus.EnsureStreams(js); err != nil {
+		slog.Error("failed to ensure streams", "error", err)
+		os.Exit(1)
+	}
+
+	jsx, err := jetstream.New(nc)
+	if err != nil {
+		slog.Error("jetstream init failed", "error", err)
+		os.Exit(1)
+	}
+
+	// MinIO
+	store, err := minio.NewClient(cfg.MinIO.Endpoint, cfg.MinIO.AccessKey, cfg.MinIO.SecretKey, cfg.MinIO.UseSSL, "")
+	if err != nil {
+		slog.Error("minio connect failed", "error", err)
+		os.Exit(1)
+	}
+
+	writer := archive.NewWriter(store, cfg.Archive.Bucket)
+	archiver := archive.NewArchiver(jsx, bus.NewPublisher(nc, js), writer, cfg.Archive.Flush, cfg.Archive.MaxEvents, cfg.Archive.MaxObjectMB<<20)
+	if err := archiver.Start(ctx); err != nil {
+		slog.Error("failed to start archiver", "error", err)
+		os.Exit(1)
+	}
+	defer archiver.Stop()
+
+	slog.Info("archived running", "bucket", cfg.Archive.Bucket)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	slog.Info("shutting down archived")
+}
@@ -0,0 +1,168 @@
+// Command indexctl runs maintenance operations on the OpenSearch indices.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/lifecycle"
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+const usage = `usage: indexctl <command> [flags]
+
+commands:
+  move [-reindex] <tenant-id> shared|dedicated
+        move a tenant between the shared indices and indices of its own
+  templates status
+        report templates and indices that differ from the current template
+  templates migrate [-reindex]
+        write the current templates and bring the indices up to date`
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+
+	var run func(context.Context, *config.Config, []string) error
+	switch os.Args[1] {
+	case "move":
+		run = move
+	case "templates":
+		run = templates
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(context.Background(), cfg, os.Args[2:]); err != nil {
+		fatal(os.Args[1]+" failed", err)
+	}
+}
+
+func move(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	reindex := fs.Bool("reindex", false, "copy existing events to the new placement and remove them from the old one")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("expected <tenant-id> shared|dedicated")
+	}
+	tenantID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid tenant ID: %w", err)
+	}
+	to := osstore.Placement(fs.Arg(1))
+	if to != osstore.PlacementShared && to != osstore.PlacementDedicated {
+		return fmt.Errorf("placement must be shared or dedicated")
+	}
+
+	pool, err := postgres.NewPool(ctx, cfg.Postgres.DSN())
+	if err != nil {
+		return fmt.Errorf("postgres connect: %w", err)
+	}
+	defer pool.Close()
+	if _, err := queries.New(pool).GetTenant(ctx, tenantID); err != nil {
+		return fmt.Errorf("get tenant: %w", err)
+	}
+
+	client, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
+	if err != nil {
+		return err
+	}
+	nc, _, err := bus.Connect(cfg.NATS.URL)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	copied, err := osstore.MoveTenant(ctx, client, tenantID.String(), to, *reindex)
+	// A failed move may have changed the aliases part way.
+	if announceErr := osstore.AnnounceIndicesChanged(nc, tenantID.String()); announceErr != nil {
+		slog.Warn("failed to notify indexers", "error", announceErr)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("tenant %s is %s (%d events copied)\n", tenantID, to, copied)
+	return nil
+}
+
+func templates(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "status" && args[0] != "migrate" {
+		return fmt.Errorf("expected status or migrate")
+	}
+	fs := flag.NewFlagSet("templates "+args[0], flag.ExitOnError)
+	reindex := fs.Bool("reindex", false, "copy the events of indices that cannot be updated in place into new indices, and index events again for new fields")
+	fs.Parse(args[1:])
+
+	pool, err := postgres.NewPool(ctx, cfg.Postgres.DSN())
+	if err != nil {
+		return fmt.Errorf("postgres connect: %w", err)
+	}
+	defer pool.Close()
+	overrides, err := lifecycle.Overrides(ctx, queries.New(pool))
+	if err != nil {
+		return fmt.Errorf("list index settings: %w", err)
+	}
+
+	client, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "status" {
+		drift, err := osstore.CheckDrift(ctx, client, overrides)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("template version %d\n", osstore.CurrentTemplate().Version)
+		for _, d := range drift {
+			action := ""
+			if d.Reindex {
+				action = " (needs a new index)"
+			}
+			fmt.Printf("%s %s%s\n", d.Kind, d.Name, action)
+			for _, p := range d.Problems {
+				fmt.Printf("  %s\n", p)
+			}
+		}
+		if len(drift) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("no drift")
+		return nil
+	}
+
+	report, err := osstore.Migrate(ctx, client, overrides, *reindex)
+	if report != nil {
+		fmt.Printf("templates written: %d\n", report.Templates)
+		fmt.Printf("updated:     %s\n", strings.Join(report.Updated, " "))
+		fmt.Printf("rolled over: %s\n", strings.Join(report.RolledOver, " "))
+		fmt.Printf("reindexed:   %s\n", strings.Join(report.Reindexed, " "))
+		fmt.Printf("backfilled:  %s\n", strings.Join(report.Backfilled, " "))
+		fmt.Printf("pending:     %s\n", strings.Join(report.Pending, " "))
+	}
+	return err
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
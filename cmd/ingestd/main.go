@@ -70,8 +70,14 @@ func main() {
 	resolver := auth.NewKeyResolver(q, cache)
 
 	// Ingest
-	pub := bus.NewPublisher(js)
-	logPub := ingest.NewLogPublisher(pub)
+	codec, err := bus.NewCodec(cfg.NATS.Encoding, cfg.NATS.CompressAbove)
+	if err != nil {
+		slog.Error("invalid event encoding", "error", err)
+		os.Exit(1)
+	}
+
+	pub := bus.NewPublisher(nc, js)
+	logPub := ingest.NewLogPublisher(pub, codec)
 	ingestHandler := ingest.NewHandler(logPub)
 
 	// Router
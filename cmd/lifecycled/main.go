@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/felipemonteiro/mintlog/internal/archive"
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/lifecycle"
+	"github.com/felipemonteiro/mintlog/internal/rehydrate"
+	"github.com/felipemonteiro/mintlog/internal/storage/filestore"
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Postgres
+	pool, err := postgres.NewPool(ctx, cfg.Postgres.DSN())
+	if err != nil {
+		slog.Error("postgres connect failed", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	q := queries.New(pool)
+
+	switch cfg.LogStore.Backend {
+	case "opensearch":
+	case "local":
+		runLocal(ctx, cfg, q)
+		return
+	default:
+		slog.Error("unknown log store backend", "backend", cfg.LogStore.Backend)
+		os.Exit(1)
+	}
+
+	// OpenSearch
+	osClient, err := osstore.NewClient(cfg.OpenSearch.URL, cfg.OpenSearch.User, cfg.OpenSearch.Password)
+	if err != nil {
+		slog.Error("opensearch connect failed", "error", err)
+		os.Exit(1)
+	}
+
+	// NATS, to tell indexers about deleted indices
+	nc, _, err := bus.Connect(cfg.NATS.URL)
+	if err != nil {
+		slog.Error("nats connect failed", "error", err)
+		os.Exit(1)
+	}
+	defer nc.Close()
+
+	// MinIO
+	store, err := minio.NewClient(cfg.MinIO.Endpoint, cfg.MinIO.AccessKey, cfg.MinIO.SecretKey, cfg.MinIO.UseSSL, "")
+	if err != nil {
+		slog.Error("minio connect failed", "error", err)
+		os.Exit(1)
+	}
+
+	// Archive export (optional)
+	var exporter *archive.Exporter
+	if cfg.Lifecycle.Archive {
+		writer := archive.NewWriter(store, cfg.Archive.Bucket)
+		exporter = archive.NewExporter(osClient, writer, cfg.Archive.MaxObjectMB<<20)
+	}
+
+	policy := lifecycle.Policy{
+		ReadOnlyAfter:   cfg.Lifecycle.ReadOnlyAfter,
+		ForceMergeAfter: cfg.Lifecycle.ForceMergeAfter,
+		Rollover: osstore.RolloverConditions{
+			MaxSizeGB: cfg.Lifecycle.RolloverMaxSizeGB,
+			MaxDocs:   cfg.Lifecycle.RolloverMaxDocs,
+			MaxAge:    cfg.Lifecycle.RolloverMaxAge,
+		},
+	}
+	svc := lifecycle.NewService(q, osClient, nc, exporter, policy, cfg.Lifecycle.Interval, cfg.Lifecycle.RolloverInterval)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner := rehydrate.NewRunner(q, osClient, archive.NewReader(store, cfg.Archive.Bucket), cfg.Archive.RehydratePoll)
+	runner.Start(ctx)
+	defer runner.Stop()
+
+	slog.Info("lifecycled running", "interval", cfg.Lifecycle.Interval, "archive", cfg.Lifecycle.Archive)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	slog.Info("shutting down lifecycled")
+}
+
+// runLocal applies retention to the local log store until interrupted.
+// Rollover, archiving and rehydration need OpenSearch and do not run.
+func runLocal(ctx context.Context, cfg *config.Config, q *queries.Queries) {
+	store, err := filestore.NewStore(cfg.LogStore.Dir)
+	if err != nil {
+		slog.Error("failed to open log store", "error", err)
+		os.Exit(1)
+	}
+
+	svc := lifecycle.NewLocalService(q, store, cfg.Lifecycle.Interval)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	slog.Info("lifecycled running", "interval", cfg.Lifecycle.Interval, "backend", cfg.LogStore.Backend)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	slog.Info("shutting down lifecycled")
+}
@@ -48,7 +48,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	pub := bus.NewPublisher(js)
+	pub := bus.NewPublisher(nc, js)
 	dispatcher := notification.NewDispatcher(js, pub, q)
 
 	if err := dispatcher.Start(); err != nil {
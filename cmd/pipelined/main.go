@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/nats-io/nats.go/jetstream"
+
 	"github.com/felipemonteiro/mintlog/internal/bus"
 	"github.com/felipemonteiro/mintlog/internal/config"
+	"github.com/felipemonteiro/mintlog/internal/fields"
+	"github.com/felipemonteiro/mintlog/internal/lookup"
+	"github.com/felipemonteiro/mintlog/internal/metrics"
 	"github.com/felipemonteiro/mintlog/internal/pipeline"
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
 )
 
 func main() {
@@ -21,6 +30,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx := context.Background()
+
+	// Postgres
+	pool, err := postgres.NewPool(ctx, cfg.Postgres.DSN())
+	if err != nil {
+		slog.Error("postgres connect failed", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	q := queries.New(pool)
+
+	// NATS
 	nc, js, err := bus.Connect(cfg.NATS.URL)
 	if err != nil {
 		slog.Error("nats connect failed", "error", err)
@@ -28,15 +49,76 @@ func main() {
 	}
 	defer nc.Close()
 
+	jsx, err := jetstream.New(nc, jetstream.WithPublishAsyncMaxPending(cfg.Pipeline.MaxPending))
+	if err != nil {
+		slog.Error("jetstream init failed", "error", err)
+		os.Exit(1)
+	}
+
 	if err := bus.EnsureStreams(js); err != nil {
 		slog.Error("failed to ensure streams", "error", err)
 		os.Exit(1)
 	}
 
-	pub := bus.NewPublisher(js)
-	worker := pipeline.NewWorker(js, pub)
+	lookups := lookup.NewCache(q, cfg.Pipeline.LookupRefresh)
+	if err := lookups.Start(ctx); err != nil {
+		slog.Error("failed to load lookup tables", "error", err)
+		os.Exit(1)
+	}
+	defer lookups.Stop()
+
+	patterns := pipeline.NewPatternMiner(q, cfg.Pipeline.PatternMax, cfg.Pipeline.PatternFlush)
+	patterns.Start(ctx)
+	defer patterns.Stop()
+
+	pipelineRules := rules.NewCache(q, cfg.Pipeline.RulesRefresh)
+	if err := pipelineRules.Start(ctx); err != nil {
+		slog.Error("failed to load pipeline rules", "error", err)
+		os.Exit(1)
+	}
+	defer pipelineRules.Stop()
+
+	metricDefs := metrics.NewCache(q, cfg.Pipeline.MetricRefresh)
+	if err := metricDefs.Start(ctx); err != nil {
+		slog.Error("failed to load metrics", "error", err)
+		os.Exit(1)
+	}
+	defer metricDefs.Stop()
+
+	aggregator := metrics.NewAggregator(q, cfg.Pipeline.MetricFlush, cfg.Pipeline.MetricMax)
+	aggregator.Start(ctx)
+	defer aggregator.Stop()
+
+	registry := fields.NewRegistry(pool, cfg.Pipeline.FieldMax, cfg.Pipeline.FieldRefresh)
+	catalog := fields.NewCatalog(pool, cfg.Pipeline.CatalogFlush, cfg.Pipeline.FieldMax)
+	catalog.Start(ctx)
+	defer catalog.Stop()
+
+	deps := pipeline.Deps{
+		Lookups:  lookups,
+		Metrics:  pipeline.NewMetricRecorder(metricDefs, aggregator),
+		Rules:    pipelineRules,
+		Fields:   pipeline.NewFieldGuard(registry, catalog, cfg.Pipeline.FieldMaxDepth),
+		Patterns: patterns,
+	}
+	if cfg.Pipeline.UserAgentField != "" {
+		deps.UserAgent, err = pipeline.NewUserAgentParser(cfg.Pipeline.UserAgentField)
+		if err != nil {
+			slog.Error("failed to load user-agent parser", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	codec, err := bus.NewCodec(cfg.NATS.Encoding, cfg.NATS.CompressAbove)
+	if err != nil {
+		slog.Error("invalid event encoding", "error", err)
+		os.Exit(1)
+	}
+
+	pub := bus.NewPublisher(nc, js)
+	worker := pipeline.NewWorker(jsx, pub, pipeline.Standard(deps), codec, cfg.Pipeline.Workers, cfg.Pipeline.BatchSize, cfg.Pipeline.MaxPending)
 
-	if err := worker.Start(); err != nil {
+	if err := worker.Start(ctx); err != nil {
 		slog.Error("failed to start pipeline worker", "error", err)
 		os.Exit(1)
 	}
@@ -5,28 +5,36 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/felipemonteiro/mintlog/internal/bus"
-	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/metrics"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
 )
 
 type Evaluator struct {
-	queries  *queries.Queries
-	searcher *osstore.Searcher
-	pub      *bus.Publisher
-	cron     *cron.Cron
+	queries   *queries.Queries
+	logs      logstore.LogStore
+	pub       *bus.Publisher
+	cron      *cron.Cron
+	metricLag time.Duration
 }
 
-func NewEvaluator(q *queries.Queries, searcher *osstore.Searcher, pub *bus.Publisher) *Evaluator {
+// NewEvaluator returns an evaluator for alert rules. metricLag is how long
+// after a minute ends its metric rollups may still be unflushed, which is
+// pipelined's metric flush interval.
+func NewEvaluator(q *queries.Queries, logs logstore.LogStore, pub *bus.Publisher, metricLag time.Duration) *Evaluator {
 	return &Evaluator{
-		queries:  q,
-		searcher: searcher,
-		pub:      pub,
-		cron:     cron.New(cron.WithSeconds()),
+		queries:   q,
+		logs:      logs,
+		pub:       pub,
+		cron:      cron.New(cron.WithSeconds()),
+		metricLag: metricLag,
 	}
 }
 
@@ -68,47 +76,25 @@ func (e *Evaluator) evaluateRule(ctx context.Context, rule queries.AlertRule) {
 		return
 	}
 
-	// Build count query over the window period
 	now := time.Now().UTC()
 	windowStart := now.Add(-time.Duration(rule.WindowSeconds) * time.Second)
 
-	must := []map[string]any{
-		{"term": map[string]any{"tenant_id": rule.TenantID.String()}},
-		{"range": map[string]any{
-			"timestamp": map[string]any{
-				"gte": windowStart.Format(time.RFC3339Nano),
-				"lte": now.Format(time.RFC3339Nano),
-			},
-		}},
+	var value float64
+	var err error
+	if _, ok := queryFilter["metric"]; ok {
+		value, err = e.metricValue(ctx, rule, queryFilter, now)
+	} else {
+		var n int32
+		n, err = e.logCount(ctx, rule, queryFilter, windowStart, now)
+		value = float64(n)
 	}
-
-	for field, value := range queryFilter {
-		if field == "query" || field == "message" {
-			must = append(must, map[string]any{
-				"match": map[string]any{"message": value},
-			})
-		} else {
-			must = append(must, map[string]any{
-				"term": map[string]any{field: value},
-			})
-		}
-	}
-
-	osQuery := map[string]any{
-		"query": map[string]any{
-			"bool": map[string]any{"must": must},
-		},
-		"size": 0,
-	}
-
-	indices := []string{fmt.Sprintf("mintlog-%s-*", rule.TenantID.String())}
-	result, err := e.searcher.Search(ctx, indices, osQuery)
 	if err != nil {
-		slog.Error("evaluator: search failed", "rule_id", rule.ID, "error", err)
+		slog.Error("evaluator: query failed", "rule_id", rule.ID, "error", err)
 		return
 	}
-
-	count := int32(result.Total)
+	// The threshold is compared with the exact value; the value recorded in
+	// the state and events is rounded.
+	count := int32(math.Round(value))
 
 	// Get current state
 	currentState := StateOK
@@ -117,7 +103,7 @@ func (e *Evaluator) evaluateRule(ctx context.Context, rule queries.AlertRule) {
 		currentState = existingState.State
 	}
 
-	exceeded := count >= rule.Threshold
+	exceeded := value >= float64(rule.Threshold)
 	newState := NextState(currentState, exceeded)
 
 	// Update state
@@ -150,3 +136,46 @@ func (e *Evaluator) evaluateRule(ctx context.Context, rule queries.AlertRule) {
 		}
 	}
 }
+
+// logCount counts the logs matching queryFilter in the window.
+func (e *Evaluator) logCount(ctx context.Context, rule queries.AlertRule, queryFilter map[string]string, windowStart, now time.Time) (int32, error) {
+	q := &logstore.Query{
+		TenantID: rule.TenantID.String(),
+		AnyWord:  true,
+		Fields:   make(map[string]string, len(queryFilter)),
+		From:     windowStart,
+		To:       now,
+	}
+	for field, value := range queryFilter {
+		if field == "query" || field == "message" {
+			q.Text = strings.TrimSpace(q.Text + " " + value)
+		} else {
+			q.Fields[field] = value
+		}
+	}
+
+	count, err := e.logs.Count(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return int32(count), nil
+}
+
+// metricValue evaluates a log-derived metric over the rule's window. The
+// query's "metric" key names the metric, "stat" picks the statistic (count
+// by default) and every other key is a label filter. The window is whole
+// minutes, rounded up, ending with the last minute whose rollups have been
+// flushed, so it never includes a minute still being recorded.
+func (e *Evaluator) metricValue(ctx context.Context, rule queries.AlertRule, queryFilter map[string]string, now time.Time) (float64, error) {
+	end := now.Add(-e.metricLag).Truncate(time.Minute)
+	minutes := (time.Duration(rule.WindowSeconds)*time.Second + time.Minute - 1) / time.Minute
+	start := end.Add(-minutes * time.Minute)
+
+	labels := make(map[string]string, len(queryFilter))
+	for k, v := range queryFilter {
+		if k != "metric" && k != "stat" {
+			labels[k] = v
+		}
+	}
+	return metrics.Evaluate(ctx, e.queries, rule.TenantID, queryFilter["metric"], queryFilter["stat"], labels, start, end)
+}
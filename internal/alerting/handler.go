@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/felipemonteiro/mintlog/internal/metrics"
 	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
 	"github.com/felipemonteiro/mintlog/internal/tenant"
 	"github.com/felipemonteiro/mintlog/pkg/apierror"
@@ -49,6 +50,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.Query == nil {
 		req.Query = json.RawMessage(`{}`)
 	}
+	if msg := validateQuery(req.Query); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
 
 	rule, err := h.queries.CreateAlertRule(r.Context(), info.ID, req.Name, []byte(req.Query), req.Threshold, req.WindowSeconds, req.EvalInterval)
 	if err != nil {
@@ -135,6 +140,10 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.Query == nil {
 		req.Query = json.RawMessage(`{}`)
 	}
+	if msg := validateQuery(req.Query); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
 
 	rule, err := h.queries.UpdateAlertRule(r.Context(), id, info.ID, req.Name, []byte(req.Query), req.Threshold, req.WindowSeconds, req.EvalInterval, isActive)
 	if err != nil {
@@ -181,3 +190,23 @@ func toAlertRuleResponse(r queries.AlertRule) AlertRuleResponse {
 		UpdatedAt:     r.UpdatedAt,
 	}
 }
+
+// validateQuery checks a rule's query as the evaluator reads it: string
+// values by key, with a known stat for metric rules. It returns an error
+// message, or "" if valid.
+func validateQuery(raw json.RawMessage) string {
+	var query map[string]string
+	if err := json.Unmarshal(raw, &query); err != nil {
+		return "query must be an object of string values"
+	}
+	if _, ok := query["metric"]; !ok {
+		return ""
+	}
+	if query["metric"] == "" {
+		return "query.metric must not be empty"
+	}
+	if !metrics.ValidStat(query["stat"]) {
+		return "query.stat must be one of: count, sum, avg, min, max"
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// stageName identifies the archiver in dead-letter headers.
+const stageName = "archiver"
+
+const (
+	archiverStream   = "LOGS_PARSED"
+	archiverConsumer = "archiver"
+	fetchBatch       = 500
+	fetchWait        = time.Second
+	writeTimeout     = 2 * time.Minute
+	retryDelay       = 30 * time.Second
+)
+
+// Archiver consumes logs.parsed and writes every event to the archive,
+// grouped into one object per tenant and hour. Objects are written every
+// flushEvery, when maxEvents events are buffered in total, or when one
+// reaches maxBytes compressed. Events are acked only once their object and
+// manifest are stored; failed writes are retried without limit.
+type Archiver struct {
+	js         jetstream.JetStream
+	pub        *bus.Publisher
+	writer     *Writer
+	flushEvery time.Duration
+	maxEvents  int
+	maxBytes   int
+
+	open     map[string]*pending
+	buffered int
+	oldest   time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// pending is an object being filled and the messages it holds.
+type pending struct {
+	obj  *Object
+	msgs []jetstream.Msg
+}
+
+func NewArchiver(js jetstream.JetStream, pub *bus.Publisher, writer *Writer, flushEvery time.Duration, maxEvents, maxBytes int) *Archiver {
+	return &Archiver{
+		js:         js,
+		pub:        pub,
+		writer:     writer,
+		flushEvery: flushEvery,
+		maxEvents:  maxEvents,
+		maxBytes:   maxBytes,
+		open:       make(map[string]*pending),
+	}
+}
+
+func (a *Archiver) Start(ctx context.Context) error {
+	cons, err := a.js.CreateOrUpdateConsumer(ctx, archiverStream, jetstream.ConsumerConfig{
+		Durable:       archiverConsumer,
+		FilterSubject: "logs.parsed.>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       a.flushEvery + 2*writeTimeout,
+		MaxAckPending: a.maxEvents + fetchBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer %s: %w", archiverConsumer, err)
+	}
+
+	ctx, a.cancel = context.WithCancel(ctx)
+	a.done = make(chan struct{})
+	go a.run(ctx, cons)
+
+	slog.Info("archiver started", "subject", "logs.parsed.>", "flush_every", a.flushEvery)
+	return nil
+}
+
+// Stop writes everything buffered, then returns.
+func (a *Archiver) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	<-a.done
+}
+
+func (a *Archiver) run(ctx context.Context, cons jetstream.Consumer) {
+	defer close(a.done)
+	for ctx.Err() == nil {
+		batch, err := cons.Fetch(fetchBatch, jetstream.FetchMaxWait(fetchWait))
+		if err != nil {
+			slog.Error("archiver: fetch failed", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(fetchWait):
+			}
+			continue
+		}
+		for msg := range batch.Messages() {
+			a.add(msg)
+		}
+		if err := batch.Error(); err != nil {
+			slog.Warn("archiver: fetch ended early", "error", err)
+		}
+
+		if a.buffered >= a.maxEvents || (a.buffered > 0 && time.Since(a.oldest) >= a.flushEvery) {
+			a.flushAll()
+		}
+	}
+	a.flushAll()
+}
+
+func (a *Archiver) add(msg jetstream.Msg) {
+	var event logmodel.LogEvent
+	if err := bus.DecodeEvent(msg.Headers(), msg.Data(), &event); err != nil {
+		a.pub.RejectJS(msg, stageName, fmt.Errorf("decode: %w", err))
+		return
+	}
+
+	hour := event.Timestamp.UTC().Truncate(time.Hour)
+	key := Partition(event.TenantID, hour)
+	p, ok := a.open[key]
+	if !ok {
+		name := fmt.Sprintf("%d-%s", time.Now().UnixNano(), uuid.NewString()[:8])
+		p = &pending{obj: NewObject(event.TenantID, hour, name)}
+		a.open[key] = p
+	}
+	if err := p.obj.AddEvent(&event); err != nil {
+		a.pub.RejectJS(msg, stageName, err)
+		return
+	}
+	p.msgs = append(p.msgs, msg)
+	if a.buffered == 0 {
+		a.oldest = time.Now()
+	}
+	a.buffered++
+
+	if p.obj.Size() >= a.maxBytes {
+		a.flush(key, p)
+	}
+}
+
+func (a *Archiver) flushAll() {
+	for key, p := range a.open {
+		a.flush(key, p)
+	}
+	a.buffered = 0
+}
+
+// flush writes one object and acks its messages, or NAKs them for a later
+// retry. The object is dropped either way; redelivered events start a new
+// one.
+func (a *Archiver) flush(key string, p *pending) {
+	delete(a.open, key)
+	a.buffered -= len(p.msgs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	m, err := a.writer.Write(ctx, p.obj)
+	cancel()
+	if err != nil {
+		slog.Error("archiver: failed to write object", "key", p.obj.Key(), "count", len(p.msgs), "error", err)
+		for _, msg := range p.msgs {
+			msg.NakWithDelay(retryDelay)
+		}
+		return
+	}
+	for _, msg := range p.msgs {
+		msg.Ack()
+	}
+	slog.Debug("archive object written", "key", m.Key, "count", m.Count, "bytes", m.Bytes)
+}
@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+)
+
+const (
+	// bloomFPRate is the false-positive rate filters are sized for.
+	bloomFPRate = 0.01
+
+	// maxBloomKeys bounds the distinct keys collected for one filter; past
+	// it the filter is left out of the manifest and cannot prune.
+	maxBloomKeys = 1 << 18
+)
+
+// Bloom is a Bloom filter stored in a manifest. A key it does not contain
+// is certainly absent from the object; a key it contains is present with
+// high probability.
+type Bloom struct {
+	K    int    `json:"k"`
+	Bits []byte `json:"bits"` // base64 in JSON
+}
+
+// newBloom builds a filter holding the given key hashes.
+func newBloom(hashes map[uint64]struct{}) *Bloom {
+	n := float64(max(len(hashes), 1))
+	m := int(math.Ceil(-n * math.Log(bloomFPRate) / (math.Ln2 * math.Ln2)))
+	m = max((m+7)/8*8, 64)
+	b := &Bloom{
+		K:    max(int(math.Round(float64(m)/n*math.Ln2)), 1),
+		Bits: make([]byte, m/8),
+	}
+	for h := range hashes {
+		b.add(h)
+	}
+	return b
+}
+
+func (b *Bloom) add(h uint64) {
+	m := uint64(len(b.Bits)) * 8
+	h1, h2 := h, h>>33|h<<31
+	for i := range uint64(b.K) {
+		bit := (h1 + i*h2) % m
+		b.Bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Test reports whether key may have been added. A nil filter may hold
+// anything.
+func (b *Bloom) Test(key string) bool {
+	if b == nil || len(b.Bits) == 0 {
+		return true
+	}
+	m := uint64(len(b.Bits)) * 8
+	h := bloomHash(key)
+	h1, h2 := h, h>>33|h<<31
+	for i := range uint64(b.K) {
+		bit := (h1 + i*h2) % m
+		if b.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAny reports whether any of keys may have been added.
+func (b *Bloom) TestAny(keys []string) bool {
+	for _, k := range keys {
+		if b.Test(k) {
+			return true
+		}
+	}
+	return len(keys) == 0
+}
+
+// TestAll reports whether every key may have been added.
+func (b *Bloom) TestAll(keys []string) bool {
+	for _, k := range keys {
+		if !b.Test(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// keySet collects the distinct key hashes of one filter.
+type keySet struct {
+	hashes   map[uint64]struct{}
+	overflow bool
+}
+
+func newKeySet() *keySet {
+	return &keySet{hashes: make(map[uint64]struct{})}
+}
+
+func (s *keySet) add(key string) {
+	if key == "" || s.overflow {
+		return
+	}
+	s.hashes[bloomHash(key)] = struct{}{}
+	if len(s.hashes) > maxBloomKeys {
+		s.overflow = true
+		s.hashes = nil
+	}
+}
+
+// bloom returns the filter, or nil if too many keys were added.
+func (s *keySet) bloom() *Bloom {
+	if s.overflow {
+		return nil
+	}
+	return newBloom(s.hashes)
+}
+
+// Terms splits text into the words indexed in a manifest's term filter,
+// as logstore.Terms does, so the filter agrees with how queries match.
+func Terms(text string) []string {
+	return logstore.Terms(text)
+}
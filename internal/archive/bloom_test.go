@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestBloomSizing(t *testing.T) {
+	tests := []struct {
+		keys     int
+		wantBits int
+		wantK    int
+	}{
+		{0, 64, 44},
+		{1, 64, 44},
+		{1000, 9592, 7},
+		{100000, 958512, 7},
+	}
+	for _, tt := range tests {
+		s := newKeySet()
+		for i := range tt.keys {
+			s.add(fmt.Sprintf("key-%d", i))
+		}
+		b := s.bloom()
+		if bits := len(b.Bits) * 8; bits != tt.wantBits || b.K != tt.wantK {
+			t.Errorf("%d keys: %d bits, k %d; want %d bits, k %d", tt.keys, bits, b.K, tt.wantBits, tt.wantK)
+		}
+	}
+}
+
+func TestBloomNoFalseNegatives(t *testing.T) {
+	const n = 20000
+	s := newKeySet()
+	for i := range n {
+		s.add(fmt.Sprintf("host-%d", i))
+	}
+	b := roundTrip(t, s.bloom())
+
+	for i := range n {
+		if key := fmt.Sprintf("host-%d", i); !b.Test(key) {
+			t.Fatalf("added key %q not found", key)
+		}
+	}
+
+	falsePositives := 0
+	for i := range n {
+		if b.Test(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 2*bloomFPRate {
+		t.Errorf("false positive rate %.4f, sized for %.2f", rate, bloomFPRate)
+	}
+}
+
+func TestBloomTooManyKeys(t *testing.T) {
+	s := newKeySet()
+	for i := range maxBloomKeys + 1 {
+		s.add(fmt.Sprintf("trace-%d", i))
+	}
+	b := s.bloom()
+	if b != nil {
+		t.Fatalf("got a filter of %d bytes past %d keys", len(b.Bits), maxBloomKeys)
+	}
+	// A missing filter prunes nothing.
+	if !b.Test("anything") || !b.TestAny([]string{"a", "b"}) || !b.TestAll([]string{"a", "b"}) {
+		t.Error("nil filter excluded a key")
+	}
+}
+
+func TestBloomTestAnyAll(t *testing.T) {
+	s := newKeySet()
+	s.add("a")
+	s.add("b")
+	b := s.bloom()
+
+	if !b.TestAny([]string{"missing", "a"}) {
+		t.Error("TestAny missed a present key")
+	}
+	if !b.TestAll([]string{"a", "b"}) {
+		t.Error("TestAll missed present keys")
+	}
+	if !b.TestAny(nil) || !b.TestAll(nil) {
+		t.Error("no keys should not exclude the object")
+	}
+}
+
+// roundTrip returns b as read back from a stored manifest.
+func roundTrip(t *testing.T, b *Bloom) *Bloom {
+	t.Helper()
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Bloom
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.K != b.K || len(out.Bits) != len(b.Bits) {
+		t.Fatalf("round trip changed the filter: k %d -> %d, %d -> %d bytes", b.K, out.K, len(b.Bits), len(out.Bits))
+	}
+	return &out
+}
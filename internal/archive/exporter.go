@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+)
+
+const exportPageSize = 1000
+
+// Exporter copies an index into the archive before it is deleted, in the
+// same layout as the Archiver. Object names derive from the index and the
+// order of its documents, so exporting again replaces earlier objects.
+type Exporter struct {
+	client   *opensearchapi.Client
+	writer   *Writer
+	maxBytes int
+}
+
+// NewExporter writes objects of at most about maxBytes compressed bytes.
+func NewExporter(client *opensearchapi.Client, writer *Writer, maxBytes int) *Exporter {
+	return &Exporter{client: client, writer: writer, maxBytes: maxBytes}
+}
+
+// Export archives every document of index and returns how many were
+// written.
+func (e *Exporter) Export(ctx context.Context, tenantID, index string) (int, error) {
+	var cur *Object
+	var curHour time.Time
+	seq := 0
+	written := 0
+
+	flush := func() error {
+		if cur == nil || cur.Count() == 0 {
+			return nil
+		}
+		m, err := e.writer.Write(ctx, cur)
+		if err != nil {
+			return err
+		}
+		written += int(m.Count)
+		cur = nil
+		return nil
+	}
+
+	_, err := osstore.Export(ctx, e.client, index, exportPageSize, func(docs []json.RawMessage) error {
+		for _, doc := range docs {
+			var head struct {
+				Timestamp time.Time `json:"timestamp"`
+			}
+			if err := json.Unmarshal(doc, &head); err != nil {
+				return fmt.Errorf("decode document: %w", err)
+			}
+			hour := head.Timestamp.UTC().Truncate(time.Hour)
+			if cur != nil && (!hour.Equal(curHour) || cur.Size() >= e.maxBytes) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if cur == nil {
+				if !hour.Equal(curHour) {
+					seq = 0
+				}
+				curHour = hour
+				cur = NewObject(tenantID, hour, fmt.Sprintf("%s-%04d", index, seq))
+				seq++
+			}
+			if err := cur.AddDoc(doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return written, fmt.Errorf("export %s: %w", index, err)
+	}
+	return written, nil
+}
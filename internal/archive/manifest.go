@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+)
+
+// ManifestVersion is the manifest format written by this package. Version 2
+// added the Bloom filters; version 1 manifests are still read.
+const ManifestVersion = 2
+
+const (
+	dataSuffix     = ".ndjson.gz"
+	manifestSuffix = ".manifest.json"
+
+	// maxManifestServices bounds the services listed in a manifest; beyond
+	// it ServicesTruncated is set and the list cannot be used for pruning.
+	maxManifestServices = 256
+)
+
+// Manifest describes one archive object. It is stored next to the object
+// as <object key without .ndjson.gz>.manifest.json.
+type Manifest struct {
+	Version           int              `json:"version"`
+	Key               string           `json:"key"`
+	TenantID          string           `json:"tenant_id"`
+	Hour              time.Time        `json:"hour"`
+	Format            string           `json:"format"`      // "ndjson"
+	Compression       string           `json:"compression"` // "gzip"
+	Count             int64            `json:"count"`
+	MinTimestamp      time.Time        `json:"min_timestamp"`
+	MaxTimestamp      time.Time        `json:"max_timestamp"`
+	Bytes             int64            `json:"bytes"`
+	UncompressedBytes int64            `json:"uncompressed_bytes"`
+	SHA256            string           `json:"sha256"` // of the stored (compressed) object
+	Levels            map[string]int64 `json:"levels"`
+	Services          []string         `json:"services"`
+	ServicesTruncated bool             `json:"services_truncated,omitempty"`
+	Hosts             *Bloom           `json:"hosts,omitempty"`     // nil when absent or too large
+	TraceIDs          *Bloom           `json:"trace_ids,omitempty"` // as stored, not normalized
+	Terms             *Bloom           `json:"terms,omitempty"`     // message words, see Terms
+	CreatedAt         time.Time        `json:"created_at"`
+}
+
+// Partition returns the key prefix holding a tenant's events for the hour
+// containing t: <tenant>/YYYY/MM/DD/HH.
+func Partition(tenantID string, t time.Time) string {
+	return path.Join(tenantID, t.UTC().Format("2006/01/02/15"))
+}
+
+// DataKey returns the key of an object named name in a partition.
+func DataKey(partition, name string) string {
+	return path.Join(partition, name+dataSuffix)
+}
+
+// ManifestKey returns the manifest key for a data object key.
+func ManifestKey(dataKey string) string {
+	return strings.TrimSuffix(dataKey, dataSuffix) + manifestSuffix
+}
+
+// Filter is what an archive search asks of an object. Empty fields match
+// anything.
+type Filter struct {
+	Level    string
+	Service  string
+	Host     string
+	TraceIDs []string // any of them
+	Terms    []string // all of them, in the message
+}
+
+// MayContain reports whether the object can hold events matching f, judging
+// by its manifest. A false result is certain; a true one is not.
+func (m *Manifest) MayContain(f Filter) bool {
+	if f.Level != "" && m.Levels[f.Level] == 0 {
+		return false
+	}
+	if f.Service != "" && !m.ServicesTruncated && !slices.Contains(m.Services, f.Service) {
+		return false
+	}
+	if f.Host != "" && !m.Hosts.Test(f.Host) {
+		return false
+	}
+	if len(f.TraceIDs) > 0 && !m.TraceIDs.TestAny(f.TraceIDs) {
+		return false
+	}
+	return m.Terms.TestAll(f.Terms)
+}
+
+// MayMatch reports whether the object can hold events matching a rule's
+// match conditions.
+func (m *Manifest) MayMatch(match *rules.Match) bool {
+	return m.MayContain(Filter{Level: match.Level, Service: match.Service, Host: match.Host})
+}
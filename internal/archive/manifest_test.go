@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// testEvents returns n events spread over a few levels and services, each
+// with its own host, trace ID and message words.
+func testEvents(n int, hour time.Time) []*logmodel.LogEvent {
+	levels := []string{"info", "warn", "error"}
+	services := []string{"api", "checkout", "worker"}
+	events := make([]*logmodel.LogEvent, n)
+	for i := range events {
+		events[i] = &logmodel.LogEvent{
+			ID:        fmt.Sprintf("e%d", i),
+			TenantID:  "t1",
+			Timestamp: hour.Add(time.Duration(i) * time.Second),
+			Level:     levels[i%len(levels)],
+			Service:   services[i%len(services)],
+			Host:      fmt.Sprintf("node-%d", i),
+			TraceID:   fmt.Sprintf("%032x", i),
+			Message:   fmt.Sprintf("order%d shipped to warehouse%d", i, i%7),
+		}
+	}
+	return events
+}
+
+// storedManifest builds an object from events and returns its manifest as
+// read back from the store.
+func storedManifest(t *testing.T, hour time.Time, events []*logmodel.LogEvent) *Manifest {
+	t.Helper()
+	o := NewObject("t1", hour, "obj")
+	for _, e := range events {
+		if err := o.AddEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, m, err := o.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Manifest
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
+
+func TestManifestSummary(t *testing.T) {
+	hour := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	events := testEvents(300, hour)
+	m := storedManifest(t, hour.Add(30*time.Minute), events)
+
+	if m.Key != "t1/2026/03/14/09/obj.ndjson.gz" || ManifestKey(m.Key) != "t1/2026/03/14/09/obj.manifest.json" {
+		t.Errorf("key = %q", m.Key)
+	}
+	if !m.Hour.Equal(hour) || m.Version != ManifestVersion || m.Format != "ndjson" {
+		t.Errorf("hour %v, version %d, format %q", m.Hour, m.Version, m.Format)
+	}
+	if m.Count != 300 || !m.MinTimestamp.Equal(hour) || !m.MaxTimestamp.Equal(hour.Add(299*time.Second)) {
+		t.Errorf("count %d, range %v - %v", m.Count, m.MinTimestamp, m.MaxTimestamp)
+	}
+	if m.Levels["info"] != 100 || m.Levels["warn"] != 100 || m.Levels["error"] != 100 {
+		t.Errorf("levels = %v", m.Levels)
+	}
+	if fmt.Sprint(m.Services) != "[api checkout worker]" {
+		t.Errorf("services = %v", m.Services)
+	}
+	if m.Hosts == nil || m.TraceIDs == nil || m.Terms == nil {
+		t.Error("filters missing")
+	}
+}
+
+func TestMayContainNoFalseNegatives(t *testing.T) {
+	hour := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	events := testEvents(5000, hour)
+	m := storedManifest(t, hour, events)
+
+	for _, e := range events {
+		f := Filter{
+			Level:    e.Level,
+			Service:  e.Service,
+			Host:     e.Host,
+			TraceIDs: []string{"absent", e.TraceID},
+			Terms:    Terms(e.Message),
+		}
+		if !m.MayContain(f) {
+			t.Fatalf("manifest excludes event %s: %+v", e.ID, f)
+		}
+		if !m.MayMatch(&rules.Match{Level: e.Level, Service: e.Service, Host: e.Host}) {
+			t.Fatalf("manifest excludes event %s by rule match", e.ID)
+		}
+	}
+}
+
+func TestMayContainPrunes(t *testing.T) {
+	hour := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	m := storedManifest(t, hour, testEvents(10, hour))
+
+	tests := []struct {
+		name string
+		f    Filter
+		want bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"absent level", Filter{Level: "debug"}, false},
+		{"absent service", Filter{Service: "billing"}, false},
+		{"present word", Filter{Terms: []string{"shipped"}}, true},
+		{"one word absent", Filter{Terms: []string{"shipped", "cancelled"}}, false},
+	}
+	for _, tt := range tests {
+		if got := m.MayContain(tt.f); got != tt.want {
+			t.Errorf("%s: MayContain = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Without service list or filters, as in version 1 manifests, only the
+	// level can prune.
+	old := &Manifest{Version: 1, Levels: m.Levels, Services: m.Services, ServicesTruncated: true}
+	if !old.MayContain(Filter{Service: "billing", Host: "x", TraceIDs: []string{"y"}, Terms: []string{"z"}}) {
+		t.Error("manifest without filters excluded an object")
+	}
+}
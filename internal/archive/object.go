@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Object accumulates the events of one archive object: gzipped NDJSON for
+// one tenant and hour, held in memory until written.
+type Object struct {
+	key      string
+	buf      bytes.Buffer
+	zw       *gzip.Writer
+	manifest Manifest
+	services map[string]bool
+	hosts    *keySet
+	traceIDs *keySet
+	terms    *keySet
+}
+
+// summary holds the fields of an event its manifest describes.
+type summary struct {
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	Service   string    `json:"service"`
+	Host      string    `json:"host"`
+	TraceID   string    `json:"trace_id"`
+	Message   string    `json:"message"`
+}
+
+// NewObject starts an object named name in the tenant's partition for hour.
+func NewObject(tenantID string, hour time.Time, name string) *Object {
+	hour = hour.UTC().Truncate(time.Hour)
+	o := &Object{
+		key: DataKey(Partition(tenantID, hour), name),
+		manifest: Manifest{
+			Version:     ManifestVersion,
+			TenantID:    tenantID,
+			Hour:        hour,
+			Format:      "ndjson",
+			Compression: "gzip",
+			Levels:      make(map[string]int64),
+		},
+		services: make(map[string]bool),
+		hosts:    newKeySet(),
+		traceIDs: newKeySet(),
+		terms:    newKeySet(),
+	}
+	o.manifest.Key = o.key
+	o.zw = gzip.NewWriter(&o.buf)
+	return o
+}
+
+// AddEvent appends an event.
+func (o *Object) AddEvent(e *logmodel.LogEvent) error {
+	doc, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return o.add(doc, summary{
+		Timestamp: e.Timestamp,
+		Level:     e.Level,
+		Service:   e.Service,
+		Host:      e.Host,
+		TraceID:   e.TraceID,
+		Message:   e.Message,
+	})
+}
+
+// AddDoc appends an event already encoded as JSON, such as an indexed
+// document.
+func (o *Object) AddDoc(doc json.RawMessage) error {
+	var e summary
+	if err := json.Unmarshal(doc, &e); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	return o.add(doc, e)
+}
+
+func (o *Object) add(doc []byte, e summary) error {
+	if _, err := o.zw.Write(doc); err != nil {
+		return err
+	}
+	if _, err := o.zw.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+
+	m := &o.manifest
+	ts := e.Timestamp.UTC()
+	if m.Count == 0 || ts.Before(m.MinTimestamp) {
+		m.MinTimestamp = ts
+	}
+	if m.Count == 0 || ts.After(m.MaxTimestamp) {
+		m.MaxTimestamp = ts
+	}
+	m.Count++
+	m.UncompressedBytes += int64(len(doc)) + 1
+	m.Levels[e.Level]++
+	if !o.services[e.Service] {
+		if len(o.services) < maxManifestServices {
+			o.services[e.Service] = true
+		} else {
+			m.ServicesTruncated = true
+		}
+	}
+	o.hosts.add(e.Host)
+	o.traceIDs.add(e.TraceID)
+	for _, t := range Terms(e.Message) {
+		o.terms.add(t)
+	}
+	return nil
+}
+
+// Key returns the data object key.
+func (o *Object) Key() string { return o.key }
+
+// Count returns the number of events added.
+func (o *Object) Count() int64 { return o.manifest.Count }
+
+// Size returns the compressed size so far.
+func (o *Object) Size() int { return o.buf.Len() }
+
+// finish closes the stream and completes the manifest.
+func (o *Object) finish() ([]byte, *Manifest, error) {
+	if err := o.zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("gzip: %w", err)
+	}
+	m := o.manifest
+	m.Bytes = int64(o.buf.Len())
+	m.Services = make([]string, 0, len(o.services))
+	for s := range o.services {
+		m.Services = append(m.Services, s)
+	}
+	sort.Strings(m.Services)
+	m.Hosts = o.hosts.bloom()
+	m.TraceIDs = o.traceIDs.bloom()
+	m.Terms = o.terms.bloom()
+	m.CreatedAt = time.Now().UTC()
+	return o.buf.Bytes(), &m, nil
+}
@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// maxLine bounds one archived event.
+const maxLine = 16 << 20
+
+// Reader finds and reads archive objects.
+type Reader struct {
+	store  *minio.Client
+	bucket string
+}
+
+func NewReader(store *minio.Client, bucket string) *Reader {
+	return &Reader{store: store, bucket: bucket}
+}
+
+// Manifests returns the manifests of a tenant's objects that may hold
+// events between from and to (inclusive), oldest hour first. Objects
+// without a manifest are incomplete and skipped.
+func (r *Reader) Manifests(ctx context.Context, tenantID string, from, to time.Time) ([]*Manifest, error) {
+	from, to = from.UTC(), to.UTC()
+	var manifests []*Manifest
+	for day := from.Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		objects, err := r.store.List(ctx, r.bucket, path.Join(tenantID, day.Format("2006/01/02"))+"/")
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if !strings.HasSuffix(obj.Key, manifestSuffix) {
+				continue
+			}
+			hour, ok := keyHour(obj.Key)
+			if !ok || hour.Add(time.Hour).Before(from) || hour.After(to) {
+				continue
+			}
+			m, err := r.manifest(ctx, obj.Key)
+			if err != nil {
+				return nil, err
+			}
+			if m.MaxTimestamp.Before(from) || m.MinTimestamp.After(to) {
+				continue
+			}
+			manifests = append(manifests, m)
+		}
+	}
+	if manifests == nil {
+		manifests = []*Manifest{}
+	}
+	return manifests, nil
+}
+
+func (r *Reader) manifest(ctx context.Context, key string) (*Manifest, error) {
+	body, err := r.store.Get(ctx, r.bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var m Manifest
+	if err := json.NewDecoder(body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", key, err)
+	}
+	return &m, nil
+}
+
+// Events calls fn with each event of an object and its JSON line, then
+// checks the object against the manifest's checksum.
+func (r *Reader) Events(ctx context.Context, m *Manifest, fn func(e *logmodel.LogEvent, line []byte) error) error {
+	body, err := r.store.Get(ctx, r.bucket, m.Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	tee := io.TeeReader(body, hash)
+	zr, err := gzip.NewReader(tee)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", m.Key, err)
+	}
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64<<10), maxLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var e logmodel.LogEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("read %s: %w", m.Key, err)
+		}
+		if err := fn(&e, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", m.Key, err)
+	}
+
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return fmt.Errorf("read %s: %w", m.Key, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != m.SHA256 {
+		return fmt.Errorf("%s: checksum mismatch (manifest %s, object %s)", m.Key, m.SHA256, sum)
+	}
+	return nil
+}
+
+// keyHour returns the partition hour of a key <tenant>/YYYY/MM/DD/HH/<name>.
+func keyHour(key string) (time.Time, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 6 {
+		return time.Time{}, false
+	}
+	hour, err := time.Parse("2006/01/02/15", strings.Join(parts[1:5], "/"))
+	return hour, err == nil
+}
@@ -0,0 +1,65 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
+)
+
+// Writer stores objects and their manifests in a bucket.
+type Writer struct {
+	store  *minio.Client
+	bucket string
+
+	mu          sync.Mutex
+	bucketReady bool
+}
+
+func NewWriter(store *minio.Client, bucket string) *Writer {
+	return &Writer{store: store, bucket: bucket}
+}
+
+// Write uploads o, then its manifest. An object without a manifest is
+// incomplete and is ignored by readers; writing it again replaces both.
+func (w *Writer) Write(ctx context.Context, o *Object) (*Manifest, error) {
+	if err := w.ensureBucket(ctx); err != nil {
+		return nil, err
+	}
+
+	data, m, err := o.finish()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	m.SHA256 = hex.EncodeToString(sum[:])
+
+	if err := w.store.Put(ctx, w.bucket, m.Key, data, "application/x-ndjson", "gzip"); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := w.store.Put(ctx, w.bucket, ManifestKey(m.Key), encoded, "application/json", ""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (w *Writer) ensureBucket(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.bucketReady {
+		return nil
+	}
+	if err := w.store.EnsureBucket(ctx, w.bucket); err != nil {
+		return err
+	}
+	w.bucketReady = true
+	return nil
+}
@@ -9,6 +9,21 @@ const (
 	ScopeIncidentWrite = "incidents:write"
 	ScopeNotifRead  = "notifications:read"
 	ScopeNotifWrite = "notifications:write"
+	ScopeLookupRead  = "lookups:read"
+	ScopeLookupWrite = "lookups:write"
+	ScopeDLQRead     = "dlq:read"
+	ScopeDLQWrite    = "dlq:write"
+	ScopePipelineRead  = "pipeline:read"
+	ScopePipelineWrite = "pipeline:write"
+	ScopeFieldRead    = "fields:read"
+	ScopeFieldWrite   = "fields:write"
+	ScopeMetricRead   = "metrics:read"
+	ScopeMetricWrite  = "metrics:write"
+	ScopeEgressRead   = "egress:read"
+	ScopeEgressWrite  = "egress:write"
+	ScopeStorageRead  = "storage:read"
+	ScopeArchiveRead  = "archive:read"
+	ScopeArchiveWrite = "archive:write"
 	ScopeAdmin      = "admin"
 )
 
@@ -17,6 +32,14 @@ var AllScopes = []string{
 	ScopeAlertRead, ScopeAlertWrite,
 	ScopeIncidentRead, ScopeIncidentWrite,
 	ScopeNotifRead, ScopeNotifWrite,
+	ScopeLookupRead, ScopeLookupWrite,
+	ScopeDLQRead, ScopeDLQWrite,
+	ScopePipelineRead, ScopePipelineWrite,
+	ScopeFieldRead, ScopeFieldWrite,
+	ScopeMetricRead, ScopeMetricWrite,
+	ScopeEgressRead, ScopeEgressWrite,
+	ScopeStorageRead,
+	ScopeArchiveRead, ScopeArchiveWrite,
 	ScopeAdmin,
 }
 
@@ -0,0 +1,119 @@
+package bus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/nats-io/nats.go"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Headers describing how an event payload is encoded. A message without
+// Content-Type is JSON, as published before encodings were introduced.
+const (
+	HeaderContentType     = "Content-Type"
+	HeaderContentEncoding = "Content-Encoding"
+)
+
+// Event content types and encodings.
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgpack = "application/vnd.mintlog.event.v1+msgpack"
+	EncodingS2         = "s2"
+)
+
+// Encodings accepted by NewCodec.
+const (
+	FormatJSON    = "json"
+	FormatMsgpack = "msgpack"
+)
+
+// Codec encodes log events for the bus. Payloads larger than compressAbove
+// bytes are compressed with S2.
+type Codec struct {
+	contentType   string
+	compressAbove int
+}
+
+// NewCodec returns a codec for format ("json" or "msgpack"). A zero
+// compressAbove disables compression.
+func NewCodec(format string, compressAbove int) (*Codec, error) {
+	c := &Codec{compressAbove: compressAbove}
+	switch format {
+	case FormatJSON:
+		c.contentType = ContentTypeJSON
+	case FormatMsgpack:
+		c.contentType = ContentTypeMsgpack
+	default:
+		return nil, fmt.Errorf("unknown event encoding %q", format)
+	}
+	return c, nil
+}
+
+// Encode returns a message for subject carrying event.
+func (c *Codec) Encode(subject string, event *logmodel.LogEvent) (*nats.Msg, error) {
+	var data []byte
+	var err error
+	if c.contentType == ContentTypeMsgpack {
+		data, err = marshalMsgpack(event)
+	} else {
+		data, err = json.Marshal(event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(HeaderContentType, c.contentType)
+	if c.compressAbove > 0 && len(data) > c.compressAbove {
+		data = s2.Encode(nil, data)
+		msg.Header.Set(HeaderContentEncoding, EncodingS2)
+	}
+	msg.Data = data
+	return msg, nil
+}
+
+// DecodeEvent decodes a payload in any supported encoding, as described by
+// its headers.
+func DecodeEvent(header nats.Header, data []byte, event *logmodel.LogEvent) error {
+	switch enc := header.Get(HeaderContentEncoding); enc {
+	case "":
+	case EncodingS2:
+		var err error
+		if data, err = s2.Decode(nil, data); err != nil {
+			return fmt.Errorf("decompress: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown content encoding %q", enc)
+	}
+
+	switch ct := header.Get(HeaderContentType); ct {
+	case "", ContentTypeJSON:
+		return json.Unmarshal(data, event)
+	case ContentTypeMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		if err := dec.Decode(event); err != nil {
+			return err
+		}
+		// msgpack timestamps decode in the local zone.
+		event.Timestamp = event.Timestamp.UTC()
+		return nil
+	default:
+		return fmt.Errorf("unknown content type %q", ct)
+	}
+}
+
+func marshalMsgpack(event *logmodel.LogEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(event); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
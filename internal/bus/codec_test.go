@@ -0,0 +1,104 @@
+package bus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+func testEvent(message string) *logmodel.LogEvent {
+	return &logmodel.LogEvent{
+		ID:         "e1",
+		TenantID:   "t1",
+		Timestamp:  time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		Level:      "error",
+		Message:    message,
+		Service:    "api",
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SampleRate: 0.25,
+		Fields:     map[string]any{"status": 503.0, "route": "/v1/x", "retry": true},
+		Tags:       []string{"prod"},
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	long := strings.Repeat("upstream timed out ", 100)
+	tests := []struct {
+		format     string
+		message    string
+		compressed bool
+	}{
+		{FormatJSON, "short", false},
+		{FormatJSON, long, true},
+		{FormatMsgpack, "short", false},
+		{FormatMsgpack, long, true},
+	}
+	for _, tt := range tests {
+		codec, err := NewCodec(tt.format, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := testEvent(tt.message)
+		msg, err := codec.Encode("logs.raw.t1", want)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := msg.Header.Get(HeaderContentEncoding) == EncodingS2; got != tt.compressed {
+			t.Errorf("%s, %d bytes: compressed %v, want %v", tt.format, len(tt.message), got, tt.compressed)
+		}
+
+		var got logmodel.LogEvent
+		if err := DecodeEvent(msg.Header, msg.Data, &got); err != nil {
+			t.Fatalf("%s: decode: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("%s, %d bytes: got %+v, want %+v", tt.format, len(tt.message), got, want)
+		}
+	}
+}
+
+func TestCodecCompressionDisabled(t *testing.T) {
+	codec, err := NewCodec(FormatJSON, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := codec.Encode("logs.raw.t1", testEvent(strings.Repeat("x", 4096)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc := msg.Header.Get(HeaderContentEncoding); enc != "" {
+		t.Errorf("got Content-Encoding %q with compression disabled", enc)
+	}
+}
+
+// Messages published before encodings were introduced have no headers and
+// are JSON.
+func TestDecodeWithoutContentType(t *testing.T) {
+	data := []byte(`{"id":"e1","tenant_id":"t1","timestamp":"2026-03-01T12:30:00Z","level":"info","message":"hello","service":"api"}`)
+	var e logmodel.LogEvent
+	if err := DecodeEvent(nats.Header{}, data, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Message != "hello" || e.TenantID != "t1" {
+		t.Errorf("got %+v", e)
+	}
+	if err := DecodeEvent(nil, data, &e); err != nil {
+		t.Errorf("nil header: %v", err)
+	}
+}
+
+func TestDecodeUnknownEncoding(t *testing.T) {
+	var e logmodel.LogEvent
+	if err := DecodeEvent(nats.Header{HeaderContentType: {"text/plain"}}, []byte("x"), &e); err == nil {
+		t.Error("expected an error for an unknown content type")
+	}
+	if err := DecodeEvent(nats.Header{HeaderContentEncoding: {"zstd"}}, []byte("x"), &e); err == nil {
+		t.Error("expected an error for an unknown content encoding")
+	}
+}
@@ -0,0 +1,39 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// EnsureQueueConsumer creates or updates a durable push consumer delivered
+// to a queue group. Subscribing to it with nats.Bind leaves the consumer in
+// place when the subscriber stops, which interest-retention streams need:
+// a consumer deleted on shutdown would let the stream discard messages
+// published before the service comes back. A zero maxAckPending keeps the
+// server default.
+func EnsureQueueConsumer(js nats.JetStreamContext, stream, durable, group, filter string, ackWait time.Duration, maxAckPending int) error {
+	cfg := &nats.ConsumerConfig{
+		Durable:        durable,
+		DeliverSubject: "deliver." + durable,
+		DeliverGroup:   group,
+		FilterSubject:  filter,
+		DeliverPolicy:  nats.DeliverAllPolicy,
+		AckPolicy:      nats.AckExplicitPolicy,
+		AckWait:        ackWait,
+		MaxDeliver:     MaxDeliver,
+		MaxAckPending:  maxAckPending,
+	}
+	_, err := js.ConsumerInfo(stream, durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(stream, cfg)
+	} else if err == nil {
+		_, err = js.UpdateConsumer(stream, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("ensure consumer %s: %w", durable, err)
+	}
+	return nil
+}
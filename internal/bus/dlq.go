@@ -0,0 +1,169 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DLQStream holds messages that exhausted their deliveries or could never be
+// processed, under logs.dlq.<tenant>.
+const DLQStream = "LOGS_DLQ"
+
+// MaxDeliver is the delivery limit for log consumers. The final attempt
+// dead-letters the message instead of NAKing it.
+const MaxDeliver = 3
+
+// Headers attached to dead-lettered messages.
+const (
+	HeaderDLQStage      = "Mintlog-DLQ-Stage"
+	HeaderDLQReason     = "Mintlog-DLQ-Reason"
+	HeaderDLQSubject    = "Mintlog-DLQ-Subject"
+	HeaderDLQDeliveries = "Mintlog-DLQ-Deliveries"
+	HeaderDLQFailedAt   = "Mintlog-DLQ-Failed-At"
+)
+
+// DLQSubject returns the dead-letter subject for a tenant.
+func DLQSubject(tenantID string) string {
+	return "logs.dlq." + tenantID
+}
+
+// TenantFromSubject returns the tenant token of subjects like logs.raw.<tenant>.
+func TenantFromSubject(subject string) string {
+	if i := strings.LastIndexByte(subject, '.'); i >= 0 {
+		return subject[i+1:]
+	}
+	return subject
+}
+
+// DeadLetter publishes the original payload to the tenant's DLQ subject with
+// the failing stage and reason attached as headers.
+func (p *Publisher) DeadLetter(subject string, header nats.Header, data []byte, stage, reason string, deliveries uint64) error {
+	out := nats.NewMsg(DLQSubject(TenantFromSubject(subject)))
+	for k, v := range header {
+		out.Header[k] = v
+	}
+	out.Header.Set(HeaderDLQStage, stage)
+	out.Header.Set(HeaderDLQReason, reason)
+	out.Header.Set(HeaderDLQSubject, subject)
+	out.Header.Set(HeaderDLQDeliveries, strconv.FormatUint(deliveries, 10))
+	out.Header.Set(HeaderDLQFailedAt, time.Now().UTC().Format(time.RFC3339Nano))
+	out.Data = data
+
+	if _, err := p.js.PublishMsg(out); err != nil {
+		return fmt.Errorf("publish %s: %w", out.Subject, err)
+	}
+	return nil
+}
+
+// Reject dead-letters a message that can never succeed and acks it. If the
+// DLQ publish fails the message is NAKed so it is not lost.
+func (p *Publisher) Reject(msg *nats.Msg, stage string, cause error) {
+	var deliveries uint64
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = meta.NumDelivered
+	}
+
+	if err := p.DeadLetter(msg.Subject, msg.Header, msg.Data, stage, cause.Error(), deliveries); err != nil {
+		slog.Error("dlq: failed to dead-letter message", "stage", stage, "subject", msg.Subject, "error", err)
+		msg.Nak()
+		return
+	}
+	slog.Warn("message dead-lettered", "stage", stage, "subject", msg.Subject, "reason", cause)
+	msg.Ack()
+}
+
+// Fail NAKs a message for redelivery, or dead-letters it on its final
+// delivery attempt.
+func (p *Publisher) Fail(msg *nats.Msg, stage string, cause error) {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered < MaxDeliver {
+		msg.Nak()
+		return
+	}
+	p.Reject(msg, stage, cause)
+}
+
+// Retry NAKs a message for redelivery after delay, or dead-letters it on
+// its final delivery attempt.
+func (p *Publisher) Retry(msg *nats.Msg, stage string, cause error, delay time.Duration) {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered < MaxDeliver {
+		msg.NakWithDelay(delay)
+		return
+	}
+	p.Reject(msg, stage, cause)
+}
+
+// maxDeliveriesAdvisory is the part of a JetStream max-deliveries advisory
+// the DLQ uses.
+type maxDeliveriesAdvisory struct {
+	StreamSeq  uint64 `json:"stream_seq"`
+	Deliveries uint64 `json:"deliveries"`
+}
+
+// WatchMaxDeliveries dead-letters the messages a consumer stops delivering
+// without the service having dead-lettered them: ones whose final delivery
+// timed out, whose handler crashed, or whose DLQ publish failed. The server
+// announces each in a max-deliveries advisory; the message it names is read
+// back from the stream and published to logs.dlq.<tenant> with stage. The
+// replicas of a service share a queue group, so each advisory is handled
+// once. Advisories are not stored, so one sent while no replica listens is
+// missed.
+func (p *Publisher) WatchMaxDeliveries(stream, consumer, stage string) (*nats.Subscription, error) {
+	subject := fmt.Sprintf("$JS.EVENT.ADVISORY.CONSUMER.MAX_DELIVERIES.%s.%s", stream, consumer)
+	sub, err := p.nc.QueueSubscribe(subject, "dlq-"+consumer, func(m *nats.Msg) {
+		var adv maxDeliveriesAdvisory
+		if err := json.Unmarshal(m.Data, &adv); err != nil {
+			slog.Error("dlq: invalid max deliveries advisory", "consumer", consumer, "error", err)
+			return
+		}
+		raw, err := p.js.GetMsg(stream, adv.StreamSeq)
+		if err != nil {
+			slog.Error("dlq: failed to read undelivered message", "stream", stream, "seq", adv.StreamSeq, "error", err)
+			return
+		}
+		reason := fmt.Sprintf("not acknowledged after %d deliveries", adv.Deliveries)
+		if err := p.DeadLetter(raw.Subject, raw.Header, raw.Data, stage, reason, adv.Deliveries); err != nil {
+			slog.Error("dlq: failed to dead-letter message", "stage", stage, "subject", raw.Subject, "error", err)
+			return
+		}
+		slog.Warn("message dead-lettered", "stage", stage, "subject", raw.Subject, "reason", reason)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("subscribe %s: %w", subject, err)
+	}
+	return sub, nil
+}
+
+// RejectJS is Reject for messages from a jetstream package consumer.
+func (p *Publisher) RejectJS(msg jetstream.Msg, stage string, cause error) {
+	var deliveries uint64
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = meta.NumDelivered
+	}
+
+	if err := p.DeadLetter(msg.Subject(), msg.Headers(), msg.Data(), stage, cause.Error(), deliveries); err != nil {
+		slog.Error("dlq: failed to dead-letter message", "stage", stage, "subject", msg.Subject(), "error", err)
+		msg.Nak()
+		return
+	}
+	slog.Warn("message dead-lettered", "stage", stage, "subject", msg.Subject(), "reason", cause)
+	msg.Ack()
+}
+
+// FailJS is Fail for messages from a jetstream package consumer.
+func (p *Publisher) FailJS(msg jetstream.Msg, stage string, cause error) {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered < MaxDeliver {
+		msg.Nak()
+		return
+	}
+	p.RejectJS(msg, stage, cause)
+}
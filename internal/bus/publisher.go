@@ -8,11 +8,12 @@ import (
 )
 
 type Publisher struct {
+	nc *nats.Conn
 	js nats.JetStreamContext
 }
 
-func NewPublisher(js nats.JetStreamContext) *Publisher {
-	return &Publisher{js: js}
+func NewPublisher(nc *nats.Conn, js nats.JetStreamContext) *Publisher {
+	return &Publisher{nc: nc, js: js}
 }
 
 func (p *Publisher) Publish(subject string, v any) error {
@@ -27,3 +28,11 @@ func (p *Publisher) Publish(subject string, v any) error {
 	}
 	return nil
 }
+
+// PublishMsg publishes a pre-encoded message, keeping its headers.
+func (p *Publisher) PublishMsg(msg *nats.Msg) error {
+	if _, err := p.js.PublishMsg(msg); err != nil {
+		return fmt.Errorf("publish %s: %w", msg.Subject, err)
+	}
+	return nil
+}
@@ -1,12 +1,18 @@
 package bus
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
+// EgressStream queues events routed to egress destinations, under
+// egress.<destination>.<tenant>.
+const EgressStream = "EGRESS"
+
 var StreamConfigs = []nats.StreamConfig{
 	{
 		Name:      "LOGS_RAW",
@@ -16,12 +22,28 @@ var StreamConfigs = []nats.StreamConfig{
 		Storage:   nats.FileStorage,
 	},
 	{
+		// Interest retention: the indexer, the egress router and the
+		// archiver each consume every parsed event.
 		Name:      "LOGS_PARSED",
 		Subjects:  []string{"logs.parsed.>"},
+		Retention: nats.InterestPolicy,
+		MaxAge:    24 * time.Hour,
+		Storage:   nats.FileStorage,
+	},
+	{
+		Name:      EgressStream,
+		Subjects:  []string{"egress.>"},
 		Retention: nats.WorkQueuePolicy,
 		MaxAge:    24 * time.Hour,
 		Storage:   nats.FileStorage,
 	},
+	{
+		Name:      DLQStream,
+		Subjects:  []string{"logs.dlq.>"},
+		Retention: nats.LimitsPolicy,
+		MaxAge:    14 * 24 * time.Hour,
+		Storage:   nats.FileStorage,
+	},
 	{
 		Name:      "ALERTS_EVENTS",
 		Subjects:  []string{"alerts.events.>"},
@@ -38,23 +60,72 @@ var StreamConfigs = []nats.StreamConfig{
 	},
 }
 
+// retentionDrainTimeout bounds how long EnsureStreams waits for a stream
+// whose retention policy changed to empty before recreating it.
+const retentionDrainTimeout = 5 * time.Minute
+
+// EnsureStreams creates missing streams and updates existing ones. NATS
+// can't change a stream's retention policy, so a stream created with
+// another one is recreated once its consumers have drained it; if it does
+// not drain in time, EnsureStreams fails rather than run with the old
+// policy.
 func EnsureStreams(js nats.JetStreamContext) error {
 	for _, cfg := range StreamConfigs {
-		_, err := js.StreamInfo(cfg.Name)
-		if err == nats.ErrStreamNotFound {
-			_, err = js.AddStream(&cfg)
-			if err != nil {
+		info, err := js.StreamInfo(cfg.Name)
+		switch {
+		case errors.Is(err, nats.ErrStreamNotFound):
+			if _, err := js.AddStream(&cfg); err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
 				return err
 			}
 			slog.Info("created stream", "name", cfg.Name)
-		} else if err != nil {
+		case err != nil:
 			return err
-		} else {
-			_, err = js.UpdateStream(&cfg)
-			if err != nil {
-				slog.Warn("failed to update stream", "name", cfg.Name, "error", err)
+		case info.Config.Retention != cfg.Retention:
+			if err := recreateStream(js, cfg, info); err != nil {
+				return err
+			}
+		default:
+			if _, err := js.UpdateStream(&cfg); err != nil {
+				return fmt.Errorf("update stream %s: %w", cfg.Name, err)
 			}
 		}
 	}
 	return nil
 }
+
+// recreateStream waits for a stream to empty, then deletes it and creates
+// it with cfg. Its consumers are deleted with it and recreated by their
+// services on restart.
+func recreateStream(js nats.JetStreamContext, cfg nats.StreamConfig, info *nats.StreamInfo) error {
+	slog.Warn("stream retention changed, recreating once drained",
+		"name", cfg.Name, "from", info.Config.Retention, "to", cfg.Retention, "messages", info.State.Msgs)
+
+	deadline := time.Now().Add(retentionDrainTimeout)
+	for info.State.Msgs > 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("stream %s: %d messages left after %s; stop its publishers, let its consumers drain it and restart",
+				cfg.Name, info.State.Msgs, retentionDrainTimeout)
+		}
+		time.Sleep(time.Second)
+		var err error
+		info, err = js.StreamInfo(cfg.Name)
+		if errors.Is(err, nats.ErrStreamNotFound) {
+			break // recreated by another service
+		}
+		if err != nil {
+			return err
+		}
+		if info.Config.Retention == cfg.Retention {
+			return nil
+		}
+	}
+
+	if err := js.DeleteStream(cfg.Name); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("delete stream %s: %w", cfg.Name, err)
+	}
+	if _, err := js.AddStream(&cfg); err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return fmt.Errorf("recreate stream %s: %w", cfg.Name, err)
+	}
+	slog.Info("recreated stream", "name", cfg.Name, "retention", cfg.Retention)
+	return nil
+}
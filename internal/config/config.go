@@ -1,6 +1,9 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +15,12 @@ type Config struct {
 	MinIO      MinIOConfig
 	Ingest     ServerConfig
 	API        ServerConfig
+	Pipeline   PipelineConfig
+	Indexer    IndexerConfig
+	Egress     EgressConfig
+	Lifecycle  LifecycleConfig
+	Archive    ArchiveConfig
+	LogStore   LogStoreConfig
 }
 
 type PostgresConfig struct {
@@ -27,7 +36,9 @@ func (p PostgresConfig) DSN() string {
 }
 
 type NATSConfig struct {
-	URL string
+	URL           string
+	Encoding      string // event encoding on the bus: "json" or "msgpack"
+	CompressAbove int    // compress event payloads larger than this many bytes; 0 disables
 }
 
 type OpenSearchConfig struct {
@@ -53,6 +64,68 @@ type ServerConfig struct {
 	Addr string
 }
 
+type PipelineConfig struct {
+	UserAgentField string
+	LookupRefresh  time.Duration
+	PatternMax     int
+	PatternFlush   time.Duration
+	FieldMaxDepth  int
+	FieldMax       int
+	FieldRefresh   time.Duration
+	CatalogFlush   time.Duration
+	RulesRefresh   time.Duration
+	MetricRefresh  time.Duration
+	MetricFlush    time.Duration
+	MetricMax      int
+	Workers        int
+	BatchSize      int
+	MaxPending     int
+}
+
+type IndexerConfig struct {
+	Workers    int // concurrent bulk requests
+	MinBatchMB int // adaptive batch size bounds
+	MaxBatchMB int
+	Flush      time.Duration
+	MaxPending int // unacked events held by the indexer
+
+	SharedPlans []string // plans whose new tenants go to the shared indices
+}
+
+type EgressConfig struct {
+	Refresh time.Duration
+	// Hosts and CIDRs destinations may reach although they are loopback,
+	// private or link-local.
+	AllowedHosts []string
+}
+
+type LifecycleConfig struct {
+	Interval        time.Duration
+	ReadOnlyAfter   int  // days; 0 disables
+	ForceMergeAfter int  // days; 0 disables
+	Archive         bool // export expiring indices to the archive
+
+	RolloverInterval  time.Duration // how often write indices are checked
+	RolloverMaxSizeGB int           // primary size; 0 disables
+	RolloverMaxDocs   int64         // 0 disables
+	RolloverMaxAge    time.Duration // 0 disables
+}
+
+type ArchiveConfig struct {
+	Bucket         string
+	Flush          time.Duration
+	MaxEvents      int
+	MaxObjectMB    int
+	RehydratePoll  time.Duration // how often lifecycled looks for rehydration jobs
+	ColdResultsTTL time.Duration // how long cold search results are kept
+}
+
+type LogStoreConfig struct {
+	Backend   string // "opensearch" or "local"
+	Dir       string // event files of the local backend
+	BatchSize int    // events per local write
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
@@ -66,6 +139,10 @@ func Load() (*Config, error) {
 	viper.SetDefault("postgres_password", "mintlog")
 	viper.SetDefault("postgres_db", "mintlog")
 	viper.SetDefault("nats_url", "nats://localhost:4222")
+	// JSON and no compression are what consumers before the codec read;
+	// switch once every consumer decodes both.
+	viper.SetDefault("nats_encoding", "json")
+	viper.SetDefault("nats_compress_above", 0)
 	viper.SetDefault("opensearch_url", "http://localhost:9200")
 	viper.SetDefault("opensearch_user", "admin")
 	viper.SetDefault("opensearch_password", "M1ntl0g!Pass")
@@ -78,6 +155,46 @@ func Load() (*Config, error) {
 	viper.SetDefault("minio_use_ssl", false)
 	viper.SetDefault("ingest_addr", ":8080")
 	viper.SetDefault("api_addr", ":8081")
+	viper.SetDefault("pipeline_useragent_field", "fields.user_agent")
+	viper.SetDefault("pipeline_lookup_refresh", "30s")
+	viper.SetDefault("pipeline_pattern_max", 1000)
+	viper.SetDefault("pipeline_pattern_flush", "30s")
+	viper.SetDefault("pipeline_field_max_depth", 5)
+	viper.SetDefault("pipeline_field_max", 1000)
+	viper.SetDefault("pipeline_field_refresh", "30s")
+	viper.SetDefault("pipeline_catalog_flush", "60s")
+	viper.SetDefault("pipeline_rules_refresh", "15s")
+	viper.SetDefault("pipeline_metric_refresh", "15s")
+	viper.SetDefault("pipeline_metric_flush", "10s")
+	viper.SetDefault("pipeline_metric_max_series", 1000)
+	viper.SetDefault("pipeline_workers", 0) // 0 = one per CPU
+	viper.SetDefault("pipeline_batch_size", 256)
+	viper.SetDefault("pipeline_max_pending", 4096)
+	viper.SetDefault("indexer_workers", 4)
+	viper.SetDefault("indexer_min_batch_mb", 1)
+	viper.SetDefault("indexer_max_batch_mb", 16)
+	viper.SetDefault("indexer_flush", "2s")
+	viper.SetDefault("indexer_max_pending", 50000)
+	viper.SetDefault("indexer_shared_plans", "free")
+	viper.SetDefault("egress_refresh", "30s")
+	viper.SetDefault("egress_allowed_hosts", "")
+	viper.SetDefault("lifecycle_interval", "1h")
+	viper.SetDefault("lifecycle_readonly_after_days", 2)
+	viper.SetDefault("lifecycle_forcemerge_after_days", 2)
+	viper.SetDefault("lifecycle_archive", false)
+	viper.SetDefault("lifecycle_rollover_interval", "5m")
+	viper.SetDefault("lifecycle_rollover_max_size_gb", 30)
+	viper.SetDefault("lifecycle_rollover_max_docs", 100000000)
+	viper.SetDefault("lifecycle_rollover_max_age", "168h")
+	viper.SetDefault("archive_bucket", "mintlog-archive")
+	viper.SetDefault("archive_flush", "5m")
+	viper.SetDefault("archive_max_events", 100000)
+	viper.SetDefault("archive_max_object_mb", 64)
+	viper.SetDefault("archive_rehydrate_poll", "30s")
+	viper.SetDefault("archive_cold_results_ttl", "1h")
+	viper.SetDefault("logstore_backend", "opensearch")
+	viper.SetDefault("logstore_dir", "data/logs")
+	viper.SetDefault("logstore_batch_size", 1000)
 
 	// Try reading .env file; ignore if not found
 	_ = viper.ReadInConfig()
@@ -91,7 +208,9 @@ func Load() (*Config, error) {
 			DB:       viper.GetString("postgres_db"),
 		},
 		NATS: NATSConfig{
-			URL: viper.GetString("nats_url"),
+			URL:           viper.GetString("nats_url"),
+			Encoding:      viper.GetString("nats_encoding"),
+			CompressAbove: viper.GetInt("nats_compress_above"),
 		},
 		OpenSearch: OpenSearchConfig{
 			URL:      viper.GetString("opensearch_url"),
@@ -115,7 +234,72 @@ func Load() (*Config, error) {
 		API: ServerConfig{
 			Addr: viper.GetString("api_addr"),
 		},
+		Pipeline: PipelineConfig{
+			UserAgentField: viper.GetString("pipeline_useragent_field"),
+			LookupRefresh:  viper.GetDuration("pipeline_lookup_refresh"),
+			PatternMax:     viper.GetInt("pipeline_pattern_max"),
+			PatternFlush:   viper.GetDuration("pipeline_pattern_flush"),
+			FieldMaxDepth:  viper.GetInt("pipeline_field_max_depth"),
+			FieldMax:       viper.GetInt("pipeline_field_max"),
+			FieldRefresh:   viper.GetDuration("pipeline_field_refresh"),
+			CatalogFlush:   viper.GetDuration("pipeline_catalog_flush"),
+			RulesRefresh:   viper.GetDuration("pipeline_rules_refresh"),
+			MetricRefresh:  viper.GetDuration("pipeline_metric_refresh"),
+			MetricFlush:    viper.GetDuration("pipeline_metric_flush"),
+			MetricMax:      viper.GetInt("pipeline_metric_max_series"),
+			Workers:        viper.GetInt("pipeline_workers"),
+			BatchSize:      viper.GetInt("pipeline_batch_size"),
+			MaxPending:     viper.GetInt("pipeline_max_pending"),
+		},
+		Indexer: IndexerConfig{
+			Workers:    viper.GetInt("indexer_workers"),
+			MinBatchMB: viper.GetInt("indexer_min_batch_mb"),
+			MaxBatchMB: viper.GetInt("indexer_max_batch_mb"),
+			Flush:      viper.GetDuration("indexer_flush"),
+			MaxPending: viper.GetInt("indexer_max_pending"),
+
+			SharedPlans: splitList(viper.GetString("indexer_shared_plans")),
+		},
+		Egress: EgressConfig{
+			Refresh:      viper.GetDuration("egress_refresh"),
+			AllowedHosts: splitList(viper.GetString("egress_allowed_hosts")),
+		},
+		Lifecycle: LifecycleConfig{
+			Interval:        viper.GetDuration("lifecycle_interval"),
+			ReadOnlyAfter:   viper.GetInt("lifecycle_readonly_after_days"),
+			ForceMergeAfter: viper.GetInt("lifecycle_forcemerge_after_days"),
+			Archive:         viper.GetBool("lifecycle_archive"),
+
+			RolloverInterval:  viper.GetDuration("lifecycle_rollover_interval"),
+			RolloverMaxSizeGB: viper.GetInt("lifecycle_rollover_max_size_gb"),
+			RolloverMaxDocs:   viper.GetInt64("lifecycle_rollover_max_docs"),
+			RolloverMaxAge:    viper.GetDuration("lifecycle_rollover_max_age"),
+		},
+		Archive: ArchiveConfig{
+			Bucket:         viper.GetString("archive_bucket"),
+			Flush:          viper.GetDuration("archive_flush"),
+			MaxEvents:      viper.GetInt("archive_max_events"),
+			MaxObjectMB:    viper.GetInt("archive_max_object_mb"),
+			RehydratePoll:  viper.GetDuration("archive_rehydrate_poll"),
+			ColdResultsTTL: viper.GetDuration("archive_cold_results_ttl"),
+		},
+		LogStore: LogStoreConfig{
+			Backend:   viper.GetString("logstore_backend"),
+			Dir:       viper.GetString("logstore_dir"),
+			BatchSize: viper.GetInt("logstore_batch_size"),
+		},
 	}
 
 	return cfg, nil
 }
+
+// splitList parses a comma-separated setting, dropping empty items.
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,212 @@
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+	maxReplayLimit   = 1000
+)
+
+type Handler struct {
+	store *Store
+}
+
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	after, _ := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > maxListLimit {
+		limit = defaultListLimit
+	}
+
+	tenantID := info.ID.String()
+	total, err := h.store.Count(tenantID)
+	if err != nil {
+		slog.Error("dlq count failed", "error", err)
+		apierror.Write(w, apierror.Internal("failed to read dead-letter queue"))
+		return
+	}
+
+	resp := ListResponse{Entries: []Entry{}, Total: total}
+	if total > 0 {
+		resp.Entries, err = h.store.List(tenantID, after, limit)
+		if err != nil {
+			slog.Error("dlq list failed", "error", err)
+			apierror.Write(w, apierror.Internal("failed to read dead-letter queue"))
+			return
+		}
+		if len(resp.Entries) == limit {
+			resp.Next = resp.Entries[len(resp.Entries)-1].Sequence
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	seq, err := strconv.ParseUint(chi.URLParam(r, "seq"), 10, 64)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid sequence"))
+		return
+	}
+
+	entry, err := h.store.Get(info.ID.String(), seq)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	seq, err := strconv.ParseUint(chi.URLParam(r, "seq"), 10, 64)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid sequence"))
+		return
+	}
+
+	if err := h.store.Delete(info.ID.String(), seq); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	if err := h.store.Purge(info.ID.String()); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Replay(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	seq, err := strconv.ParseUint(chi.URLParam(r, "seq"), 10, 64)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid sequence"))
+		return
+	}
+
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+			return
+		}
+	}
+
+	if err := h.store.Replay(info.ID.String(), seq, req.Target); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReplayResponse{Replayed: 1})
+}
+
+// ReplayAll replays up to limit entries, oldest first.
+func (h *Handler) ReplayAll(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+			return
+		}
+	}
+	if req.Target != "" && req.Target != "raw" && req.Target != "parsed" {
+		apierror.Write(w, apierror.BadRequest(ErrInvalidTarget.Error()))
+		return
+	}
+	if req.Limit <= 0 || req.Limit > maxReplayLimit {
+		req.Limit = maxReplayLimit
+	}
+
+	tenantID := info.ID.String()
+	entries, err := h.store.List(tenantID, 0, req.Limit)
+	if err != nil {
+		slog.Error("dlq list failed", "error", err)
+		apierror.Write(w, apierror.Internal("failed to read dead-letter queue"))
+		return
+	}
+
+	var resp ReplayResponse
+	for _, e := range entries {
+		if err := h.store.Replay(tenantID, e.Sequence, req.Target); err != nil {
+			slog.Warn("dlq replay failed", "sequence", e.Sequence, "error", err)
+			resp.Failed++
+			continue
+		}
+		resp.Replayed++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		apierror.Write(w, apierror.NotFound(err.Error()))
+	case errors.Is(err, ErrInvalidTarget):
+		apierror.Write(w, apierror.BadRequest(err.Error()))
+	default:
+		slog.Error("dlq operation failed", "error", err)
+		apierror.Write(w, apierror.Internal("dead-letter operation failed"))
+	}
+}
@@ -0,0 +1,34 @@
+package dlq
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Entry struct {
+	Sequence   uint64          `json:"sequence"`
+	Stage      string          `json:"stage"`
+	Reason     string          `json:"reason"`
+	Subject    string          `json:"subject"`
+	Deliveries int             `json:"deliveries"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Size       int             `json:"size"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	RawPayload []byte          `json:"raw_payload,omitempty"` // set when the payload is neither JSON nor a decodable event
+}
+
+type ListResponse struct {
+	Entries []Entry `json:"entries"`
+	Total   uint64  `json:"total"`
+	Next    uint64  `json:"next,omitempty"` // pass as ?after= to continue
+}
+
+type ReplayRequest struct {
+	Target string `json:"target,omitempty"` // "raw", "parsed", or empty for the original subject
+	Limit  int    `json:"limit,omitempty"`  // bulk replay only
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
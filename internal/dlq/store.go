@@ -0,0 +1,196 @@
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const listWait = 500 * time.Millisecond
+
+var (
+	ErrNotFound      = errors.New("dlq entry not found")
+	ErrInvalidTarget = errors.New("target must be \"raw\" or \"parsed\"")
+)
+
+// Store reads and manages a tenant's entries in the LOGS_DLQ stream.
+type Store struct {
+	js  nats.JetStreamContext
+	pub *bus.Publisher
+}
+
+func NewStore(js nats.JetStreamContext, pub *bus.Publisher) *Store {
+	return &Store{js: js, pub: pub}
+}
+
+// Count returns the number of entries held for a tenant.
+func (s *Store) Count(tenantID string) (uint64, error) {
+	subject := bus.DLQSubject(tenantID)
+	info, err := s.js.StreamInfo(bus.DLQStream, &nats.StreamInfoRequest{SubjectsFilter: subject})
+	if err != nil {
+		return 0, fmt.Errorf("stream info: %w", err)
+	}
+	return info.State.Subjects[subject], nil
+}
+
+// List returns up to limit entries with a stream sequence greater than after.
+func (s *Store) List(tenantID string, after uint64, limit int) ([]Entry, error) {
+	start := nats.DeliverAll()
+	if after > 0 {
+		start = nats.StartSequence(after + 1)
+	}
+
+	sub, err := s.js.SubscribeSync(bus.DLQSubject(tenantID), nats.OrderedConsumer(), start)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe dlq: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	entries := make([]Entry, 0, limit)
+	for len(entries) < limit {
+		msg, err := sub.NextMsg(listWait)
+		if errors.Is(err, nats.ErrTimeout) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read dlq: %w", err)
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("dlq metadata: %w", err)
+		}
+
+		entries = append(entries, toEntry(meta.Sequence.Stream, msg.Header, msg.Data, false))
+		if meta.NumPending == 0 {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// Get returns a single entry including its payload.
+func (s *Store) Get(tenantID string, seq uint64) (Entry, error) {
+	msg, err := s.get(tenantID, seq)
+	if err != nil {
+		return Entry{}, err
+	}
+	return toEntry(msg.Sequence, msg.Header, msg.Data, true), nil
+}
+
+func (s *Store) Delete(tenantID string, seq uint64) error {
+	if _, err := s.get(tenantID, seq); err != nil {
+		return err
+	}
+	if err := s.js.DeleteMsg(bus.DLQStream, seq); err != nil {
+		return fmt.Errorf("delete dlq entry: %w", err)
+	}
+	return nil
+}
+
+// Purge removes every entry held for a tenant.
+func (s *Store) Purge(tenantID string) error {
+	err := s.js.PurgeStream(bus.DLQStream, &nats.StreamPurgeRequest{Subject: bus.DLQSubject(tenantID)})
+	if err != nil {
+		return fmt.Errorf("purge dlq: %w", err)
+	}
+	return nil
+}
+
+// Replay republishes an entry to logs.raw or logs.parsed and removes it from
+// the DLQ. An empty target replays to the subject the message failed on,
+// which for egress failures is the destination's queue.
+func (s *Store) Replay(tenantID string, seq uint64, target string) error {
+	msg, err := s.get(tenantID, seq)
+	if err != nil {
+		return err
+	}
+
+	subject, err := replaySubject(tenantID, target, msg.Header.Get(bus.HeaderDLQSubject))
+	if err != nil {
+		return err
+	}
+
+	out := nats.NewMsg(subject)
+	for k, v := range msg.Header {
+		if !strings.HasPrefix(k, "Mintlog-DLQ-") {
+			out.Header[k] = v
+		}
+	}
+	out.Data = msg.Data
+
+	if err := s.pub.PublishMsg(out); err != nil {
+		return err
+	}
+	if err := s.js.DeleteMsg(bus.DLQStream, seq); err != nil {
+		return fmt.Errorf("delete replayed entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) get(tenantID string, seq uint64) (*nats.RawStreamMsg, error) {
+	msg, err := s.js.GetMsg(bus.DLQStream, seq)
+	if errors.Is(err, nats.ErrMsgNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get dlq entry: %w", err)
+	}
+	// Sequences are stream-wide; never expose another tenant's entry.
+	if msg.Subject != bus.DLQSubject(tenantID) {
+		return nil, ErrNotFound
+	}
+	return msg, nil
+}
+
+func replaySubject(tenantID, target, original string) (string, error) {
+	switch target {
+	case "raw", "parsed":
+		return fmt.Sprintf("logs.%s.%s", target, tenantID), nil
+	case "":
+		for _, stream := range []string{"raw", "parsed"} {
+			if original == fmt.Sprintf("logs.%s.%s", stream, tenantID) {
+				return original, nil
+			}
+		}
+		// Events that failed delivery go back to their destination's queue.
+		if strings.HasPrefix(original, "egress.") && strings.HasSuffix(original, "."+tenantID) {
+			return original, nil
+		}
+		return "", fmt.Errorf("%w: original subject %q cannot be replayed", ErrInvalidTarget, original)
+	default:
+		return "", ErrInvalidTarget
+	}
+}
+
+func toEntry(seq uint64, header nats.Header, data []byte, withPayload bool) Entry {
+	e := Entry{
+		Sequence: seq,
+		Stage:    header.Get(bus.HeaderDLQStage),
+		Reason:   header.Get(bus.HeaderDLQReason),
+		Subject:  header.Get(bus.HeaderDLQSubject),
+		Size:     len(data),
+	}
+	e.Deliveries, _ = strconv.Atoi(header.Get(bus.HeaderDLQDeliveries))
+	e.FailedAt, _ = time.Parse(time.RFC3339Nano, header.Get(bus.HeaderDLQFailedAt))
+
+	if withPayload {
+		// Binary events are shown as JSON when they decode.
+		var event logmodel.LogEvent
+		if json.Valid(data) {
+			e.Payload = data
+		} else if bus.DecodeEvent(header, data, &event) == nil {
+			e.Payload, _ = json.Marshal(&event)
+		} else {
+			e.RawPayload = data
+		}
+	}
+	return e
+}
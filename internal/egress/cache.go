@@ -0,0 +1,123 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Cache keeps every tenant's active destinations in memory, reloading them
+// every interval.
+type Cache struct {
+	queries  *queries.Queries
+	interval time.Duration
+
+	mu       sync.RWMutex
+	all      []*Destination
+	byTenant map[string][]*Destination
+	cancel   context.CancelFunc
+}
+
+func NewCache(q *queries.Queries, interval time.Duration) *Cache {
+	return &Cache{
+		queries:  q,
+		interval: interval,
+		byTenant: make(map[string][]*Destination),
+	}
+}
+
+// Start loads all destinations and keeps refreshing them until Stop is called.
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					slog.Error("egress cache: refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	slog.Info("egress destination cache started")
+	return nil
+}
+
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Destinations returns the tenant's active destinations.
+func (c *Cache) Destinations(tenantID string) []*Destination {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.byTenant[tenantID]
+}
+
+// All returns every active destination.
+func (c *Cache) All() []*Destination {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.all
+}
+
+// Refresh reloads all active destinations. Destinations whose match cannot
+// be decoded are skipped.
+func (c *Cache) Refresh(ctx context.Context) error {
+	rows, err := c.queries.ListActiveEgressDestinations(ctx)
+	if err != nil {
+		return fmt.Errorf("list egress destinations: %w", err)
+	}
+
+	all := make([]*Destination, 0, len(rows))
+	byTenant := make(map[string][]*Destination)
+	for _, row := range rows {
+		d, err := fromRow(row)
+		if err != nil {
+			slog.Error("egress cache: invalid destination", "destination_id", row.ID, "error", err)
+			continue
+		}
+		all = append(all, d)
+		byTenant[d.TenantID] = append(byTenant[d.TenantID], d)
+	}
+
+	c.mu.Lock()
+	c.all = all
+	c.byTenant = byTenant
+	c.mu.Unlock()
+	return nil
+}
+
+func fromRow(row queries.EgressDestination) (*Destination, error) {
+	d := &Destination{
+		ID:         row.ID,
+		TenantID:   row.TenantID.String(),
+		Name:       row.Name,
+		Type:       row.Type,
+		Config:     row.Config,
+		BatchSize:  int(row.BatchSize),
+		FlushEvery: time.Duration(row.FlushSeconds) * time.Second,
+		MaxRetries: int(row.MaxRetries),
+		UpdatedAt:  row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Match, &d.Match); err != nil {
+		return nil, fmt.Errorf("decode match: %w", err)
+	}
+	return d, nil
+}
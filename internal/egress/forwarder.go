@@ -0,0 +1,222 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const (
+	sendTimeout = 60 * time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+func consumerName(destinationID uuid.UUID) string {
+	return "egress-" + destinationID.String()
+}
+
+func filterSubject(destinationID uuid.UUID) string {
+	return fmt.Sprintf("egress.%s.>", destinationID)
+}
+
+// ensureConsumer creates or updates the pull consumer for a destination's
+// queue. The server stops redelivering after MaxRetries+1 deliveries; the
+// forwarder dead-letters on that last delivery.
+func ensureConsumer(js nats.JetStreamContext, d *Destination) error {
+	cfg := &nats.ConsumerConfig{
+		Durable:       consumerName(d.ID),
+		FilterSubject: filterSubject(d.ID),
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       d.FlushEvery + 2*sendTimeout,
+		MaxDeliver:    d.MaxRetries + 1,
+		MaxAckPending: 2 * d.BatchSize,
+	}
+	_, err := js.ConsumerInfo(bus.EgressStream, cfg.Durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(bus.EgressStream, cfg)
+	} else if err == nil {
+		_, err = js.UpdateConsumer(bus.EgressStream, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("ensure consumer %s: %w", cfg.Durable, err)
+	}
+	return nil
+}
+
+// RemoveQueue deletes a destination's consumer and any events still queued
+// for it.
+func RemoveQueue(js nats.JetStreamContext, destinationID uuid.UUID) error {
+	err := js.DeleteConsumer(bus.EgressStream, consumerName(destinationID))
+	if err != nil && !errors.Is(err, nats.ErrConsumerNotFound) {
+		return fmt.Errorf("delete consumer: %w", err)
+	}
+	err = js.PurgeStream(bus.EgressStream, &nats.StreamPurgeRequest{Subject: filterSubject(destinationID)})
+	if err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("purge queue: %w", err)
+	}
+	return nil
+}
+
+// Forwarder delivers one destination's queue to its sink. It pulls up to
+// BatchSize events, waiting at most FlushEvery, and sends them as one batch.
+// A failed batch is NAKed with exponential backoff; after MaxRetries retries,
+// or at once for permanent errors, its events are dead-lettered to
+// logs.dlq.<tenant> with stage "egress:<name>".
+type Forwarder struct {
+	js    nats.JetStreamContext
+	pub   *bus.Publisher
+	dest  *Destination
+	sink  Sink
+	stage string
+
+	sub    *nats.Subscription
+	dlq    *nats.Subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewForwarder(js nats.JetStreamContext, pub *bus.Publisher, d *Destination, sink Sink) *Forwarder {
+	return &Forwarder{js: js, pub: pub, dest: d, sink: sink, stage: "egress:" + d.Name}
+}
+
+func (f *Forwarder) Start(ctx context.Context) error {
+	if err := ensureConsumer(f.js, f.dest); err != nil {
+		return err
+	}
+	name := consumerName(f.dest.ID)
+	dlq, err := f.pub.WatchMaxDeliveries(bus.EgressStream, name, f.stage)
+	if err != nil {
+		return err
+	}
+	sub, err := f.js.PullSubscribe(filterSubject(f.dest.ID), name, nats.Bind(bus.EgressStream, name))
+	if err != nil {
+		dlq.Unsubscribe()
+		return fmt.Errorf("subscribe %s: %w", name, err)
+	}
+	f.sub = sub
+	f.dlq = dlq
+
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.done = make(chan struct{})
+	go f.run(ctx)
+
+	slog.Info("egress forwarder started", "destination_id", f.dest.ID, "name", f.dest.Name, "type", f.dest.Type)
+	return nil
+}
+
+// Stop waits for the batch in flight, then releases the subscription and
+// sink. The consumer is kept, so queued events wait for the next start.
+func (f *Forwarder) Stop() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+	f.sub.Unsubscribe()
+	f.dlq.Unsubscribe()
+	f.sink.Close()
+}
+
+func (f *Forwarder) run(ctx context.Context) {
+	defer close(f.done)
+	for ctx.Err() == nil {
+		msgs := f.collect(ctx)
+		if len(msgs) == 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			// Shutting down: hand the batch back for immediate redelivery.
+			for _, m := range msgs {
+				m.Nak()
+			}
+			return
+		}
+		f.deliver(ctx, msgs)
+	}
+}
+
+// collect pulls messages until the batch is full or FlushEvery has passed.
+func (f *Forwarder) collect(ctx context.Context) []*nats.Msg {
+	deadline := time.Now().Add(f.dest.FlushEvery)
+	var msgs []*nats.Msg
+	for len(msgs) < f.dest.BatchSize {
+		fctx, cancel := context.WithDeadline(ctx, deadline)
+		got, err := f.sub.Fetch(f.dest.BatchSize-len(msgs), nats.Context(fctx))
+		cancel()
+		msgs = append(msgs, got...)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) && !errors.Is(err, nats.ErrTimeout) {
+			slog.Error("egress: fetch failed", "destination_id", f.dest.ID, "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(deadline)):
+			}
+		}
+		break
+	}
+	return msgs
+}
+
+func (f *Forwarder) deliver(ctx context.Context, msgs []*nats.Msg) {
+	events := make([]*logmodel.LogEvent, 0, len(msgs))
+	valid := make([]*nats.Msg, 0, len(msgs))
+	for _, m := range msgs {
+		var e logmodel.LogEvent
+		if err := bus.DecodeEvent(m.Header, m.Data, &e); err != nil {
+			f.pub.Reject(m, f.stage, fmt.Errorf("decode: %w", err))
+			continue
+		}
+		events = append(events, &e)
+		valid = append(valid, m)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := f.sink.Send(sctx, events)
+	cancel()
+
+	if err == nil {
+		for _, m := range valid {
+			m.Ack()
+		}
+		slog.Debug("egress batch delivered", "destination_id", f.dest.ID, "count", len(valid))
+		return
+	}
+
+	slog.Warn("egress batch failed", "destination_id", f.dest.ID, "name", f.dest.Name, "count", len(valid), "permanent", IsPermanent(err), "error", err)
+	for _, m := range valid {
+		f.retry(m, err)
+	}
+}
+
+// retry NAKs m with backoff, or dead-letters it when err is permanent or
+// its retries are used up.
+func (f *Forwarder) retry(m *nats.Msg, cause error) {
+	meta, err := m.Metadata()
+	if err != nil || IsPermanent(cause) || meta.NumDelivered > uint64(f.dest.MaxRetries) {
+		f.pub.Reject(m, f.stage, cause)
+		return
+	}
+	m.NakWithDelay(backoff(meta.NumDelivered))
+}
+
+// backoff doubles from one second per delivery, up to maxBackoff.
+func backoff(delivered uint64) time.Duration {
+	d := time.Second
+	for i := uint64(1); i < delivered && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,242 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const (
+	defaultBatchSize    = 500
+	defaultFlushSeconds = 5
+	defaultMaxRetries   = 5
+
+	maxBatchSize    = 5000
+	maxFlushSeconds = 300
+	maxRetries      = 20
+)
+
+type Handler struct {
+	queries *queries.Queries
+	js      nats.JetStreamContext
+	hosts   *Hosts
+}
+
+// NewHandler serves the destinations API, accepting only destinations that
+// connect to hosts allows.
+func NewHandler(q *queries.Queries, js nats.JetStreamContext, hosts *Hosts) *Handler {
+	return &Handler{queries: q, js: js, hosts: hosts}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req DestinationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := h.validate(r.Context(), &req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	d, err := h.queries.CreateEgressDestination(r.Context(), info.ID, req.Name, req.Type, req.Config, match, req.BatchSize, req.FlushSeconds, req.MaxRetries)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to create destination"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toDestinationResponse(d))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid destination ID"))
+		return
+	}
+
+	d, err := h.queries.GetEgressDestination(r.Context(), id, info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("destination not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toDestinationResponse(d))
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	list, err := h.queries.ListEgressDestinations(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list destinations"))
+		return
+	}
+
+	resp := make([]DestinationResponse, len(list))
+	for i, d := range list {
+		resp[i] = toDestinationResponse(d)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid destination ID"))
+		return
+	}
+
+	var req DestinationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := h.validate(r.Context(), &req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	d, err := h.queries.UpdateEgressDestination(r.Context(), id, info.ID, req.Name, req.Type, req.Config, match, req.BatchSize, req.FlushSeconds, req.MaxRetries, isActive)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("destination not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toDestinationResponse(d))
+}
+
+// Delete removes a destination together with its queue. Events not yet
+// delivered are discarded.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid destination ID"))
+		return
+	}
+
+	if _, err := h.queries.GetEgressDestination(r.Context(), id, info.ID); err != nil {
+		apierror.Write(w, apierror.NotFound("destination not found"))
+		return
+	}
+	if err := h.queries.DeleteEgressDestination(r.Context(), id, info.ID); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete destination"))
+		return
+	}
+	if err := RemoveQueue(h.js, id); err != nil {
+		slog.Error("egress: failed to remove queue", "destination_id", id, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate checks a destination request and fills in defaults. It returns
+// an error message, or "" if the request is valid.
+func (h *Handler) validate(ctx context.Context, req *DestinationRequest) string {
+	if req.Name == "" {
+		return "name is required"
+	}
+	if msg := validateConfig(ctx, req.Type, req.Config, h.hosts); msg != "" {
+		return msg
+	}
+	if len(req.Config) == 0 {
+		req.Config = json.RawMessage(`{}`)
+	}
+
+	if req.BatchSize == 0 {
+		req.BatchSize = defaultBatchSize
+	}
+	if req.FlushSeconds == 0 {
+		req.FlushSeconds = defaultFlushSeconds
+	}
+	if req.MaxRetries == 0 {
+		req.MaxRetries = defaultMaxRetries
+	}
+	if req.BatchSize < 1 || req.BatchSize > maxBatchSize {
+		return "batch_size must be between 1 and 5000"
+	}
+	if req.FlushSeconds < 1 || req.FlushSeconds > maxFlushSeconds {
+		return "flush_seconds must be between 1 and 300"
+	}
+	if req.MaxRetries < 1 || req.MaxRetries > maxRetries {
+		return "max_retries must be between 1 and 20"
+	}
+	return ""
+}
+
+func toDestinationResponse(d queries.EgressDestination) DestinationResponse {
+	resp := DestinationResponse{
+		ID:           d.ID,
+		Name:         d.Name,
+		Type:         d.Type,
+		Config:       d.Config,
+		BatchSize:    d.BatchSize,
+		FlushSeconds: d.FlushSeconds,
+		MaxRetries:   d.MaxRetries,
+		IsActive:     d.IsActive,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+	json.Unmarshal(d.Match, &resp.Match)
+	return resp
+}
@@ -0,0 +1,122 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Hosts decides which hosts destinations may connect to. Loopback, private,
+// link-local and unspecified addresses are refused, so a tenant cannot point
+// a destination at Mintlog's own services or at cloud metadata, unless the
+// operator allows the host by name or its network by CIDR.
+type Hosts struct {
+	names map[string]bool
+	nets  []netip.Prefix
+}
+
+// NewHosts parses the operator's allow-list of host names and CIDRs.
+func NewHosts(allowed []string) (*Hosts, error) {
+	h := &Hosts{names: make(map[string]bool)}
+	for _, item := range allowed {
+		if strings.Contains(item, "/") {
+			p, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("egress allowed host %q: %w", item, err)
+			}
+			h.nets = append(h.nets, p.Masked())
+			continue
+		}
+		h.names[strings.ToLower(item)] = true
+	}
+	return h, nil
+}
+
+// Check resolves host and returns an error if any of its addresses is
+// refused.
+func (h *Hosts) Check(ctx context.Context, host string) error {
+	_, err := h.resolve(ctx, host)
+	return err
+}
+
+// CheckURL checks that raw is an http or https URL whose host is allowed.
+func (h *Hosts) CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("url must be http or https")
+	}
+	if u.Hostname() == "" {
+		return errors.New("url has no host")
+	}
+	return h.Check(ctx, u.Hostname())
+}
+
+// DialContext dials only allowed addresses, checking them again at connect
+// time so a host that later resolves to an internal address is refused.
+func (h *Hosts) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := h.resolve(ctx, host)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	var d net.Dialer
+	if addrs == nil {
+		return d.DialContext(ctx, network, address)
+	}
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// resolve returns host's addresses, or nil for a host allowed by name.
+func (h *Hosts) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if h.names[strings.ToLower(host)] {
+		return nil, nil
+	}
+	var addrs []netip.Addr
+	if ip, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{ip}
+	} else {
+		if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", host, err)
+		}
+	}
+	for _, ip := range addrs {
+		if !h.allowed(ip.Unmap()) {
+			return nil, fmt.Errorf("host %s resolves to internal address %s", host, ip)
+		}
+	}
+	return addrs, nil
+}
+
+func (h *Hosts) allowed(ip netip.Addr) bool {
+	for _, p := range h.nets {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified() && !ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is carrier-grade NAT (RFC 6598), internal in practice.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
@@ -0,0 +1,177 @@
+package egress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const (
+	httpTimeout = 30 * time.Second
+	userAgent   = "mintlog-egress/1.0"
+
+	// mintlogMaxBatch matches the ingest API's per-request event limit.
+	mintlogMaxBatch = 1000
+)
+
+// HTTPSink POSTs each batch as newline-delimited JSON.
+type HTTPSink struct {
+	cfg    HTTPConfig
+	client *http.Client
+}
+
+func NewHTTPSink(cfg HTTPConfig, hosts *Hosts) *HTTPSink {
+	return &HTTPSink{cfg: cfg, client: newHTTPClient(hosts)}
+}
+
+// newHTTPClient returns a client that connects only to hosts allows,
+// including on redirects.
+func newHTTPClient(hosts *Hosts) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = hosts.DialContext
+	return &http.Client{Timeout: httpTimeout, Transport: transport}
+}
+
+func (s *HTTPSink) Send(ctx context.Context, events []*logmodel.LogEvent) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return Permanent(fmt.Errorf("marshal event: %w", err))
+		}
+	}
+
+	payload := body.Bytes()
+	if s.cfg.Gzip {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		zw.Write(payload)
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		payload = zbuf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return Permanent(fmt.Errorf("create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", userAgent)
+	if s.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+		mac.Write(payload)
+		req.Header.Set("X-Mintlog-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return checkStatus(resp.StatusCode)
+}
+
+func (s *HTTPSink) Close() error { return nil }
+
+// MintlogSink forwards events to another Mintlog through its ingest API.
+// Events keep their timestamp, level, service, host, trace context, fields
+// and tags; the receiving pipeline assigns new IDs.
+type MintlogSink struct {
+	cfg    MintlogConfig
+	client *http.Client
+}
+
+func NewMintlogSink(cfg MintlogConfig, hosts *Hosts) *MintlogSink {
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &MintlogSink{cfg: cfg, client: newHTTPClient(hosts)}
+}
+
+func (s *MintlogSink) Send(ctx context.Context, events []*logmodel.LogEvent) error {
+	for start := 0; start < len(events); start += mintlogMaxBatch {
+		end := min(start+mintlogMaxBatch, len(events))
+		if err := s.send(ctx, events[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MintlogSink) send(ctx context.Context, events []*logmodel.LogEvent) error {
+	ingest := logmodel.IngestRequest{Events: make([]logmodel.IngestEvent, len(events))}
+	for i, e := range events {
+		ingest.Events[i] = logmodel.IngestEvent{
+			Timestamp: e.Timestamp.Format(time.RFC3339Nano),
+			Level:     e.Level,
+			Message:   e.Message,
+			Service:   e.Service,
+			Host:      e.Host,
+			TraceID:   e.TraceID,
+			SpanID:    e.SpanID,
+			Fields:    e.Fields,
+			Tags:      e.Tags,
+		}
+	}
+	body, err := json.Marshal(ingest)
+	if err != nil {
+		return Permanent(fmt.Errorf("marshal batch: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL+"/v1/ingest/logs", bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-API-Key", s.cfg.APIKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp.StatusCode); err != nil {
+		io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	var result logmodel.IngestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Rejected > 0 {
+		slog.Warn("egress: remote mintlog rejected events", "url", s.cfg.URL, "rejected", result.Rejected, "accepted", result.Accepted)
+	}
+	return nil
+}
+
+func (s *MintlogSink) Close() error { return nil }
+
+// checkStatus maps a response status to nil, a retryable error (408, 429,
+// 5xx) or a permanent one (other 4xx).
+func checkStatus(code int) error {
+	switch {
+	case code >= 200 && code < 300:
+		return nil
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return fmt.Errorf("destination returned status %d", code)
+	default:
+		return Permanent(fmt.Errorf("destination returned status %d", code))
+	}
+}
@@ -0,0 +1,109 @@
+package egress
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+)
+
+// Manager runs one Forwarder per active destination, starting, restarting
+// and stopping them as destinations are created, updated and deactivated.
+type Manager struct {
+	js       nats.JetStreamContext
+	pub      *bus.Publisher
+	cache    *Cache
+	hosts    *Hosts
+	interval time.Duration
+
+	forwarders map[uuid.UUID]*Forwarder
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewManager reconciles forwarders with cache every interval. Sinks connect
+// only to hosts allows.
+func NewManager(js nats.JetStreamContext, pub *bus.Publisher, cache *Cache, hosts *Hosts, interval time.Duration) *Manager {
+	return &Manager{
+		js:         js,
+		pub:        pub,
+		cache:      cache,
+		hosts:      hosts,
+		interval:   interval,
+		forwarders: make(map[uuid.UUID]*Forwarder),
+	}
+}
+
+func (m *Manager) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	m.reconcile(ctx)
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.reconcile(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops every forwarder after its batch in flight.
+func (m *Manager) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+	for id, f := range m.forwarders {
+		f.Stop()
+		delete(m.forwarders, id)
+	}
+}
+
+func (m *Manager) reconcile(ctx context.Context) {
+	active := make(map[uuid.UUID]bool)
+	for _, d := range m.cache.All() {
+		active[d.ID] = true
+
+		f, ok := m.forwarders[d.ID]
+		if ok && f.dest.UpdatedAt.Equal(d.UpdatedAt) {
+			continue
+		}
+		if ok {
+			f.Stop()
+			delete(m.forwarders, d.ID)
+		}
+
+		sink, err := NewSink(ctx, d, m.hosts)
+		if err != nil {
+			slog.Error("egress: invalid destination", "destination_id", d.ID, "name", d.Name, "error", err)
+			continue
+		}
+		f = NewForwarder(m.js, m.pub, d, sink)
+		if err := f.Start(ctx); err != nil {
+			slog.Error("egress: failed to start forwarder", "destination_id", d.ID, "name", d.Name, "error", err)
+			sink.Close()
+			continue
+		}
+		m.forwarders[d.ID] = f
+	}
+
+	for id, f := range m.forwarders {
+		if !active[id] {
+			f.Stop()
+			delete(m.forwarders, id)
+			slog.Info("egress forwarder stopped", "destination_id", id)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package egress
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+)
+
+// Destination types.
+const (
+	TypeSyslog  = "syslog"
+	TypeHTTP    = "http"
+	TypeMintlog = "mintlog"
+	TypeS3      = "s3"
+)
+
+// SyslogConfig sends RFC 5424 messages whose body is the event as JSON.
+type SyslogConfig struct {
+	Address  string `json:"address"`            // host:port
+	Network  string `json:"network,omitempty"`  // "udp", "tcp" (default) or "tls"
+	Facility int    `json:"facility,omitempty"` // 0-23, default 16 (local0)
+	AppName  string `json:"app_name,omitempty"` // default "mintlog"
+	Insecure bool   `json:"insecure,omitempty"` // tls: skip certificate verification
+}
+
+// HTTPConfig POSTs batches as NDJSON.
+type HTTPConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Secret  string            `json:"secret,omitempty"` // HMAC-SHA256 of the body in X-Mintlog-Signature
+	Gzip    bool              `json:"gzip,omitempty"`
+}
+
+// MintlogConfig forwards batches to another Mintlog's ingest API.
+type MintlogConfig struct {
+	URL    string `json:"url"` // ingest base URL, e.g. https://ingest.example.com
+	APIKey string `json:"api_key"`
+}
+
+// S3Config writes each batch as one gzipped NDJSON object under
+// <prefix>/<tenant>/YYYY/MM/DD/HH/, in the tenant's own store.
+type S3Config struct {
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix,omitempty"`
+	Endpoint  string `json:"endpoint"` // host[:port]
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Region    string `json:"region,omitempty"`
+	UseSSL    bool   `json:"use_ssl,omitempty"`
+}
+
+// Destination is an active destination as used by the router and forwarders.
+type Destination struct {
+	ID         uuid.UUID
+	TenantID   string
+	Name       string
+	Type       string
+	Config     json.RawMessage
+	Match      rules.Match
+	BatchSize  int
+	FlushEvery time.Duration
+	MaxRetries int
+	UpdatedAt  time.Time
+}
+
+type DestinationRequest struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type"` // "syslog", "http", "mintlog" or "s3"
+	Config       json.RawMessage `json:"config"`
+	Match        rules.Match     `json:"match"`
+	BatchSize    int32           `json:"batch_size,omitempty"`    // default 500
+	FlushSeconds int32           `json:"flush_seconds,omitempty"` // default 5
+	MaxRetries   int32           `json:"max_retries,omitempty"`   // default 5
+	IsActive     *bool           `json:"is_active,omitempty"`
+}
+
+type DestinationResponse struct {
+	ID           uuid.UUID       `json:"id"`
+	Name         string          `json:"name"`
+	Type         string          `json:"type"`
+	Config       json.RawMessage `json:"config"`
+	Match        rules.Match     `json:"match"`
+	BatchSize    int32           `json:"batch_size"`
+	FlushSeconds int32           `json:"flush_seconds"`
+	MaxRetries   int32           `json:"max_retries"`
+	IsActive     bool            `json:"is_active"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
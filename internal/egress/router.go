@@ -0,0 +1,106 @@
+package egress
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/internal/pipeline"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// routerStage identifies the router in dead-letter headers.
+const routerStage = "egress-router"
+
+// Subject returns the egress subject queuing a tenant's events for a
+// destination.
+func Subject(destinationID uuid.UUID, tenantID string) string {
+	return fmt.Sprintf("egress.%s.%s", destinationID, tenantID)
+}
+
+// Router consumes logs.parsed and copies each event to the queue of every
+// destination of its tenant whose match it satisfies. Each copy carries a
+// Nats-Msg-Id, so redeliveries within the stream's duplicate window are
+// not queued twice.
+type Router struct {
+	js    nats.JetStreamContext
+	pub   *bus.Publisher
+	cache *Cache
+	sub   *nats.Subscription
+	dlq   *nats.Subscription
+}
+
+func NewRouter(js nats.JetStreamContext, pub *bus.Publisher, cache *Cache) *Router {
+	return &Router{js: js, pub: pub, cache: cache}
+}
+
+func (r *Router) Start() error {
+	err := bus.EnsureQueueConsumer(r.js, "LOGS_PARSED", "egress-router", "egress-routers", "logs.parsed.>", 30*time.Second, 0)
+	if err != nil {
+		return err
+	}
+	r.dlq, err = r.pub.WatchMaxDeliveries("LOGS_PARSED", "egress-router", routerStage)
+	if err != nil {
+		return err
+	}
+
+	sub, err := r.js.QueueSubscribe(
+		"logs.parsed.>",
+		"egress-routers",
+		r.handleMessage,
+		nats.Bind("LOGS_PARSED", "egress-router"),
+		nats.ManualAck(),
+	)
+	if err != nil {
+		return fmt.Errorf("subscribe logs.parsed: %w", err)
+	}
+	r.sub = sub
+	slog.Info("egress router started", "subject", "logs.parsed.>")
+	return nil
+}
+
+func (r *Router) Stop() {
+	if r.sub != nil {
+		r.sub.Unsubscribe()
+	}
+	if r.dlq != nil {
+		r.dlq.Unsubscribe()
+	}
+}
+
+func (r *Router) handleMessage(msg *nats.Msg) {
+	tenantID := bus.TenantFromSubject(msg.Subject)
+	dests := r.cache.Destinations(tenantID)
+	if len(dests) == 0 {
+		msg.Ack()
+		return
+	}
+
+	var event logmodel.LogEvent
+	if err := bus.DecodeEvent(msg.Header, msg.Data, &event); err != nil {
+		r.pub.Reject(msg, routerStage, fmt.Errorf("decode: %w", err))
+		return
+	}
+
+	for _, d := range dests {
+		if !pipeline.Matches(&d.Match, &event) {
+			continue
+		}
+		out := nats.NewMsg(Subject(d.ID, tenantID))
+		for k, v := range msg.Header {
+			out.Header[k] = v
+		}
+		out.Header.Set(nats.MsgIdHdr, event.ID+":"+d.ID.String())
+		out.Data = msg.Data
+		if err := r.pub.PublishMsg(out); err != nil {
+			slog.Error("egress: failed to queue event", "destination_id", d.ID, "error", err)
+			r.pub.Fail(msg, routerStage, err)
+			return
+		}
+	}
+	msg.Ack()
+}
@@ -0,0 +1,94 @@
+package egress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/minio"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// S3Sink writes gzipped NDJSON objects to an S3-compatible bucket, one
+// object per batch and event hour:
+// <prefix>/<tenant>/YYYY/MM/DD/HH/<unix nanos>-<uuid>.ndjson.gz.
+type S3Sink struct {
+	cfg    S3Config
+	client *minio.Client
+
+	mu          sync.Mutex
+	bucketReady bool
+}
+
+func NewS3Sink(cfg S3Config) (*S3Sink, error) {
+	client, err := minio.NewClient(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.UseSSL, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3Sink{cfg: cfg, client: client}, nil
+}
+
+func (s *S3Sink) Send(ctx context.Context, events []*logmodel.LogEvent) error {
+	if err := s.ensureBucket(ctx); err != nil {
+		return err
+	}
+
+	type group struct {
+		buf bytes.Buffer
+		zw  *gzip.Writer
+		enc *json.Encoder
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, e := range events {
+		key := e.TenantID + "/" + e.Timestamp.UTC().Format("2006/01/02/15")
+		g, ok := groups[key]
+		if !ok {
+			g = &group{}
+			g.zw = gzip.NewWriter(&g.buf)
+			g.enc = json.NewEncoder(g.zw)
+			groups[key] = g
+			order = append(order, key)
+		}
+		if err := g.enc.Encode(e); err != nil {
+			return Permanent(fmt.Errorf("marshal event: %w", err))
+		}
+	}
+
+	now := time.Now().UnixNano()
+	for _, key := range order {
+		g := groups[key]
+		if err := g.zw.Close(); err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		name := path.Join(s.cfg.Prefix, key, fmt.Sprintf("%d-%s.ndjson.gz", now, uuid.NewString()))
+		if err := s.client.Put(ctx, s.cfg.Bucket, name, g.buf.Bytes(), "application/x-ndjson", "gzip"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Sink) Close() error { return nil }
+
+func (s *S3Sink) ensureBucket(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bucketReady {
+		return nil
+	}
+	if err := s.client.EnsureBucket(ctx, s.cfg.Bucket); err != nil {
+		return err
+	}
+	s.bucketReady = true
+	return nil
+}
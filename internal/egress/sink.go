@@ -0,0 +1,153 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Sink delivers batches of events to one destination. Send either delivers
+// the whole batch or returns an error; errors wrapped with Permanent are not
+// retried.
+type Sink interface {
+	Send(ctx context.Context, events []*logmodel.LogEvent) error
+	Close() error
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying cannot fix, such as a 4xx
+// response, so the batch is dead-lettered immediately.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// NewSink builds the sink for a destination. Sinks connect only to hosts
+// allows.
+func NewSink(ctx context.Context, d *Destination, hosts *Hosts) (Sink, error) {
+	switch d.Type {
+	case TypeSyslog:
+		var cfg SyslogConfig
+		if err := decodeConfig(d.Config, &cfg); err != nil {
+			return nil, err
+		}
+		return NewSyslogSink(cfg)
+	case TypeHTTP:
+		var cfg HTTPConfig
+		if err := decodeConfig(d.Config, &cfg); err != nil {
+			return nil, err
+		}
+		return NewHTTPSink(cfg, hosts), nil
+	case TypeMintlog:
+		var cfg MintlogConfig
+		if err := decodeConfig(d.Config, &cfg); err != nil {
+			return nil, err
+		}
+		return NewMintlogSink(cfg, hosts), nil
+	case TypeS3:
+		var cfg S3Config
+		if err := decodeConfig(d.Config, &cfg); err != nil {
+			return nil, err
+		}
+		if err := hosts.Check(ctx, endpointHost(cfg.Endpoint)); err != nil {
+			return nil, err
+		}
+		return NewS3Sink(cfg)
+	}
+	return nil, fmt.Errorf("unknown destination type %q", d.Type)
+}
+
+// validateConfig checks that config decodes for destType, has its required
+// settings and connects only to hosts allows. It returns an error message,
+// or "" if valid.
+func validateConfig(ctx context.Context, destType string, raw json.RawMessage, hosts *Hosts) string {
+	switch destType {
+	case TypeSyslog:
+		var cfg SyslogConfig
+		if err := decodeConfig(raw, &cfg); err != nil {
+			return err.Error()
+		}
+		if cfg.Address == "" {
+			return "config.address is required"
+		}
+		switch cfg.Network {
+		case "", "tcp", "udp", "tls":
+		default:
+			return "config.network must be one of: tcp, udp, tls"
+		}
+		if cfg.Facility < 0 || cfg.Facility > 23 {
+			return "config.facility must be between 0 and 23"
+		}
+	case TypeHTTP:
+		var cfg HTTPConfig
+		if err := decodeConfig(raw, &cfg); err != nil {
+			return err.Error()
+		}
+		if cfg.URL == "" {
+			return "config.url is required"
+		}
+		if err := hosts.CheckURL(ctx, cfg.URL); err != nil {
+			return "config.url: " + err.Error()
+		}
+	case TypeMintlog:
+		var cfg MintlogConfig
+		if err := decodeConfig(raw, &cfg); err != nil {
+			return err.Error()
+		}
+		if cfg.URL == "" || cfg.APIKey == "" {
+			return "config.url and config.api_key are required"
+		}
+		if err := hosts.CheckURL(ctx, cfg.URL); err != nil {
+			return "config.url: " + err.Error()
+		}
+	case TypeS3:
+		var cfg S3Config
+		if err := decodeConfig(raw, &cfg); err != nil {
+			return err.Error()
+		}
+		if cfg.Bucket == "" {
+			return "config.bucket is required"
+		}
+		// Never Mintlog's own MinIO: a tenant could write to any bucket on it.
+		if cfg.Endpoint == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return "config.endpoint, config.access_key and config.secret_key are required"
+		}
+		if err := hosts.Check(ctx, endpointHost(cfg.Endpoint)); err != nil {
+			return "config.endpoint: " + err.Error()
+		}
+	default:
+		return "type must be one of: syslog, http, mintlog, s3"
+	}
+	return ""
+}
+
+// endpointHost returns the host of an S3 endpoint given as host[:port].
+func endpointHost(endpoint string) string {
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
+
+func decodeConfig(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage(`{}`)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
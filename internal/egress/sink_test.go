@@ -0,0 +1,37 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateConfigHosts(t *testing.T) {
+	hosts, err := NewHosts([]string{"10.20.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		destType string
+		config   string
+		valid    bool
+	}{
+		{TypeHTTP, `{"url": "https://203.0.113.10/logs"}`, true},
+		{TypeHTTP, `{"url": "http://127.0.0.1:9200/_bulk"}`, false},
+		{TypeHTTP, `{"url": "http://169.254.169.254/latest/meta-data"}`, false},
+		{TypeHTTP, `{"url": "http://192.168.1.5/"}`, false},
+		{TypeHTTP, `{"url": "http://[::1]/"}`, false},
+		{TypeHTTP, `{"url": "file:///etc/passwd"}`, false},
+		{TypeHTTP, `{"url": "http://10.20.3.4/logs"}`, true},
+		{TypeMintlog, `{"url": "http://10.0.0.1", "api_key": "mk_x"}`, false},
+		{TypeS3, `{"bucket": "mintlog-archive"}`, false},
+		{TypeS3, `{"bucket": "b", "endpoint": "127.0.0.1:9000", "access_key": "a", "secret_key": "s"}`, false},
+		{TypeS3, `{"bucket": "b", "endpoint": "203.0.113.10:9000", "access_key": "a", "secret_key": "s"}`, true},
+	}
+	for _, tt := range tests {
+		msg := validateConfig(context.Background(), tt.destType, json.RawMessage(tt.config), hosts)
+		if (msg == "") != tt.valid {
+			t.Errorf("%s %s: got %q, want valid %v", tt.destType, tt.config, msg, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package egress
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const (
+	defaultSyslogFacility = 16 // local0
+	syslogDialTimeout     = 10 * time.Second
+	syslogWriteTimeout    = 30 * time.Second
+)
+
+var syslogSeverity = map[string]int{
+	"fatal": 2,
+	"error": 3,
+	"warn":  4,
+	"info":  6,
+	"debug": 7,
+	"trace": 7,
+}
+
+// SyslogSink writes RFC 5424 messages over UDP, TCP or TLS. Stream
+// transports use octet-counting framing (RFC 6587). The connection is kept
+// open between batches and redialed after a write error.
+type SyslogSink struct {
+	cfg SyslogConfig
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = defaultSyslogFacility
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "mintlog"
+	}
+	return &SyslogSink{cfg: cfg}, nil
+}
+
+func (s *SyslogSink) Send(ctx context.Context, events []*logmodel.LogEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(syslogWriteTimeout)
+	}
+	s.conn.SetWriteDeadline(deadline)
+
+	stream := s.cfg.Network != "udp"
+	var buf bytes.Buffer
+	for _, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			return Permanent(err)
+		}
+		if !stream {
+			if _, err := s.conn.Write(msg); err != nil {
+				s.reset()
+				return fmt.Errorf("syslog write: %w", err)
+			}
+			continue
+		}
+		buf.WriteString(strconv.Itoa(len(msg)))
+		buf.WriteByte(' ')
+		buf.Write(msg)
+	}
+	if stream {
+		if _, err := s.conn.Write(buf.Bytes()); err != nil {
+			s.reset()
+			return fmt.Errorf("syslog write: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+	return nil
+}
+
+func (s *SyslogSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	d := &net.Dialer{Timeout: syslogDialTimeout}
+	if s.cfg.Network == "tls" {
+		td := &tls.Dialer{NetDialer: d, Config: &tls.Config{InsecureSkipVerify: s.cfg.Insecure}}
+		conn, err := td.DialContext(ctx, "tcp", s.cfg.Address)
+		if err != nil {
+			return nil, fmt.Errorf("syslog dial: %w", err)
+		}
+		return conn, nil
+	}
+	conn, err := d.DialContext(ctx, s.cfg.Network, s.cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("syslog dial: %w", err)
+	}
+	return conn, nil
+}
+
+// format renders one event as
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME - - - MSG, with the event JSON as MSG.
+func (s *SyslogSink) format(e *logmodel.LogEvent) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+
+	severity, ok := syslogSeverity[e.Level]
+	if !ok {
+		severity = 6
+	}
+	app := e.Service
+	if app == "" {
+		app = s.cfg.AppName
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s - - - ",
+		s.cfg.Facility*8+severity,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		syslogHeaderField(e.Host, 255),
+		syslogHeaderField(app, 48),
+	)
+	b.Write(body)
+	return b.Bytes(), nil
+}
+
+// syslogHeaderField keeps printable ASCII without spaces, truncated to limit,
+// or "-" when nothing is left.
+func syslogHeaderField(s string, limit int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
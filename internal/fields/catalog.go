@@ -0,0 +1,294 @@
+package fields
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+const (
+	// MaxSamples is the number of distinct sample values kept per field.
+	MaxSamples      = 5
+	maxSampleLength = 128
+)
+
+// Catalog records which fields each tenant sends: first/last seen, the
+// types observed before coercion, a cardinality sketch and a few sample
+// values. Observations are accumulated in memory and merged into Postgres
+// every interval.
+type Catalog struct {
+	pool      *pgxpool.Pool
+	queries   *queries.Queries
+	interval  time.Duration
+	maxFields int
+
+	mu      sync.Mutex
+	pending map[string]map[string]*fieldStats
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+type fieldStats struct {
+	types     map[string]int64
+	count     int64
+	sketch    *HLL
+	samples   []string
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// NewCatalog returns a catalog tracking at most maxFields fields per tenant
+// between flushes.
+func NewCatalog(pool *pgxpool.Pool, interval time.Duration, maxFields int) *Catalog {
+	return &Catalog{
+		pool:      pool,
+		queries:   queries.New(pool),
+		interval:  interval,
+		maxFields: maxFields,
+		pending:   make(map[string]map[string]*fieldStats),
+	}
+}
+
+// Observe records one value of a field as it arrived, before coercion.
+func (c *Catalog) Observe(tenantID, name, fieldType string, value any, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byName, ok := c.pending[tenantID]
+	if !ok {
+		byName = make(map[string]*fieldStats)
+		c.pending[tenantID] = byName
+	}
+	st, ok := byName[name]
+	if !ok {
+		if len(byName) >= c.maxFields {
+			return
+		}
+		st = &fieldStats{types: make(map[string]int64), sketch: NewHLL(), firstSeen: at, lastSeen: at}
+		byName[name] = st
+	}
+
+	st.count++
+	st.types[fieldType]++
+	if at.Before(st.firstSeen) {
+		st.firstSeen = at
+	}
+	if at.After(st.lastSeen) {
+		st.lastSeen = at
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	for _, v := range values {
+		s := sampleString(v)
+		st.sketch.Add(s)
+		st.samples = addSample(st.samples, s)
+	}
+}
+
+// Start merges observations into Postgres every interval until Stop is called.
+func (c *Catalog) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				c.Flush(context.Background())
+				return
+			case <-ticker.C:
+				c.Flush(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the flush loop after a final flush.
+func (c *Catalog) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// Flush merges pending observations into the stored catalog, one
+// transaction per tenant. Tenants that fail are kept for the next flush.
+func (c *Catalog) Flush(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]map[string]*fieldStats)
+	c.mu.Unlock()
+
+	for tenantID, byName := range pending {
+		if err := c.flushTenant(ctx, tenantID, byName); err != nil {
+			slog.Error("field catalog: flush failed", "tenant_id", tenantID, "fields", len(byName), "error", err)
+			c.requeue(tenantID, byName)
+		}
+	}
+}
+
+func (c *Catalog) flushTenant(ctx context.Context, tenantID string, byName map[string]*fieldStats) error {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return fmt.Errorf("invalid tenant id: %w", err)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	// Lock rows in a stable order so concurrent flushes cannot deadlock.
+	sort.Strings(names)
+
+	return pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		q := c.queries.WithTx(tx)
+
+		stored, err := q.LockFieldCatalog(ctx, id, names)
+		if err != nil {
+			return fmt.Errorf("lock catalog: %w", err)
+		}
+		existing := make(map[string]queries.FieldCatalogEntry, len(stored))
+		for _, e := range stored {
+			existing[e.Name] = e
+		}
+
+		for _, name := range names {
+			merged, err := mergeEntry(byName[name], existing[name])
+			if err != nil {
+				return fmt.Errorf("merge %s: %w", name, err)
+			}
+			typeCounts, err := json.Marshal(merged.types)
+			if err != nil {
+				return err
+			}
+			err = q.UpsertFieldCatalog(ctx, id, name, typeCounts, merged.count, merged.sketch.Bytes(),
+				merged.samples, merged.firstSeen, merged.lastSeen)
+			if err != nil {
+				return fmt.Errorf("upsert %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// mergeEntry combines pending stats with a stored entry without modifying
+// the pending stats, which may need to be requeued.
+func mergeEntry(st *fieldStats, e queries.FieldCatalogEntry) (*fieldStats, error) {
+	out := &fieldStats{
+		types:     make(map[string]int64, len(st.types)),
+		count:     st.count,
+		sketch:    NewHLL(),
+		samples:   append([]string(nil), st.samples...),
+		firstSeen: st.firstSeen,
+		lastSeen:  st.lastSeen,
+	}
+	for t, n := range st.types {
+		out.types[t] = n
+	}
+	out.sketch.Merge(st.sketch)
+
+	if e.Name == "" {
+		return out, nil
+	}
+
+	var types map[string]int64
+	if err := json.Unmarshal(e.TypeCounts, &types); err != nil {
+		return nil, fmt.Errorf("decode type counts: %w", err)
+	}
+	for t, n := range types {
+		out.types[t] += n
+	}
+	sketch, err := ParseHLL(e.Sketch)
+	if err != nil {
+		return nil, err
+	}
+	out.sketch.Merge(sketch)
+	out.count += e.EventCount
+	samples := append([]string(nil), e.Samples...)
+	for _, s := range out.samples {
+		samples = addSample(samples, s)
+	}
+	out.samples = samples
+	if e.FirstSeen.Before(out.firstSeen) {
+		out.firstSeen = e.FirstSeen
+	}
+	if e.LastSeen.After(out.lastSeen) {
+		out.lastSeen = e.LastSeen
+	}
+	return out, nil
+}
+
+func (c *Catalog) requeue(tenantID string, byName map[string]*fieldStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur, ok := c.pending[tenantID]
+	if !ok {
+		c.pending[tenantID] = byName
+		return
+	}
+	for name, st := range byName {
+		existing, ok := cur[name]
+		if !ok {
+			cur[name] = st
+			continue
+		}
+		existing.count += st.count
+		for t, n := range st.types {
+			existing.types[t] += n
+		}
+		existing.sketch.Merge(st.sketch)
+		for _, s := range st.samples {
+			existing.samples = addSample(existing.samples, s)
+		}
+		if st.firstSeen.Before(existing.firstSeen) {
+			existing.firstSeen = st.firstSeen
+		}
+		if st.lastSeen.After(existing.lastSeen) {
+			existing.lastSeen = st.lastSeen
+		}
+	}
+}
+
+func addSample(samples []string, s string) []string {
+	if len(samples) >= MaxSamples {
+		return samples
+	}
+	for _, existing := range samples {
+		if existing == s {
+			return samples
+		}
+	}
+	return append(samples, s)
+}
+
+func sampleString(v any) string {
+	var s string
+	if str, ok := v.(string); ok {
+		s = str
+	} else {
+		b, _ := json.Marshal(v)
+		s = string(b)
+	}
+	if len(s) > maxSampleLength {
+		s = strings.ToValidUTF8(s[:maxSampleLength], "")
+	}
+	return s
+}
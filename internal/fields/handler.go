@@ -0,0 +1,169 @@
+package fields
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const maxNameLength = 255
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type Handler struct {
+	queries  *queries.Queries
+	registry *Registry
+}
+
+// NewHandler returns the field API handler. registry may be nil; when set,
+// its cached types are dropped whenever a tenant's types change.
+func NewHandler(q *queries.Queries, registry *Registry) *Handler {
+	return &Handler{queries: q, registry: registry}
+}
+
+func (h *Handler) ListTypes(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	types, err := h.queries.ListFieldTypes(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list field types"))
+		return
+	}
+
+	resp := make([]TypeResponse, len(types))
+	for i, f := range types {
+		resp[i] = toTypeResponse(f)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SetType pins a field to a type. New events are coerced to it; values that
+// cannot be converted go to fields._conflicts.
+func (h *Handler) SetType(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if name == "" || len(name) > maxNameLength || strings.HasPrefix(name, "_") {
+		apierror.Write(w, apierror.BadRequest("invalid field name"))
+		return
+	}
+
+	var req SetTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if !ValidType(req.Type) {
+		apierror.Write(w, apierror.BadRequest("type must be one of: string, number, boolean"))
+		return
+	}
+
+	f, err := h.queries.SetFieldType(r.Context(), info.ID, name, req.Type)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to set field type"))
+		return
+	}
+	h.forget(info.ID.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTypeResponse(f))
+}
+
+// DeleteType forgets a field's type; the next value seen registers it again.
+func (h *Handler) DeleteType(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	if err := h.queries.DeleteFieldType(r.Context(), info.ID, chi.URLParam(r, "name")); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete field type"))
+		return
+	}
+	h.forget(info.ID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Catalog lists the fields seen in the tenant's events, optionally
+// restricted to names starting with ?prefix=.
+func (h *Handler) Catalog(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	prefix := likeEscaper.Replace(r.URL.Query().Get("prefix"))
+	entries, err := h.queries.ListFieldCatalog(r.Context(), info.ID, prefix)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list field catalog"))
+		return
+	}
+	types, err := h.queries.ListFieldTypes(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list field types"))
+		return
+	}
+	registered := make(map[string]string, len(types))
+	for _, f := range types {
+		registered[f.Name] = f.Type
+	}
+
+	resp := CatalogResponse{Fields: make([]CatalogEntry, 0, len(entries))}
+	for _, e := range entries {
+		entry := CatalogEntry{
+			Name:      e.Name,
+			Type:      registered[e.Name],
+			Count:     e.EventCount,
+			Samples:   e.Samples,
+			FirstSeen: e.FirstSeen,
+			LastSeen:  e.LastSeen,
+		}
+		if err := json.Unmarshal(e.TypeCounts, &entry.Types); err != nil {
+			apierror.Write(w, apierror.Internal("failed to decode field catalog"))
+			return
+		}
+		entry.Conflict = len(entry.Types) > 1
+		if sketch, err := ParseHLL(e.Sketch); err == nil {
+			entry.Cardinality = sketch.Estimate()
+		}
+		resp.Fields = append(resp.Fields, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) forget(tenantID string) {
+	if h.registry != nil {
+		h.registry.Forget(tenantID)
+	}
+}
+
+func toTypeResponse(f queries.FieldType) TypeResponse {
+	return TypeResponse{
+		Name:      f.Name,
+		Type:      f.Type,
+		Source:    f.Source,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
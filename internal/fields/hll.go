@@ -0,0 +1,83 @@
+package fields
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// hllPrecision gives 2^10 one-byte registers per sketch, for a standard
+// error of about 3.3%.
+const (
+	hllPrecision = 10
+	hllRegisters = 1 << hllPrecision
+)
+
+// HLL is a HyperLogLog cardinality sketch.
+type HLL struct {
+	registers [hllRegisters]uint8
+}
+
+// NewHLL returns an empty sketch.
+func NewHLL() *HLL {
+	return &HLL{}
+}
+
+// ParseHLL decodes a sketch produced by Bytes.
+func ParseHLL(b []byte) (*HLL, error) {
+	if len(b) != hllRegisters {
+		return nil, errors.New("invalid sketch length")
+	}
+	h := &HLL{}
+	copy(h.registers[:], b)
+	return h, nil
+}
+
+// Add records a value.
+func (h *HLL) Add(value string) {
+	x := xxhash.Sum64String(value)
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Merge folds other into h.
+func (h *HLL) Merge(other *HLL) {
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+// Estimate returns the approximate number of distinct values added.
+func (h *HLL) Estimate() uint64 {
+	const m = float64(hllRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	est := alpha * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		est = m * math.Log(m/float64(zeros))
+	}
+	return uint64(est + 0.5)
+}
+
+// Bytes encodes the sketch for storage.
+func (h *HLL) Bytes() []byte {
+	b := make([]byte, hllRegisters)
+	copy(b, h.registers[:])
+	return b
+}
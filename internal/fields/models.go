@@ -0,0 +1,34 @@
+package fields
+
+import "time"
+
+type TypeResponse struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"` // "inferred" or "manual"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SetTypeRequest struct {
+	Type string `json:"type"`
+}
+
+// CatalogEntry describes a field seen in a tenant's events. Types counts
+// values by the type they arrived with; more than one key means the field
+// has conflicting types.
+type CatalogEntry struct {
+	Name        string           `json:"name"`
+	Type        string           `json:"type,omitempty"` // registered type
+	Types       map[string]int64 `json:"types"`
+	Conflict    bool             `json:"conflict"`
+	Count       int64            `json:"count"`
+	Cardinality uint64           `json:"cardinality"` // HyperLogLog estimate
+	Samples     []string         `json:"samples"`
+	FirstSeen   time.Time        `json:"first_seen"`
+	LastSeen    time.Time        `json:"last_seen"`
+}
+
+type CatalogResponse struct {
+	Fields []CatalogEntry `json:"fields"`
+}
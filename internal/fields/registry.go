@@ -0,0 +1,243 @@
+package fields
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+var (
+	// ErrTooManyFields is returned when registering a field would exceed the
+	// tenant's field cap.
+	ErrTooManyFields = errors.New("field limit reached")
+	// ErrPathConflict is returned when a field name is both a leaf and a
+	// prefix of another field ("a" and "a.b"), which OpenSearch cannot map.
+	ErrPathConflict = errors.New("field path conflicts with an existing field")
+)
+
+// Registry holds each tenant's field types. The first type seen for a field
+// wins and is persisted, so all pipelined replicas coerce values the same
+// way. Tenants are loaded on first use and reloaded every refresh interval
+// to pick up types set through the API.
+type Registry struct {
+	pool      *pgxpool.Pool
+	queries   *queries.Queries
+	maxFields int
+	refresh   time.Duration
+	readOnly  bool
+
+	mu      sync.RWMutex
+	tenants map[string]*tenantTypes
+	loading map[string]*tenantLoad
+}
+
+// tenantLoad is a load of a tenant's types from Postgres in progress.
+// Concurrent lookups wait for it rather than query again.
+type tenantLoad struct {
+	done chan struct{}
+	tt   *tenantTypes
+	err  error
+}
+
+type tenantTypes struct {
+	types    map[string]string
+	prefixes map[string]int // object paths implied by registered names
+	loadedAt time.Time
+}
+
+func NewRegistry(pool *pgxpool.Pool, maxFields int, refresh time.Duration) *Registry {
+	return &Registry{
+		pool:      pool,
+		queries:   queries.New(pool),
+		maxFields: maxFields,
+		refresh:   refresh,
+		tenants:   make(map[string]*tenantTypes),
+		loading:   make(map[string]*tenantLoad),
+	}
+}
+
+// NewRegistryPreview returns a registry that resolves new fields without
+// persisting them, for simulating the pipeline.
+func NewRegistryPreview(pool *pgxpool.Pool, maxFields int, refresh time.Duration) *Registry {
+	r := NewRegistry(pool, maxFields, refresh)
+	r.readOnly = true
+	return r
+}
+
+// Resolve returns the type registered for name, registering observed if the
+// field is new. The cached check only saves a round trip: the cap is
+// enforced by the registration itself, across workers and replicas.
+func (r *Registry) Resolve(ctx context.Context, tenantID, name, observed string) (string, error) {
+	tt, err := r.tenant(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	r.mu.RLock()
+	if t, ok := tt.types[name]; ok {
+		r.mu.RUnlock()
+		return t, nil
+	}
+	err = tt.check(name, r.maxFields)
+	r.mu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+
+	if r.readOnly {
+		return observed, nil
+	}
+
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return "", fmt.Errorf("invalid tenant id: %w", err)
+	}
+	var f queries.FieldType
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+		if err := q.LockFieldTypes(ctx, id); err != nil {
+			return err
+		}
+		f, err = q.RegisterFieldType(ctx, id, name, observed, int32(r.maxFields))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrTooManyFields
+	}
+	if err != nil {
+		return "", fmt.Errorf("register field %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	tt.add(f.Name, f.Type)
+	r.mu.Unlock()
+	return f.Type, nil
+}
+
+// Types returns a copy of the tenant's field types by name.
+func (r *Registry) Types(ctx context.Context, tenantID string) (map[string]string, error) {
+	tt, err := r.tenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make(map[string]string, len(tt.types))
+	for name, t := range tt.types {
+		types[name] = t
+	}
+	return types, nil
+}
+
+// Forget drops a tenant's cached types so the next Resolve reloads them.
+func (r *Registry) Forget(tenantID string) {
+	r.mu.Lock()
+	delete(r.tenants, tenantID)
+	r.mu.Unlock()
+}
+
+// tenant returns the tenant's cached types, loading them when missing or
+// stale. Postgres is queried without holding r.mu, once per tenant at a
+// time: other lookups wait for the load, or use the stale types while it
+// runs.
+func (r *Registry) tenant(ctx context.Context, tenantID string) (*tenantTypes, error) {
+	r.mu.RLock()
+	tt, ok := r.tenants[tenantID]
+	r.mu.RUnlock()
+	if ok && time.Since(tt.loadedAt) < r.refresh {
+		return tt, nil
+	}
+
+	r.mu.Lock()
+	if l, loading := r.loading[tenantID]; loading {
+		r.mu.Unlock()
+		if ok {
+			return tt, nil
+		}
+		select {
+		case <-l.done:
+			return l.tt, l.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l := &tenantLoad{done: make(chan struct{})}
+	r.loading[tenantID] = l
+	r.mu.Unlock()
+
+	l.tt, l.err = r.load(ctx, tenantID)
+
+	r.mu.Lock()
+	if l.err == nil {
+		r.tenants[tenantID] = l.tt
+	}
+	delete(r.loading, tenantID)
+	r.mu.Unlock()
+	close(l.done)
+	return l.tt, l.err
+}
+
+// load reads a tenant's types from Postgres.
+func (r *Registry) load(ctx context.Context, tenantID string) (*tenantTypes, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant id: %w", err)
+	}
+	rows, err := r.queries.ListFieldTypes(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load field types: %w", err)
+	}
+
+	tt := &tenantTypes{
+		types:    make(map[string]string, len(rows)),
+		prefixes: make(map[string]int),
+		loadedAt: time.Now(),
+	}
+	for _, f := range rows {
+		tt.add(f.Name, f.Type)
+	}
+	return tt, nil
+}
+
+func (tt *tenantTypes) check(name string, maxFields int) error {
+	if len(tt.types) >= maxFields {
+		return ErrTooManyFields
+	}
+	if tt.prefixes[name] > 0 {
+		return ErrPathConflict
+	}
+	for i := strings.IndexByte(name, '.'); i >= 0; i = next(name, i) {
+		if _, ok := tt.types[name[:i]]; ok {
+			return ErrPathConflict
+		}
+	}
+	return nil
+}
+
+func (tt *tenantTypes) add(name, fieldType string) {
+	if _, ok := tt.types[name]; ok {
+		tt.types[name] = fieldType
+		return
+	}
+	tt.types[name] = fieldType
+	for i := strings.IndexByte(name, '.'); i >= 0; i = next(name, i) {
+		tt.prefixes[name[:i]]++
+	}
+}
+
+// next returns the index of the next '.' in name after i, or -1.
+func next(name string, i int) int {
+	j := strings.IndexByte(name[i+1:], '.')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
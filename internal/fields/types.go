@@ -0,0 +1,126 @@
+package fields
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Field types tracked by the registry. Values of any other shape are
+// flattened or serialized before they reach the registry.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// ValidType reports whether t is a known field type.
+func ValidType(t string) bool {
+	switch t {
+	case TypeString, TypeNumber, TypeBoolean:
+		return true
+	}
+	return false
+}
+
+// TypeOf returns the field type of a flattened value. Arrays take the type
+// of their elements and must be homogeneous.
+func TypeOf(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return TypeString, true
+	case bool:
+		return TypeBoolean, true
+	case float64, float32, int, int32, int64, uint, uint32, uint64:
+		return TypeNumber, true
+	case []any:
+		var t string
+		for _, e := range v {
+			et, ok := TypeOf(e)
+			if !ok || (t != "" && et != t) {
+				return "", false
+			}
+			t = et
+		}
+		return t, t != ""
+	}
+	return "", false
+}
+
+// Coerce converts v to type t. Conversions are only made when they lose no
+// information: numbers and booleans become strings, numeric strings become
+// numbers and "true"/"false" become booleans.
+func Coerce(v any, t string) (any, bool) {
+	if arr, ok := v.([]any); ok {
+		out := make([]any, len(arr))
+		for i, e := range arr {
+			c, ok := Coerce(e, t)
+			if !ok {
+				return nil, false
+			}
+			out[i] = c
+		}
+		return out, true
+	}
+
+	switch t {
+	case TypeString:
+		switch v := v.(type) {
+		case string:
+			return v, true
+		case bool:
+			return strconv.FormatBool(v), true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		}
+		if vt, ok := TypeOf(v); ok && vt == TypeNumber {
+			return toString(v), true
+		}
+	case TypeNumber:
+		switch v := v.(type) {
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, false
+			}
+			return f, true
+		case bool:
+			return nil, false
+		}
+		if vt, ok := TypeOf(v); ok && vt == TypeNumber {
+			return v, true
+		}
+	case TypeBoolean:
+		switch v := v.(type) {
+		case bool:
+			return v, true
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true":
+				return true, true
+			case "false":
+				return false, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	}
+	return ""
+}
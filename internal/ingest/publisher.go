@@ -8,14 +8,18 @@ import (
 )
 
 type LogPublisher struct {
-	pub *bus.Publisher
+	pub   *bus.Publisher
+	codec *bus.Codec
 }
 
-func NewLogPublisher(pub *bus.Publisher) *LogPublisher {
-	return &LogPublisher{pub: pub}
+func NewLogPublisher(pub *bus.Publisher, codec *bus.Codec) *LogPublisher {
+	return &LogPublisher{pub: pub, codec: codec}
 }
 
 func (lp *LogPublisher) Publish(tenantID string, event *logmodel.LogEvent) error {
-	subject := fmt.Sprintf("logs.raw.%s", tenantID)
-	return lp.pub.Publish(subject, event)
+	msg, err := lp.codec.Encode(fmt.Sprintf("logs.raw.%s", tenantID), event)
+	if err != nil {
+		return err
+	}
+	return lp.pub.PublishMsg(msg)
 }
@@ -0,0 +1,104 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+type Handler struct {
+	queries *queries.Queries
+	client  *opensearchapi.Client
+}
+
+func NewHandler(q *queries.Queries, client *opensearchapi.Client) *Handler {
+	return &Handler{queries: q, client: client}
+}
+
+// Usage reports the caller's indices with their document counts and sizes.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	indices, err := osstore.ListIndices(r.Context(), h.client, osstore.TenantPattern(info.ID.String()))
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list indices"))
+		return
+	}
+
+	resp := UsageResponse{
+		TenantID:      info.ID,
+		RetentionDays: info.RetentionDays,
+		Indices:       make([]IndexUsage, 0, len(indices)),
+	}
+	for _, idx := range indices {
+		u := IndexUsage{
+			Index:            idx.Name,
+			Docs:             idx.Docs,
+			SizeBytes:        idx.Bytes,
+			PrimarySizeBytes: idx.PrimaryBytes,
+			ReadOnly:         idx.ReadOnly,
+		}
+		if day, ok := osstore.IndexDate(idx.Name); ok {
+			u.Date = day.Format("2006-01-02")
+		}
+		resp.Indices = append(resp.Indices, u)
+		resp.add(idx)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AdminUsage reports storage totals for every tenant, largest first.
+func (h *Handler) AdminUsage(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.queries.ListTenants(r.Context())
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list tenants"))
+		return
+	}
+	indices, err := osstore.ListIndices(r.Context(), h.client, "mintlog-*")
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list indices"))
+		return
+	}
+
+	byTenant := make(map[string]*UsageResponse, len(tenants))
+	resp := make([]*UsageResponse, len(tenants))
+	for i, t := range tenants {
+		resp[i] = &UsageResponse{TenantID: t.ID, Name: t.Name, RetentionDays: t.RetentionDays}
+		byTenant[t.ID.String()] = resp[i]
+	}
+	for _, idx := range indices {
+		// mintlog-<tenant uuid>-...
+		rest := strings.TrimPrefix(idx.Name, "mintlog-")
+		if len(rest) < 36 {
+			continue
+		}
+		if u, ok := byTenant[rest[:36]]; ok {
+			u.add(idx)
+		}
+	}
+	sort.SliceStable(resp, func(i, j int) bool { return resp[i].SizeBytes > resp[j].SizeBytes })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (u *UsageResponse) add(idx osstore.IndexStats) {
+	u.IndexCount++
+	u.Docs += idx.Docs
+	u.SizeBytes += idx.Bytes
+	u.PrimarySizeBytes += idx.PrimaryBytes
+}
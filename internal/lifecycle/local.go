@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/filestore"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// LocalService applies tenants' retention to the local log store on a
+// schedule, deleting the day files older than retention_days. The local
+// store has no indices to roll over, merge or archive.
+type LocalService struct {
+	queries  *queries.Queries
+	store    *filestore.Store
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewLocalService(q *queries.Queries, store *filestore.Store, interval time.Duration) *LocalService {
+	return &LocalService{queries: q, store: store, interval: interval}
+}
+
+func (s *LocalService) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.Run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *LocalService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// Run applies retention once to every tenant.
+func (s *LocalService) Run(ctx context.Context) {
+	tenants, err := s.queries.ListTenants(ctx)
+	if err != nil {
+		slog.Error("lifecycle: failed to list tenants", "error", err)
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, t := range tenants {
+		if ctx.Err() != nil {
+			return
+		}
+		if t.RetentionDays <= 0 {
+			continue
+		}
+		n, err := s.store.Expire(t.ID.String(), today.AddDate(0, 0, -int(t.RetentionDays)))
+		if err != nil {
+			slog.Error("lifecycle: tenant failed", "tenant_id", t.ID, "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.Info("lifecycle: deleted expired days", "tenant_id", t.ID, "days", n)
+		}
+	}
+}
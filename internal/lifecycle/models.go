@@ -0,0 +1,41 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+)
+
+type IndexUsage struct {
+	Index            string `json:"index"`
+	Date             string `json:"date,omitempty"` // YYYY-MM-DD
+	Docs             int64  `json:"docs"`
+	SizeBytes        int64  `json:"size_bytes"` // including replicas
+	PrimarySizeBytes int64  `json:"primary_size_bytes"`
+	ReadOnly         bool   `json:"read_only"`
+}
+
+type UsageResponse struct {
+	TenantID         uuid.UUID    `json:"tenant_id"`
+	Name             string       `json:"name,omitempty"`
+	RetentionDays    int32        `json:"retention_days"`
+	IndexCount       int          `json:"index_count"`
+	Docs             int64        `json:"docs"`
+	SizeBytes        int64        `json:"size_bytes"`
+	PrimarySizeBytes int64        `json:"primary_size_bytes"`
+	Indices          []IndexUsage `json:"indices,omitempty"`
+}
+
+type IndexSettingsResponse struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	osstore.IndexOverrides
+	TemplateVersion int       `json:"template_version"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type DriftResponse struct {
+	TemplateVersion int             `json:"template_version"`
+	Drift           []osstore.Drift `json:"drift"`
+}
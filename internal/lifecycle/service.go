@@ -0,0 +1,384 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	"github.com/felipemonteiro/mintlog/internal/archive"
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Policy sets when indices change state, in whole days after the day of
+// the newest event they hold. Zero disables a transition.
+type Policy struct {
+	ReadOnlyAfter   int // block writes
+	ForceMergeAfter int // merge each shard to one segment
+
+	// Rollover moves a tenant's write alias to a new index once the current
+	// one meets any condition.
+	Rollover osstore.RolloverConditions
+}
+
+// Service applies the lifecycle to every tenant's indices on a schedule.
+// Indices whose newest event is older than the tenant's retention_days are
+// deleted, after being exported to the archive when an exporter is set;
+// in the shared indices, expired events are deleted per tenant instead.
+// Write indices are checked for rollover on a shorter schedule of their own.
+// Deletions are announced to the indexers, which check the tenant's write
+// alias again.
+type Service struct {
+	queries       *queries.Queries
+	client        *opensearchapi.Client
+	nc            *nats.Conn
+	exporter      *archive.Exporter
+	policy        Policy
+	interval      time.Duration
+	rolloverEvery time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewService runs every interval and checks rollover every rolloverEvery.
+// exporter may be nil to delete without archiving.
+func NewService(q *queries.Queries, client *opensearchapi.Client, nc *nats.Conn, exporter *archive.Exporter, policy Policy, interval, rolloverEvery time.Duration) *Service {
+	return &Service{queries: q, client: client, nc: nc, exporter: exporter, policy: policy, interval: interval, rolloverEvery: rolloverEvery}
+}
+
+func (s *Service) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.loop(ctx, s.interval, s.Run)
+	}()
+	go func() {
+		defer wg.Done()
+		s.loop(ctx, s.rolloverEvery, s.RollOver)
+	}()
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+}
+
+func (s *Service) loop(ctx context.Context, every time.Duration, run func(context.Context)) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		run(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop waits for a run in progress to stop at the next index.
+func (s *Service) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// RollOver rolls over every tenant's write index that holds events and
+// meets the policy's conditions.
+func (s *Service) RollOver(ctx context.Context) {
+	writes, err := osstore.TenantWriteIndices(ctx, s.client)
+	if err != nil {
+		slog.Error("lifecycle: failed to list write indices", "error", err)
+		return
+	}
+	if len(writes) == 0 {
+		return
+	}
+	indices, err := osstore.ListIndices(ctx, s.client, "mintlog-*")
+	if err != nil {
+		slog.Error("lifecycle: failed to list indices", "error", err)
+		return
+	}
+	docs := make(map[string]int64, len(indices))
+	for _, idx := range indices {
+		docs[idx.Name] = idx.Docs
+	}
+
+	for tenantID, index := range writes {
+		if ctx.Err() != nil {
+			return
+		}
+		// An empty index would otherwise roll over on age alone. Shared
+		// indices roll over as a whole below.
+		if docs[index] == 0 || osstore.SharedIndex(index) {
+			continue
+		}
+		newIndex, err := osstore.Rollover(ctx, s.client, tenantID, s.policy.Rollover)
+		if err != nil {
+			slog.Error("lifecycle: rollover failed", "tenant_id", tenantID, "error", err)
+			continue
+		}
+		if newIndex != "" {
+			slog.Info("index rolled over", "index", index, "new_index", newIndex, "docs", docs[index])
+		}
+	}
+	s.rollOverShared(ctx, docs)
+}
+
+// rollOverShared rolls over the shared indices, then moves any tenant left
+// writing to an older one, such as one that joined during a rollover.
+func (s *Service) rollOverShared(ctx context.Context, docs map[string]int64) {
+	writes, err := osstore.WriteIndices(ctx, s.client, osstore.SharedWriteAlias)
+	if err != nil {
+		slog.Error("lifecycle: failed to get shared write index", "error", err)
+		return
+	}
+	index := writes[osstore.SharedWriteAlias]
+	if index == "" {
+		return
+	}
+	if docs[index] > 0 {
+		newIndex, err := osstore.RolloverShared(ctx, s.client, s.policy.Rollover)
+		if err != nil {
+			slog.Error("lifecycle: shared rollover failed", "error", err)
+		} else if newIndex != "" {
+			slog.Info("index rolled over", "index", index, "new_index", newIndex, "docs", docs[index])
+		}
+	}
+	moved, err := osstore.SyncShared(ctx, s.client)
+	if err != nil {
+		slog.Error("lifecycle: failed to sync shared tenants", "error", err)
+	} else if moved > 0 {
+		slog.Info("shared tenants moved to write index", "count", moved)
+	}
+}
+
+// Run applies the lifecycle once to every tenant.
+func (s *Service) Run(ctx context.Context) {
+	tenants, err := s.queries.ListTenants(ctx)
+	if err != nil {
+		slog.Error("lifecycle: failed to list tenants", "error", err)
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, t := range tenants {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.applyTenant(ctx, t, today); err != nil {
+			slog.Error("lifecycle: tenant failed", "tenant_id", t.ID, "error", err)
+		}
+	}
+	if err := s.applyShared(ctx, tenants, today); err != nil {
+		slog.Error("lifecycle: shared indices failed", "error", err)
+	}
+}
+
+func (s *Service) applyTenant(ctx context.Context, t queries.Tenant, today time.Time) error {
+	tenantID := t.ID.String()
+	pattern := osstore.TenantPattern(tenantID)
+	indices, err := osstore.ListIndices(ctx, s.client, pattern)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	writeIndex, err := osstore.WriteIndex(ctx, s.client, tenantID)
+	if err != nil {
+		return err
+	}
+	newest, err := osstore.NewestEvents(ctx, s.client, pattern)
+	if err != nil {
+		return err
+	}
+	if err := s.aliasDaily(ctx, tenantID, indices); err != nil {
+		slog.Error("lifecycle: failed to alias daily indices", "tenant_id", tenantID, "error", err)
+	}
+
+	for _, idx := range indices {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Daily indices hold events up to the end of their day; backing
+		// indices up to their newest event. The write index and
+		// rehydrated indices are left alone.
+		day, ok := osstore.IndexDate(idx.Name)
+		if !ok {
+			if !osstore.BackingIndex(idx.Name) || idx.Name == writeIndex {
+				continue
+			}
+			ts, found := newest[idx.Name]
+			if !found {
+				if idx.Docs == 0 {
+					s.deleteEmpty(ctx, tenantID, idx)
+				}
+				continue
+			}
+			day = ts.Truncate(24 * time.Hour)
+		}
+		age := int(today.Sub(day) / (24 * time.Hour))
+
+		if t.RetentionDays > 0 && age > int(t.RetentionDays) {
+			if err := s.expire(ctx, tenantID, idx); err != nil {
+				slog.Error("lifecycle: failed to expire index", "index", idx.Name, "error", err)
+			}
+			continue
+		}
+		if s.policy.ForceMergeAfter > 0 && age >= s.policy.ForceMergeAfter && idx.Segments > idx.Shards {
+			if err := osstore.ForceMerge(ctx, s.client, idx.Name, 1); err != nil {
+				slog.Error("lifecycle: failed to force-merge index", "index", idx.Name, "error", err)
+			} else {
+				slog.Info("index force-merged", "index", idx.Name, "segments", idx.Segments)
+			}
+		}
+		if s.policy.ReadOnlyAfter > 0 && age >= s.policy.ReadOnlyAfter && !idx.ReadOnly {
+			if err := osstore.SetReadOnly(ctx, s.client, idx.Name); err != nil {
+				slog.Error("lifecycle: failed to make index read-only", "index", idx.Name, "error", err)
+			} else {
+				slog.Info("index made read-only", "index", idx.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// applyShared enforces each tenant's retention in the shared indices by
+// deleting its expired events, then deletes shared indices left empty.
+// Shared indices are never made read-only, since that would block those
+// deletes, and are not exported: archived already holds their events.
+func (s *Service) applyShared(ctx context.Context, tenants []queries.Tenant, today time.Time) error {
+	members, err := osstore.SharedTenants(ctx, s.client)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	for _, t := range tenants {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !members[t.ID.String()] || t.RetentionDays <= 0 {
+			continue
+		}
+		before := today.AddDate(0, 0, -int(t.RetentionDays))
+		n, err := osstore.DeleteSharedEvents(ctx, s.client, t.ID.String(), before)
+		if err != nil {
+			slog.Error("lifecycle: failed to expire shared events", "tenant_id", t.ID, "error", err)
+		} else if n > 0 {
+			slog.Info("shared events expired", "tenant_id", t.ID, "docs", n)
+		}
+	}
+
+	indices, err := osstore.ListIndices(ctx, s.client, osstore.SharedPattern)
+	if err != nil {
+		return err
+	}
+	writes, err := osstore.WriteIndices(ctx, s.client, osstore.SharedWriteAlias)
+	if err != nil {
+		return err
+	}
+	newest, err := osstore.NewestEvents(ctx, s.client, osstore.SharedPattern)
+	if err != nil {
+		return err
+	}
+	for _, idx := range indices {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if idx.Name == writes[osstore.SharedWriteAlias] {
+			continue
+		}
+		ts, found := newest[idx.Name]
+		if !found {
+			if idx.Docs == 0 {
+				s.deleteEmpty(ctx, "", idx)
+			}
+			continue
+		}
+		age := int(today.Sub(ts.Truncate(24*time.Hour)) / (24 * time.Hour))
+		if s.policy.ForceMergeAfter > 0 && age >= s.policy.ForceMergeAfter && idx.Segments > idx.Shards {
+			if err := osstore.ForceMerge(ctx, s.client, idx.Name, 1); err != nil {
+				slog.Error("lifecycle: failed to force-merge index", "index", idx.Name, "error", err)
+			} else {
+				slog.Info("index force-merged", "index", idx.Name, "segments", idx.Segments)
+			}
+		}
+	}
+	return nil
+}
+
+// aliasDaily adds daily indices written before rollover to the tenant's
+// read alias, so searches keep finding their events.
+func (s *Service) aliasDaily(ctx context.Context, tenantID string, indices []osstore.IndexStats) error {
+	aliased, err := osstore.Aliased(ctx, s.client, osstore.TenantPattern(tenantID), osstore.ReadAlias(tenantID))
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, idx := range indices {
+		if _, ok := osstore.IndexDate(idx.Name); ok && !aliased[idx.Name] {
+			missing = append(missing, idx.Name)
+		}
+	}
+	if err := osstore.AddReadAlias(ctx, s.client, tenantID, missing); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		slog.Info("daily indices added to read alias", "tenant_id", tenantID, "count", len(missing))
+	}
+	return nil
+}
+
+// deleteEmpty deletes a rolled-over index that holds no events. tenantID
+// is empty for shared indices.
+func (s *Service) deleteEmpty(ctx context.Context, tenantID string, idx osstore.IndexStats) {
+	if err := osstore.DeleteIndex(ctx, s.client, idx.Name); err != nil {
+		slog.Error("lifecycle: failed to delete empty index", "index", idx.Name, "error", err)
+		return
+	}
+	s.indicesChanged(tenantID)
+	slog.Info("empty index deleted", "index", idx.Name)
+}
+
+// expire archives an index if configured, then deletes it. The index is
+// kept if the archive does not hold every document.
+func (s *Service) expire(ctx context.Context, tenantID string, idx osstore.IndexStats) error {
+	if s.exporter != nil {
+		n, err := s.exporter.Export(ctx, tenantID, idx.Name)
+		if err != nil {
+			return err
+		}
+		if int64(n) != idx.Docs {
+			return fmt.Errorf("archived %d of %d documents", n, idx.Docs)
+		}
+		slog.Info("index exported to archive", "index", idx.Name, "docs", n)
+	}
+	if err := osstore.DeleteIndex(ctx, s.client, idx.Name); err != nil {
+		return err
+	}
+	s.indicesChanged(tenantID)
+	slog.Info("index deleted", "index", idx.Name, "docs", idx.Docs, "bytes", idx.Bytes)
+	return nil
+}
+
+// indicesChanged tells the indexers that a tenant's indices, or the shared
+// ones if tenantID is empty, were deleted. A failure only delays the
+// indexers noticing until a write fails.
+func (s *Service) indicesChanged(tenantID string) {
+	if err := osstore.AnnounceIndicesChanged(s.nc, tenantID); err != nil {
+		slog.Warn("lifecycle: failed to announce index change", "tenant_id", tenantID, "error", err)
+	}
+}
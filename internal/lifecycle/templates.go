@@ -0,0 +1,166 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+// Overrides loads every tenant's index overrides, by tenant ID.
+func Overrides(ctx context.Context, q *queries.Queries) (map[string]osstore.IndexOverrides, error) {
+	rows, err := q.ListIndexSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]osstore.IndexOverrides, len(rows))
+	for _, s := range rows {
+		out[s.TenantID.String()] = overrides(s)
+	}
+	return out, nil
+}
+
+func overrides(s queries.IndexSetting) osstore.IndexOverrides {
+	var o osstore.IndexOverrides
+	if s.Shards.Valid {
+		n := int(s.Shards.Int32)
+		o.Shards = &n
+	}
+	if s.Replicas.Valid {
+		n := int(s.Replicas.Int32)
+		o.Replicas = &n
+	}
+	o.RefreshInterval = s.RefreshInterval.String
+	o.MessageAnalyzer = s.MessageAnalyzer.String
+	json.Unmarshal(s.Mappings, &o.Mappings)
+	return o
+}
+
+func indexSettingsResponse(s queries.IndexSetting) IndexSettingsResponse {
+	return IndexSettingsResponse{
+		TenantID:        s.TenantID,
+		IndexOverrides:  overrides(s),
+		TemplateVersion: osstore.CurrentTemplate().Version,
+		UpdatedAt:       s.UpdatedAt,
+	}
+}
+
+// GetIndexSettings returns a tenant's index overrides.
+func (h *Handler) GetIndexSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid tenant ID"))
+		return
+	}
+	s, err := h.queries.GetIndexSettings(r.Context(), tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apierror.Write(w, apierror.NotFound("tenant has no index settings"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to get index settings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(indexSettingsResponse(s))
+}
+
+// PutIndexSettings sets a tenant's index overrides and updates its
+// template. Existing indices keep their settings until
+// `indexctl templates migrate`; new ones get the overrides.
+func (h *Handler) PutIndexSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid tenant ID"))
+		return
+	}
+	var o osstore.IndexOverrides
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON"))
+		return
+	}
+	if err := o.Validate(); err != nil {
+		apierror.Write(w, apierror.BadRequest(err.Error()))
+		return
+	}
+	if _, err := h.queries.GetTenant(r.Context(), tenantID); err != nil {
+		apierror.Write(w, apierror.NotFound("tenant not found"))
+		return
+	}
+
+	var shards, replicas pgtype.Int4
+	if o.Shards != nil {
+		shards = pgtype.Int4{Int32: int32(*o.Shards), Valid: true}
+	}
+	if o.Replicas != nil {
+		replicas = pgtype.Int4{Int32: int32(*o.Replicas), Valid: true}
+	}
+	refresh := pgtype.Text{String: o.RefreshInterval, Valid: o.RefreshInterval != ""}
+	analyzer := pgtype.Text{String: o.MessageAnalyzer, Valid: o.MessageAnalyzer != ""}
+	mappings := []byte("{}")
+	if len(o.Mappings) > 0 {
+		mappings, _ = json.Marshal(o.Mappings)
+	}
+
+	s, err := h.queries.UpsertIndexSettings(r.Context(), tenantID, shards, replicas, refresh, mappings, analyzer)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to save index settings"))
+		return
+	}
+	if err := osstore.PutTenantTemplate(r.Context(), h.client, tenantID.String(), o); err != nil {
+		apierror.Write(w, apierror.Internal("failed to update index template: "+err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(indexSettingsResponse(s))
+}
+
+// DeleteIndexSettings drops a tenant's index overrides and its template.
+func (h *Handler) DeleteIndexSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid tenant ID"))
+		return
+	}
+	if err := h.queries.DeleteIndexSettings(r.Context(), tenantID); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete index settings"))
+		return
+	}
+	if err := osstore.DeleteTenantTemplate(r.Context(), h.client, tenantID.String()); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete index template: "+err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Drift reports the templates and indices that differ from the current
+// template and the tenants' overrides.
+func (h *Handler) Drift(w http.ResponseWriter, r *http.Request) {
+	overrides, err := Overrides(r.Context(), h.queries)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list index settings"))
+		return
+	}
+	drift, err := osstore.CheckDrift(r.Context(), h.client, overrides)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to check drift: "+err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DriftResponse{
+		TemplateVersion: osstore.CurrentTemplate().Version,
+		Drift:           drift,
+	})
+}
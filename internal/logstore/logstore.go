@@ -0,0 +1,141 @@
+// Package logstore defines how parsed events are stored and queried,
+// independently of the backend holding them.
+package logstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// LogStore stores events and answers queries over one tenant's events at
+// a time.
+type LogStore interface {
+	// Write stores events, which may belong to several tenants.
+	Write(ctx context.Context, events []*logmodel.LogEvent) error
+
+	// Search returns a page of matching events, sorted by timestamp then
+	// ID.
+	Search(ctx context.Context, q *Query, opts SearchOptions) (*SearchResult, error)
+
+	// Count returns how many events match.
+	Count(ctx context.Context, q *Query) (int64, error)
+
+	// Aggregate groups matching events.
+	Aggregate(ctx context.Context, q *Query, agg *Aggregation) (*AggregateResult, error)
+
+	// Tail calls fn with matching events as they arrive, oldest first,
+	// until ctx is done or fn returns an error.
+	Tail(ctx context.Context, q *Query, fn func(json.RawMessage) error) error
+}
+
+// ErrInvalidCursor is returned by Search for a SearchAfter it cannot use.
+var ErrInvalidCursor = errors.New("invalid search_after")
+
+// Query selects a tenant's events. Empty fields match anything.
+type Query struct {
+	TenantID string
+
+	// Text matches events whose message contains every word of it, or any
+	// word with AnyWord set, unless Match says otherwise.
+	Text    string
+	AnyWord bool
+	Match   Match
+
+	Level    string
+	Service  string
+	Host     string
+	TraceIDs []string // any of them
+
+	// Fields matches exact values by field path, such as "service" or
+	// "fields.status".
+	Fields map[string]string
+
+	// Expr is a parsed query matched on top of the rest.
+	Expr Expr
+
+	From  time.Time // inclusive
+	To    time.Time // inclusive
+	After time.Time // exclusive
+
+	// text matches a message by Text and Match; set by Compile.
+	text func(message string) bool
+}
+
+// Expr is a parsed query expression, such as one of package querylang.
+type Expr interface {
+	// Matches reports whether an event matches, for backends that scan
+	// events.
+	Matches(e *logmodel.LogEvent) bool
+
+	// DSL returns the expression as an OpenSearch query.
+	DSL() map[string]any
+}
+
+// Match is how a query's Text matches the message.
+type Match string
+
+const (
+	MatchWords    Match = ""         // every word of Text, or any with AnyWord
+	MatchPhrase   Match = "phrase"   // the words of Text, in order and adjacent
+	MatchExact    Match = "exact"    // the whole message, case-sensitively
+	MatchPrefix   Match = "prefix"   // the start of the message
+	MatchWildcard Match = "wildcard" // the whole message, * matching any run of characters and ? any one
+	MatchRegex    Match = "regex"    // the whole message, by a regular expression
+)
+
+// SearchOptions pages a search.
+type SearchOptions struct {
+	Size int
+	Asc  bool // oldest first; newest first by default
+
+	// SearchAfter continues from the Cursor of a previous result.
+	SearchAfter []any
+}
+
+type SearchResult struct {
+	Hits  []json.RawMessage
+	Total int
+
+	// Cursor identifies the last hit, to pass as SearchAfter for the next
+	// page; nil without hits.
+	Cursor []any
+}
+
+// Aggregation groups events by a field, over time, or both. With neither
+// set it groups by level.
+type Aggregation struct {
+	GroupBy  string        // field path
+	Size     int           // groups returned, largest first; default 20
+	Interval time.Duration // histogram bucket width
+
+	// Weighted adds the count estimated before sampling: each event counts
+	// 1/sample_rate.
+	Weighted bool
+
+	// Seen adds each group's first and last event time.
+	Seen bool
+
+	// Sample adds these fields of each group's latest event.
+	Sample []string
+}
+
+type AggregateResult struct {
+	Total          int64    // matching events
+	EstimatedTotal *float64 // with Weighted
+	Groups         []Bucket // with GroupBy
+	OverTime       []Bucket // with Interval, oldest first
+}
+
+type Bucket struct {
+	Key            string    // group value; empty over time
+	Time           time.Time // bucket start over time
+	Count          int64
+	EstimatedCount *float64        // with Weighted
+	FirstSeen      time.Time       // with Seen
+	LastSeen       time.Time       // with Seen
+	Sample         json.RawMessage // with Sample
+}
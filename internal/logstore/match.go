@@ -0,0 +1,241 @@
+package logstore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Matches reports whether an event matches q. It is how backends without
+// a query engine of their own apply a query, and approximates OpenSearch:
+// message words are compared as Terms, other fields exactly.
+//
+// It is not safe for concurrent use until q is compiled.
+func (q *Query) Matches(e *logmodel.LogEvent) bool {
+	if q.TenantID != "" && e.TenantID != q.TenantID {
+		return false
+	}
+	if !q.From.IsZero() && e.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && e.Timestamp.After(q.To) {
+		return false
+	}
+	if !q.After.IsZero() && !e.Timestamp.After(q.After) {
+		return false
+	}
+	if q.Level != "" && e.Level != q.Level {
+		return false
+	}
+	if q.Service != "" && e.Service != q.Service {
+		return false
+	}
+	if q.Host != "" && e.Host != q.Host {
+		return false
+	}
+	if len(q.TraceIDs) > 0 && !slices.Contains(q.TraceIDs, e.TraceID) {
+		return false
+	}
+	for path, want := range q.Fields {
+		if !fieldEquals(e, path, want) {
+			return false
+		}
+	}
+	if q.Expr != nil && !q.Expr.Matches(e) {
+		return false
+	}
+	if q.Text != "" {
+		if q.text == nil {
+			q.Compile()
+		}
+		return q.text(e.Message)
+	}
+	return true
+}
+
+// Compile checks Text against Match and prepares the query for Matches,
+// which compiles it on first use otherwise. A Text that does not compile
+// matches nothing.
+func (q *Query) Compile() error {
+	text := q.Text
+	q.text = func(string) bool { return false }
+	switch q.Match {
+	case MatchWords:
+		terms := Terms(text)
+		if q.AnyWord {
+			q.text = func(message string) bool {
+				words := Terms(message)
+				return slices.ContainsFunc(terms, func(t string) bool { return slices.Contains(words, t) })
+			}
+		} else {
+			q.text = func(message string) bool {
+				words := Terms(message)
+				return !slices.ContainsFunc(terms, func(t string) bool { return !slices.Contains(words, t) })
+			}
+		}
+	case MatchPhrase:
+		terms := Terms(text)
+		q.text = func(message string) bool { return ContainsRun(Terms(message), terms) }
+	case MatchExact:
+		q.text = func(message string) bool { return message == text }
+	case MatchPrefix:
+		q.text = func(message string) bool { return strings.HasPrefix(message, text) }
+	case MatchWildcard:
+		q.text = Glob(text).MatchString
+	case MatchRegex:
+		if _, err := regexp.Compile(text); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		q.text = regexp.MustCompile(`^(?s:` + text + `)$`).MatchString
+	default:
+		return fmt.Errorf("match must be words, phrase, exact, prefix, wildcard or regex")
+	}
+	return nil
+}
+
+// ContainsRun reports whether run appears in words, in order and adjacent.
+func ContainsRun(words, run []string) bool {
+	if len(run) == 0 {
+		return true
+	}
+	for i := 0; i+len(run) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(run)], run) {
+			return true
+		}
+	}
+	return false
+}
+
+// Glob compiles an OpenSearch wildcard pattern, where * matches any run of
+// characters, ? any one and \ escapes the next, to a regular expression
+// matching whole strings.
+func Glob(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			b.WriteString(`.*`)
+		case r == '?':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		b.WriteString(`\\`)
+	}
+	b.WriteString(`)$`)
+	return regexp.MustCompile(b.String())
+}
+
+// Terms splits text into lower-cased words: runs of letters and digits.
+// It approximates the tokens OpenSearch's standard analyzer produces for
+// the message field.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// FieldValue returns the value of a field path in an event: a top-level
+// field by its JSON name, or a path into fields such as "fields.ua.os".
+func FieldValue(e *logmodel.LogEvent, path string) (any, bool) {
+	switch path {
+	case "id":
+		return e.ID, true
+	case "tenant_id":
+		return e.TenantID, true
+	case "timestamp":
+		return e.Timestamp, true
+	case "level":
+		return e.Level, true
+	case "message":
+		return e.Message, true
+	case "service":
+		return e.Service, true
+	case "host":
+		return e.Host, e.Host != ""
+	case "trace_id":
+		return e.TraceID, e.TraceID != ""
+	case "span_id":
+		return e.SpanID, e.SpanID != ""
+	case "pattern_id":
+		return e.PatternID, e.PatternID != ""
+	case "pattern":
+		return e.Pattern, e.Pattern != ""
+	case "sample_rate":
+		return e.SampleRate, e.SampleRate != 0
+	case "tags":
+		return e.Tags, len(e.Tags) > 0
+	}
+
+	rest, ok := strings.CutPrefix(path, "fields.")
+	if !ok {
+		return nil, false
+	}
+	// Flattened fields keep their dotted names as keys.
+	if v, ok := e.Fields[rest]; ok {
+		return v, v != nil
+	}
+	var v any = e.Fields
+	for _, key := range strings.Split(rest, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// FormatValue renders a field value the way it is compared and grouped.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// fieldEquals reports whether a field holds want, or for an array whether
+// any element does.
+func fieldEquals(e *logmodel.LogEvent, path, want string) bool {
+	v, ok := FieldValue(e, path)
+	if !ok {
+		return false
+	}
+	switch v := v.(type) {
+	case []string:
+		return slices.Contains(v, want)
+	case []any:
+		return slices.ContainsFunc(v, func(item any) bool { return FormatValue(item) == want })
+	}
+	return FormatValue(v) == want
+}
+
+// Weight returns how many events an event stands for before sampling.
+func Weight(e *logmodel.LogEvent) float64 {
+	if e.SampleRate <= 0 {
+		return 1
+	}
+	return 1 / e.SampleRate
+}
@@ -0,0 +1,25 @@
+package logstore
+
+import (
+	"testing"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+func TestWeight(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want float64
+	}{
+		{0, 1},
+		{-1, 1},
+		{1, 1},
+		{0.25, 4},
+		{0.1, 10},
+	}
+	for _, tt := range tests {
+		if got := Weight(&logmodel.LogEvent{SampleRate: tt.rate}); got != tt.want {
+			t.Errorf("Weight(sample_rate %v) = %v, want %v", tt.rate, got, tt.want)
+		}
+	}
+}
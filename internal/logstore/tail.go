@@ -0,0 +1,43 @@
+package logstore
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const (
+	tailEvery    = 2 * time.Second
+	tailLookback = 10 * time.Second
+	tailPage     = 100
+)
+
+// PollTail implements Tail by searching for newer events every two
+// seconds, starting ten seconds back. Search errors are logged and the
+// poll retried.
+func PollTail(ctx context.Context, s LogStore, q *Query, fn func(json.RawMessage) error) error {
+	tq := *q
+	tq.After = time.Now().UTC().Add(-tailLookback)
+	ticker := time.NewTicker(tailEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			result, err := s.Search(ctx, &tq, SearchOptions{Size: tailPage, Asc: true})
+			if err != nil {
+				slog.Error("tail search failed", "error", err)
+				continue
+			}
+			for _, hit := range result.Hits {
+				if err := fn(hit); err != nil {
+					return err
+				}
+			}
+			tq.After = time.Now().UTC()
+		}
+	}
+}
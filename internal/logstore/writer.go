@@ -0,0 +1,168 @@
+package logstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/felipemonteiro/mintlog/internal/bus"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const (
+	// stageName identifies the writer in dead-letter headers.
+	stageName = "logstore"
+
+	retryDelay   = 5 * time.Second
+	writeTimeout = 30 * time.Second
+)
+
+// Writer consumes logs.parsed and writes events to a LogStore in batches
+// of up to batchSize, or whatever is buffered every flushEvery. It is the
+// counterpart of the OpenSearch indexer for other backends.
+type Writer struct {
+	store      LogStore
+	js         nats.JetStreamContext
+	pub        *bus.Publisher
+	sub        *nats.Subscription
+	dlq        *nats.Subscription
+	batchSize  int
+	flushEvery time.Duration
+	maxPending int
+
+	mu     sync.Mutex // serializes writes
+	bufMu  sync.Mutex
+	buffer []pending
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type pending struct {
+	event *logmodel.LogEvent
+	msg   *nats.Msg
+}
+
+func NewWriter(store LogStore, js nats.JetStreamContext, pub *bus.Publisher, batchSize int, flushEvery time.Duration, maxPending int) *Writer {
+	return &Writer{
+		store:      store,
+		js:         js,
+		pub:        pub,
+		batchSize:  max(batchSize, 1),
+		flushEvery: flushEvery,
+		maxPending: maxPending,
+	}
+}
+
+func (w *Writer) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	err := bus.EnsureQueueConsumer(w.js, "LOGS_PARSED", "logstore-writer", "logstore-writers", "logs.parsed.>", w.flushEvery+writeTimeout, w.maxPending)
+	if err != nil {
+		return err
+	}
+	w.dlq, err = w.pub.WatchMaxDeliveries("LOGS_PARSED", "logstore-writer", stageName)
+	if err != nil {
+		return err
+	}
+	sub, err := w.js.QueueSubscribe(
+		"logs.parsed.>",
+		"logstore-writers",
+		w.handleMessage,
+		nats.Bind("LOGS_PARSED", "logstore-writer"),
+		nats.ManualAck(),
+	)
+	if err != nil {
+		return fmt.Errorf("subscribe logs.parsed: %w", err)
+	}
+	sub.SetPendingLimits(-1, -1)
+	w.sub = sub
+
+	go w.flushLoop(ctx)
+
+	slog.Info("log store writer started", "batch_size", w.batchSize)
+	return nil
+}
+
+// Stop stops consuming and writes what is buffered.
+func (w *Writer) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	if w.sub != nil {
+		w.sub.Unsubscribe()
+	}
+	w.dlq.Unsubscribe()
+	w.flush()
+}
+
+func (w *Writer) handleMessage(msg *nats.Msg) {
+	var event logmodel.LogEvent
+	if err := bus.DecodeEvent(msg.Header, msg.Data, &event); err != nil {
+		slog.Error("log store writer: decode failed", "error", err)
+		w.pub.Reject(msg, stageName, fmt.Errorf("decode: %w", err))
+		return
+	}
+
+	w.bufMu.Lock()
+	w.buffer = append(w.buffer, pending{event: &event, msg: msg})
+	full := len(w.buffer) >= w.batchSize
+	w.bufMu.Unlock()
+
+	if full {
+		w.flush()
+	}
+}
+
+func (w *Writer) flushLoop(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.flushEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+// flush writes the buffer and acks it, or retries every message if the
+// write fails.
+func (w *Writer) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.bufMu.Lock()
+	batch := w.buffer
+	w.buffer = nil
+	w.bufMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	events := make([]*logmodel.LogEvent, len(batch))
+	for i, p := range batch {
+		events[i] = p.event
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := w.store.Write(ctx, events); err != nil {
+		slog.Error("log store write failed", "error", err, "count", len(batch))
+		for _, p := range batch {
+			w.pub.Retry(p.msg, stageName, fmt.Errorf("write: %w", err), retryDelay)
+		}
+		return
+	}
+	for _, p := range batch {
+		p.msg.Ack()
+	}
+	slog.Debug("log store wrote events", "count", len(batch))
+}
@@ -0,0 +1,164 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Table is an in-memory copy of a tenant's lookup table.
+type Table struct {
+	ID         uuid.UUID
+	TenantID   string
+	Name       string
+	MatchField string
+	Version    int32
+	Rows       Rows
+}
+
+// Lookup returns the row for key. Callers must not modify the result.
+func (t *Table) Lookup(key string) (map[string]any, bool) {
+	row, ok := t.Rows[key]
+	return row, ok
+}
+
+// Cache keeps every tenant's lookup tables in memory and reloads tables
+// whose version changed in Postgres.
+type Cache struct {
+	queries  *queries.Queries
+	interval time.Duration
+
+	mu       sync.RWMutex
+	byID     map[uuid.UUID]*Table
+	byTenant map[string][]*Table
+	cancel   context.CancelFunc
+}
+
+func NewCache(q *queries.Queries, interval time.Duration) *Cache {
+	return &Cache{
+		queries:  q,
+		interval: interval,
+		byID:     make(map[uuid.UUID]*Table),
+		byTenant: make(map[string][]*Table),
+	}
+}
+
+// Start loads all tables and keeps refreshing them until Stop is called.
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	tables := len(c.byID)
+	c.mu.RUnlock()
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					slog.Error("lookup cache: refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	slog.Info("lookup cache started", "tables", tables)
+	return nil
+}
+
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Tables returns the tenant's tables ordered by name.
+func (c *Cache) Tables(tenantID string) []*Table {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.byTenant[tenantID]
+}
+
+// Refresh reloads tables that are new or whose version changed and drops
+// tables that were deleted.
+func (c *Cache) Refresh(ctx context.Context) error {
+	versions, err := c.queries.ListLookupTableVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("list lookup tables: %w", err)
+	}
+
+	c.mu.RLock()
+	current := make(map[uuid.UUID]*Table, len(c.byID))
+	for id, t := range c.byID {
+		current[id] = t
+	}
+	c.mu.RUnlock()
+
+	next := make(map[uuid.UUID]*Table, len(versions))
+	for _, v := range versions {
+		if t, ok := current[v.ID]; ok && t.Version == v.Version {
+			next[v.ID] = t
+			continue
+		}
+
+		t, err := c.load(ctx, v.ID)
+		if err != nil {
+			slog.Error("lookup cache: failed to load table", "table_id", v.ID, "error", err)
+			if old, ok := current[v.ID]; ok {
+				next[v.ID] = old
+			}
+			continue
+		}
+		next[v.ID] = t
+		slog.Info("lookup table loaded", "tenant_id", t.TenantID, "name", t.Name, "version", t.Version, "rows", len(t.Rows))
+	}
+
+	byTenant := make(map[string][]*Table)
+	for _, t := range next {
+		byTenant[t.TenantID] = append(byTenant[t.TenantID], t)
+	}
+	for _, tables := range byTenant {
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	}
+
+	c.mu.Lock()
+	c.byID = next
+	c.byTenant = byTenant
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) load(ctx context.Context, id uuid.UUID) (*Table, error) {
+	row, err := c.queries.GetLookupTableByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows Rows
+	if err := json.Unmarshal(row.Rows, &rows); err != nil {
+		return nil, fmt.Errorf("decode rows: %w", err)
+	}
+
+	return &Table{
+		ID:         row.ID,
+		TenantID:   row.TenantID.String(),
+		Name:       row.Name,
+		MatchField: row.MatchField,
+		Version:    row.Version,
+		Rows:       rows,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package lookup
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+type Handler struct {
+	queries *queries.Queries
+}
+
+func NewHandler(q *queries.Queries) *Handler {
+	return &Handler{queries: q}
+}
+
+// Upload creates or replaces a lookup table. The body is CSV when the
+// Content-Type is text/csv and a JSON array of objects otherwise.
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if !validName.MatchString(name) {
+		apierror.Write(w, apierror.BadRequest("name must match "+validName.String()))
+		return
+	}
+
+	matchField := r.URL.Query().Get("match_field")
+	if matchField == "" {
+		apierror.Write(w, apierror.BadRequest("match_field is required"))
+		return
+	}
+	keyColumn := r.URL.Query().Get("key_column")
+
+	body := http.MaxBytesReader(w, r.Body, maxUploadBytes)
+
+	var (
+		rows    Rows
+		columns []string
+		err     error
+	)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		rows, columns, keyColumn, err = ParseCSV(body, keyColumn)
+	} else {
+		rows, columns, err = ParseJSON(body, keyColumn)
+	}
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest(err.Error()))
+		return
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to encode table"))
+		return
+	}
+
+	t, err := h.queries.UpsertLookupTable(r.Context(), info.ID, name, matchField, keyColumn, columns, data, int32(len(rows)))
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to store lookup table"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTableResponse(t))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	t, err := h.queries.GetLookupTable(r.Context(), info.ID, chi.URLParam(r, "name"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		apierror.Write(w, apierror.NotFound("lookup table not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to get lookup table"))
+		return
+	}
+
+	resp := toTableResponse(queries.LookupTableMeta{
+		ID:         t.ID,
+		TenantID:   t.TenantID,
+		Name:       t.Name,
+		MatchField: t.MatchField,
+		KeyColumn:  t.KeyColumn,
+		Columns:    t.Columns,
+		RowCount:   t.RowCount,
+		Version:    t.Version,
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+	})
+	if r.URL.Query().Get("rows") == "true" {
+		resp.Rows = t.Rows
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	tables, err := h.queries.ListLookupTables(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list lookup tables"))
+		return
+	}
+
+	resp := make([]TableResponse, len(tables))
+	for i, t := range tables {
+		resp[i] = toTableResponse(t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	if err := h.queries.DeleteLookupTable(r.Context(), info.ID, chi.URLParam(r, "name")); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete lookup table"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toTableResponse(t queries.LookupTableMeta) TableResponse {
+	return TableResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		MatchField: t.MatchField,
+		KeyColumn:  t.KeyColumn,
+		Columns:    t.Columns,
+		RowCount:   t.RowCount,
+		Version:    t.Version,
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+	}
+}
@@ -0,0 +1,21 @@
+package lookup
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TableResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	Name       string          `json:"name"`
+	MatchField string          `json:"match_field"`
+	KeyColumn  string          `json:"key_column"`
+	Columns    []string        `json:"columns"`
+	RowCount   int32           `json:"row_count"`
+	Version    int32           `json:"version"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Rows       json.RawMessage `json:"rows,omitempty"`
+}
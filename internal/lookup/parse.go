@@ -0,0 +1,122 @@
+package lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	maxUploadBytes = 10 << 20
+	maxRows        = 100_000
+)
+
+// Rows maps a key value to the remaining columns of its row.
+type Rows map[string]map[string]any
+
+// ParseCSV reads a CSV table whose first record is the header. If keyColumn
+// is empty the first column is used. Later rows win on duplicate keys.
+func ParseCSV(r io.Reader, keyColumn string) (Rows, []string, string, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("read header: %w", err)
+	}
+	if keyColumn == "" {
+		keyColumn = header[0]
+	}
+
+	keyIdx := -1
+	for i, col := range header {
+		if col == keyColumn {
+			keyIdx = i
+		}
+	}
+	if keyIdx < 0 {
+		return nil, nil, "", fmt.Errorf("key column %q not in header", keyColumn)
+	}
+
+	rows := make(Rows)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("read row: %w", err)
+		}
+		if len(rows) >= maxRows {
+			return nil, nil, "", fmt.Errorf("table exceeds maximum of %d rows", maxRows)
+		}
+
+		row := make(map[string]any, len(header)-1)
+		for i, col := range header {
+			if i != keyIdx {
+				row[col] = record[i]
+			}
+		}
+		rows[record[keyIdx]] = row
+	}
+
+	return rows, header, keyColumn, nil
+}
+
+// ParseJSON reads a JSON array of objects keyed by keyColumn.
+func ParseJSON(r io.Reader, keyColumn string) (Rows, []string, error) {
+	if keyColumn == "" {
+		return nil, nil, fmt.Errorf("key_column is required for JSON tables")
+	}
+
+	var records []map[string]any
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, nil, fmt.Errorf("decode rows: %w", err)
+	}
+	if len(records) > maxRows {
+		return nil, nil, fmt.Errorf("table exceeds maximum of %d rows", maxRows)
+	}
+
+	seen := map[string]bool{}
+	rows := make(Rows, len(records))
+	for i, record := range records {
+		key, ok := record[keyColumn]
+		if !ok {
+			return nil, nil, fmt.Errorf("row %d has no %q column", i, keyColumn)
+		}
+		row := make(map[string]any, len(record)-1)
+		for col, v := range record {
+			seen[col] = true
+			if col != keyColumn {
+				row[col] = v
+			}
+		}
+		rows[KeyString(key)] = row
+	}
+
+	columns := make([]string, 0, len(seen))
+	for col := range seen {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	return rows, columns, nil
+}
+
+// KeyString renders a key value the way keys are stored.
+func KeyString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		if t == float64(int64(t)) {
+			return fmt.Sprintf("%d", int64(t))
+		}
+		return fmt.Sprintf("%g", t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,274 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Resolution is a rollup interval and how long its points are kept.
+type Resolution struct {
+	Name      string
+	Step      time.Duration
+	Retention time.Duration
+}
+
+// Resolutions are the fixed rollup intervals, finest first.
+var Resolutions = []Resolution{
+	{Name: "1m", Step: time.Minute, Retention: 7 * 24 * time.Hour},
+	{Name: "1h", Step: time.Hour, Retention: 400 * 24 * time.Hour},
+}
+
+const (
+	pruneInterval = time.Hour
+
+	// finalFlushTimeout bounds the flush on Stop, so shutdown doesn't hang
+	// on an unreachable database.
+	finalFlushTimeout = 10 * time.Second
+
+	// maxRequeuedPoints caps the points kept across failed flushes. During
+	// a long outage new buckets keep starting; points beyond the cap are
+	// dropped rather than held without bound.
+	maxRequeuedPoints = 100_000
+)
+
+// Aggregator accumulates metric values in memory and adds them to the
+// Postgres rollups every interval.
+type Aggregator struct {
+	queries   *queries.Queries
+	interval  time.Duration
+	maxSeries int
+
+	mu        sync.Mutex
+	points    map[pointKey]*point
+	series    map[uuid.UUID]map[string]bool
+	dropped   int64 // observations over the series limit
+	lost      int64 // points dropped after failed flushes
+	lastPrune time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+type pointKey struct {
+	metricID   uuid.UUID
+	resolution int32
+	start      int64
+	labelsKey  string
+}
+
+type point struct {
+	labels  []byte
+	count   float64
+	sum     float64
+	min     float64
+	max     float64
+	buckets []float64
+}
+
+// NewAggregator returns an aggregator keeping at most maxSeries label
+// combinations per metric between flushes.
+func NewAggregator(q *queries.Queries, interval time.Duration, maxSeries int) *Aggregator {
+	return &Aggregator{
+		queries:   q,
+		interval:  interval,
+		maxSeries: maxSeries,
+		points:    make(map[pointKey]*point),
+		series:    make(map[uuid.UUID]map[string]bool),
+	}
+}
+
+// Record adds one observation. weight is the number of events it stands
+// for (1/sample_rate for sampled events); value is ignored for counters.
+func (a *Aggregator) Record(m *Metric, labels map[string]string, value, weight float64, at time.Time) {
+	encoded, err := json.Marshal(labels) // map keys are sorted, so this is canonical
+	if err != nil {
+		return
+	}
+	labelsKey := string(encoded)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen, ok := a.series[m.ID]
+	if !ok {
+		seen = make(map[string]bool)
+		a.series[m.ID] = seen
+	}
+	if !seen[labelsKey] {
+		if len(seen) >= a.maxSeries {
+			a.dropped++
+			return
+		}
+		seen[labelsKey] = true
+	}
+
+	for _, res := range Resolutions {
+		key := pointKey{
+			metricID:   m.ID,
+			resolution: int32(res.Step / time.Second),
+			start:      at.Truncate(res.Step).Unix(),
+			labelsKey:  labelsKey,
+		}
+		p, ok := a.points[key]
+		if !ok {
+			p = &point{labels: encoded, min: math.Inf(1), max: math.Inf(-1)}
+			if m.Type == TypeHistogram {
+				p.buckets = make([]float64, len(m.Buckets)+1)
+			}
+			a.points[key] = p
+		}
+
+		p.count += weight
+		if m.Type != TypeHistogram {
+			continue
+		}
+		p.sum += value * weight
+		p.min = math.Min(p.min, value)
+		p.max = math.Max(p.max, value)
+		if len(p.buckets) == len(m.Buckets)+1 {
+			p.buckets[sort.SearchFloat64s(m.Buckets, value)] += weight
+		}
+	}
+}
+
+// Start flushes every interval until Stop is called.
+func (a *Aggregator) Start(ctx context.Context) {
+	ctx, a.cancel = context.WithCancel(ctx)
+	a.done = make(chan struct{})
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				ctx, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+				a.Flush(ctx)
+				cancel()
+				return
+			case <-ticker.C:
+				a.Flush(ctx)
+				a.prune(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the flush loop after a final flush.
+func (a *Aggregator) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	<-a.done
+}
+
+// Flush writes accumulated points. Points that fail to write are kept for
+// the next flush, within the series limit and maxRequeuedPoints.
+func (a *Aggregator) Flush(ctx context.Context) {
+	a.mu.Lock()
+	points := a.points
+	dropped, lost := a.dropped, a.lost
+	a.points = make(map[pointKey]*point)
+	a.series = make(map[uuid.UUID]map[string]bool)
+	a.dropped, a.lost = 0, 0
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		slog.Warn("metrics: series limit reached, observations dropped", "dropped", dropped, "max_series", a.maxSeries)
+	}
+	if lost > 0 {
+		slog.Error("metrics: points dropped after failed flushes", "dropped", lost, "max_requeued", maxRequeuedPoints)
+	}
+
+	failed := 0
+	for key, p := range points {
+		err := a.queries.UpsertMetricPoint(ctx, queries.MetricPoint{
+			MetricID:     key.metricID,
+			Resolution:   key.resolution,
+			BucketStart:  time.Unix(key.start, 0).UTC(),
+			Labels:       p.labels,
+			LabelsKey:    key.labelsKey,
+			Count:        p.count,
+			Sum:          p.sum,
+			Min:          finite(p.min),
+			Max:          finite(p.max),
+			BucketCounts: p.buckets,
+		})
+		if err != nil {
+			failed++
+			a.requeue(key, p)
+		}
+	}
+	if failed > 0 {
+		slog.Error("metrics: flush incomplete", "failed", failed, "total", len(points))
+	}
+}
+
+// requeue keeps a point that failed to write for the next flush. Its
+// series counts against the metric's limit again, as if just recorded.
+func (a *Aggregator) requeue(key pointKey, p *point) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cur, ok := a.points[key]
+	if !ok {
+		seen, ok := a.series[key.metricID]
+		if !ok {
+			seen = make(map[string]bool)
+			a.series[key.metricID] = seen
+		}
+		if !seen[key.labelsKey] && len(seen) >= a.maxSeries || len(a.points) >= maxRequeuedPoints {
+			a.lost++
+			return
+		}
+		seen[key.labelsKey] = true
+		a.points[key] = p
+		return
+	}
+	cur.count += p.count
+	cur.sum += p.sum
+	cur.min = math.Min(cur.min, p.min)
+	cur.max = math.Max(cur.max, p.max)
+	for i := range cur.buckets {
+		if i < len(p.buckets) {
+			cur.buckets[i] += p.buckets[i]
+		}
+	}
+}
+
+// prune deletes points older than their resolution's retention, at most
+// once per pruneInterval.
+func (a *Aggregator) prune(ctx context.Context) {
+	if time.Since(a.lastPrune) < pruneInterval {
+		return
+	}
+	a.lastPrune = time.Now()
+
+	for _, res := range Resolutions {
+		before := time.Now().UTC().Add(-res.Retention)
+		n, err := a.queries.DeleteMetricPointsBefore(ctx, int32(res.Step/time.Second), before)
+		if err != nil {
+			slog.Error("metrics: prune failed", "resolution", res.Name, "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.Info("metrics: pruned points", "resolution", res.Name, "deleted", n)
+		}
+	}
+}
+
+func finite(v float64) float64 {
+	if math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// execDB counts upserts and fails them while down is set.
+type execDB struct {
+	down  bool
+	execs int
+}
+
+func (db *execDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	db.execs++
+	if db.down {
+		return pgconn.CommandTag{}, errors.New("connection refused")
+	}
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (db *execDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (db *execDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	return nil
+}
+
+func TestRecordSeriesLimit(t *testing.T) {
+	a := NewAggregator(nil, time.Minute, 2)
+	m := &Metric{ID: uuid.New(), Type: TypeCounter}
+	at := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	for _, svc := range []string{"api", "web", "worker", "api"} {
+		a.Record(m, map[string]string{"service": svc}, 0, 1, at)
+	}
+	if a.dropped != 1 {
+		t.Errorf("dropped = %d, want 1", a.dropped)
+	}
+	// Two series at both resolutions.
+	if len(a.points) != 2*len(Resolutions) {
+		t.Errorf("got %d points, want %d", len(a.points), 2*len(Resolutions))
+	}
+
+	// The limit is per metric.
+	other := &Metric{ID: uuid.New(), Type: TypeCounter}
+	a.Record(other, map[string]string{"service": "worker"}, 0, 1, at)
+	if a.dropped != 1 {
+		t.Errorf("dropped = %d after another metric, want 1", a.dropped)
+	}
+}
+
+func TestRecordHistogram(t *testing.T) {
+	a := NewAggregator(nil, time.Minute, 10)
+	m := &Metric{ID: uuid.New(), Type: TypeHistogram, Buckets: []float64{10, 100}}
+	at := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	a.Record(m, nil, 5, 1, at)
+	a.Record(m, nil, 50, 4, at)
+	a.Record(m, nil, 500, 1, at)
+
+	p := a.points[pointKey{metricID: m.ID, resolution: 60, start: at.Truncate(time.Minute).Unix(), labelsKey: "null"}]
+	if p == nil {
+		t.Fatal("no minute point")
+	}
+	if p.count != 6 || p.sum != 5+200+500 || p.min != 5 || p.max != 500 {
+		t.Errorf("point = %+v", p)
+	}
+	want := []float64{1, 4, 1}
+	for i := range want {
+		if p.buckets[i] != want[i] {
+			t.Errorf("buckets = %v, want %v", p.buckets, want)
+			break
+		}
+	}
+}
+
+func TestFlushRequeuesFailedPoints(t *testing.T) {
+	db := &execDB{down: true}
+	a := NewAggregator(queries.New(db), time.Minute, 10)
+	m := &Metric{ID: uuid.New(), Type: TypeHistogram, Buckets: []float64{10}}
+	at := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	a.Record(m, nil, 5, 1, at)
+	a.Flush(context.Background())
+	if len(a.points) != len(Resolutions) {
+		t.Fatalf("got %d points after failed flush, want %d", len(a.points), len(Resolutions))
+	}
+
+	// A point recorded during the outage merges with the requeued one.
+	a.Record(m, nil, 20, 2, at)
+	a.Flush(context.Background())
+	key := pointKey{metricID: m.ID, resolution: 60, start: at.Truncate(time.Minute).Unix(), labelsKey: "null"}
+	p := a.points[key]
+	if p == nil {
+		t.Fatal("minute point lost")
+	}
+	if p.count != 3 || p.sum != 45 || p.min != 5 || p.max != 20 || p.buckets[0] != 1 || p.buckets[1] != 2 {
+		t.Errorf("merged point = %+v", p)
+	}
+
+	db.down = false
+	db.execs = 0
+	a.Flush(context.Background())
+	if db.execs != len(Resolutions) || len(a.points) != 0 {
+		t.Errorf("recovery flush: %d upserts, %d points left", db.execs, len(a.points))
+	}
+}
+
+func TestRequeueLimits(t *testing.T) {
+	a := NewAggregator(nil, time.Minute, 1)
+	id := uuid.New()
+	newPoint := func() *point { return &point{count: 1, min: math.Inf(1), max: math.Inf(-1)} }
+
+	// A new series recorded during the outage takes the only slot, so the
+	// failed point of another series is lost.
+	a.Record(&Metric{ID: id, Type: TypeCounter}, map[string]string{"service": "api"}, 0, 1, time.Now())
+	a.requeue(pointKey{metricID: id, resolution: 60, labelsKey: `{"service":"web"}`}, newPoint())
+	if a.lost != 1 {
+		t.Errorf("lost = %d over the series limit, want 1", a.lost)
+	}
+
+	a = NewAggregator(nil, time.Minute, maxRequeuedPoints+1)
+	for i := range maxRequeuedPoints + 1 {
+		a.requeue(pointKey{metricID: id, resolution: 60, start: int64(i)}, newPoint())
+	}
+	if len(a.points) != maxRequeuedPoints || a.lost != 1 {
+		t.Errorf("got %d points and %d lost, want %d and 1", len(a.points), a.lost, maxRequeuedPoints)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	bounds := []float64{10, 100}
+	tests := []struct {
+		name   string
+		counts []float64
+		q      float64
+		want   float64
+	}{
+		{"median in first bucket", []float64{10, 0, 0}, 0.5, 6},
+		{"median in middle bucket", []float64{0, 10, 0}, 0.5, 55},
+		{"skips empty buckets", []float64{5, 0, 5}, 0.75, 550},
+		{"open bucket capped at hi", []float64{0, 0, 4}, 1, 1000},
+		{"lower bound clamped to lo", []float64{4, 0, 0}, 0, 2},
+		{"empty histogram", []float64{0, 0, 0}, 0.5, 0},
+		{"mismatched buckets", []float64{1, 1}, 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Quantile(bounds, tt.counts, tt.q, 2, 1000)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Quantile = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
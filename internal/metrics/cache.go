@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Cache keeps every tenant's active metric definitions in memory,
+// reloading them every interval.
+type Cache struct {
+	queries  *queries.Queries
+	interval time.Duration
+
+	mu       sync.RWMutex
+	byTenant map[string][]*Metric
+	cancel   context.CancelFunc
+}
+
+func NewCache(q *queries.Queries, interval time.Duration) *Cache {
+	return &Cache{
+		queries:  q,
+		interval: interval,
+		byTenant: make(map[string][]*Metric),
+	}
+}
+
+// Start loads all definitions and keeps refreshing them until Stop is called.
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					slog.Error("metric cache: refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	slog.Info("metric cache started")
+	return nil
+}
+
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Metrics returns the tenant's active metrics.
+func (c *Cache) Metrics(tenantID string) []*Metric {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.byTenant[tenantID]
+}
+
+func (c *Cache) Refresh(ctx context.Context) error {
+	rows, err := c.queries.ListActiveMetrics(ctx)
+	if err != nil {
+		return fmt.Errorf("list metrics: %w", err)
+	}
+
+	byTenant := make(map[string][]*Metric)
+	for _, row := range rows {
+		m, err := fromRow(row)
+		if err != nil {
+			slog.Error("metric cache: invalid metric", "metric_id", row.ID, "error", err)
+			continue
+		}
+		tenantID := row.TenantID.String()
+		byTenant[tenantID] = append(byTenant[tenantID], m)
+	}
+
+	c.mu.Lock()
+	c.byTenant = byTenant
+	c.mu.Unlock()
+	return nil
+}
+
+func fromRow(row queries.Metric) (*Metric, error) {
+	m := &Metric{
+		ID:       row.ID,
+		TenantID: row.TenantID,
+		Name:     row.Name,
+		Type:     row.Type,
+		Field:    row.Field,
+		GroupBy:  row.GroupBy,
+		Buckets:  row.Buckets,
+	}
+	if err := json.Unmarshal(row.Match, &m.Match); err != nil {
+		return nil, fmt.Errorf("decode match: %w", err)
+	}
+	return m, nil
+}
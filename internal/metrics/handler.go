@@ -0,0 +1,354 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const (
+	maxGroupBy     = 5
+	maxBuckets     = 50
+	maxQueryPoints = 20_000
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]{0,127}$`)
+
+type Handler struct {
+	queries *queries.Queries
+}
+
+func NewHandler(q *queries.Queries) *Handler {
+	return &Handler{queries: q}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req MetricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := validate(&req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	m, err := h.queries.CreateMetric(r.Context(), info.ID, req.Name, req.Type, match, req.Field, req.GroupBy, req.Buckets)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to create metric"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toMetricResponse(m))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid metric ID"))
+		return
+	}
+
+	m, err := h.queries.GetMetric(r.Context(), id, info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("metric not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toMetricResponse(m))
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	list, err := h.queries.ListMetrics(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list metrics"))
+		return
+	}
+
+	resp := make([]MetricResponse, len(list))
+	for i, m := range list {
+		resp[i] = toMetricResponse(m)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid metric ID"))
+		return
+	}
+
+	var req MetricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := validate(&req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	m, err := h.queries.UpdateMetric(r.Context(), id, info.ID, req.Name, req.Type, match, req.Field, req.GroupBy, req.Buckets, isActive)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("metric not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toMetricResponse(m))
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid metric ID"))
+		return
+	}
+
+	if err := h.queries.DeleteMetric(r.Context(), id, info.ID); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete metric"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Query returns a metric's rollups as one series per label combination.
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if req.Metric == "" {
+		apierror.Write(w, apierror.BadRequest("metric is required"))
+		return
+	}
+	if req.To.IsZero() {
+		req.To = time.Now().UTC()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.Add(-time.Hour)
+	}
+	if !req.From.Before(req.To) {
+		apierror.Write(w, apierror.BadRequest("from must be before to"))
+		return
+	}
+
+	res := ResolutionFor(req.From, req.To)
+	if req.Resolution != "" {
+		var ok bool
+		if res, ok = ParseResolution(req.Resolution); !ok {
+			apierror.Write(w, apierror.BadRequest("resolution must be one of: 1m, 1h"))
+			return
+		}
+	}
+	if req.To.Sub(req.From)/res.Step > maxQueryPoints {
+		apierror.Write(w, apierror.BadRequest("range too large for resolution "+res.Name))
+		return
+	}
+
+	m, err := h.queries.GetMetricByName(r.Context(), info.ID, req.Metric)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apierror.Write(w, apierror.NotFound("metric not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to get metric"))
+		return
+	}
+
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	filter, err := json.Marshal(req.Labels)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid labels"))
+		return
+	}
+
+	points, err := h.queries.ListMetricPoints(r.Context(), m.ID, int32(res.Step/time.Second), req.From.Truncate(res.Step), req.To, filter)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to query metric"))
+		return
+	}
+
+	resp := QueryResponse{
+		Metric:     m.Name,
+		Type:       m.Type,
+		Resolution: res.Name,
+		Series:     []Series{},
+	}
+	if m.Type == TypeHistogram {
+		resp.Buckets = m.Buckets
+	}
+
+	// Points are ordered by labels_key, so each series is contiguous.
+	var cur *Series
+	lastKey := ""
+	for _, p := range points {
+		if cur == nil || p.LabelsKey != lastKey {
+			resp.Series = append(resp.Series, Series{Labels: map[string]string{}})
+			cur = &resp.Series[len(resp.Series)-1]
+			json.Unmarshal(p.Labels, &cur.Labels)
+			lastKey = p.LabelsKey
+		}
+		cur.Points = append(cur.Points, toPoint(m, p))
+	}
+	sort.SliceStable(resp.Series, func(i, j int) bool {
+		return seriesTotal(resp.Series[i]) > seriesTotal(resp.Series[j])
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func toPoint(m queries.Metric, p queries.MetricPoint) Point {
+	pt := Point{Timestamp: p.BucketStart, Count: p.Count}
+	if m.Type != TypeHistogram {
+		return pt
+	}
+	pt.Sum = p.Sum
+	pt.Min = p.Min
+	pt.Max = p.Max
+	if p.Count > 0 {
+		pt.Avg = p.Sum / p.Count
+	}
+	pt.BucketCounts = p.BucketCounts
+	pt.P50 = Quantile(m.Buckets, p.BucketCounts, 0.50, p.Min, p.Max)
+	pt.P95 = Quantile(m.Buckets, p.BucketCounts, 0.95, p.Min, p.Max)
+	pt.P99 = Quantile(m.Buckets, p.BucketCounts, 0.99, p.Min, p.Max)
+	return pt
+}
+
+func seriesTotal(s Series) float64 {
+	var total float64
+	for _, p := range s.Points {
+		total += p.Count
+	}
+	return total
+}
+
+// validate checks a metric request. It returns an error message, or "" if
+// the request is valid.
+func validate(req *MetricRequest) string {
+	if !validName.MatchString(req.Name) {
+		return "name must match " + validName.String()
+	}
+	if len(req.GroupBy) > maxGroupBy {
+		return "at most 5 group_by fields are allowed"
+	}
+	if req.GroupBy == nil {
+		req.GroupBy = []string{}
+	}
+
+	switch req.Type {
+	case TypeCounter:
+		req.Field = ""
+		req.Buckets = []float64{}
+	case TypeHistogram:
+		if req.Field == "" {
+			return "field is required for histograms"
+		}
+		if len(req.Buckets) == 0 || len(req.Buckets) > maxBuckets {
+			return "histograms need between 1 and 50 buckets"
+		}
+		if !sort.Float64sAreSorted(req.Buckets) {
+			return "buckets must be in ascending order"
+		}
+		for i := 1; i < len(req.Buckets); i++ {
+			if req.Buckets[i] == req.Buckets[i-1] {
+				return "buckets must be distinct"
+			}
+		}
+	default:
+		return "type must be one of: counter, histogram"
+	}
+	return ""
+}
+
+func toMetricResponse(m queries.Metric) MetricResponse {
+	resp := MetricResponse{
+		ID:        m.ID,
+		Name:      m.Name,
+		Type:      m.Type,
+		Field:     m.Field,
+		GroupBy:   m.GroupBy,
+		Buckets:   m.Buckets,
+		IsActive:  m.IsActive,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	json.Unmarshal(m.Match, &resp.Match)
+	return resp
+}
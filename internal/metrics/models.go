@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+)
+
+// Metric types.
+const (
+	TypeCounter   = "counter"   // events matching the rule
+	TypeHistogram = "histogram" // distribution of a numeric field
+)
+
+// Metric is an active metric definition as applied by the pipeline.
+type Metric struct {
+	ID       uuid.UUID
+	TenantID uuid.UUID
+	Name     string
+	Type     string
+	Match    rules.Match
+	Field    string
+	GroupBy  []string
+	Buckets  []float64
+}
+
+type MetricRequest struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"` // "counter" or "histogram"
+	Match    rules.Match `json:"match"`
+	Field    string      `json:"field,omitempty"`    // histogram: numeric field, e.g. "fields.duration_ms"
+	GroupBy  []string    `json:"group_by,omitempty"` // field references used as labels, e.g. "service"
+	Buckets  []float64   `json:"buckets,omitempty"`  // histogram: ascending upper bounds
+	IsActive *bool       `json:"is_active,omitempty"`
+}
+
+type MetricResponse struct {
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Match     rules.Match `json:"match"`
+	Field     string      `json:"field,omitempty"`
+	GroupBy   []string    `json:"group_by"`
+	Buckets   []float64   `json:"buckets,omitempty"`
+	IsActive  bool        `json:"is_active"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type QueryRequest struct {
+	Metric     string            `json:"metric"`
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to,omitempty"`         // defaults to now
+	Resolution string            `json:"resolution,omitempty"` // "1m" or "1h"; chosen from the range if empty
+	Labels     map[string]string `json:"labels,omitempty"`     // only series with these label values
+}
+
+type QueryResponse struct {
+	Metric     string    `json:"metric"`
+	Type       string    `json:"type"`
+	Resolution string    `json:"resolution"`
+	Buckets    []float64 `json:"buckets,omitempty"`
+	Series     []Series  `json:"series"`
+}
+
+type Series struct {
+	Labels map[string]string `json:"labels"`
+	Points []Point           `json:"points"`
+}
+
+// Point is one rollup interval. Histogram points carry the value statistics
+// and estimated percentiles; counters only Count.
+type Point struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Count        float64   `json:"count"`
+	Sum          float64   `json:"sum,omitempty"`
+	Min          float64   `json:"min,omitempty"`
+	Max          float64   `json:"max,omitempty"`
+	Avg          float64   `json:"avg,omitempty"`
+	P50          float64   `json:"p50,omitempty"`
+	P95          float64   `json:"p95,omitempty"`
+	P99          float64   `json:"p99,omitempty"`
+	BucketCounts []float64 `json:"bucket_counts,omitempty"`
+}
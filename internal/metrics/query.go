@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Statistics accepted by Evaluate.
+const (
+	StatCount = "count"
+	StatSum   = "sum"
+	StatAvg   = "avg"
+	StatMin   = "min"
+	StatMax   = "max"
+)
+
+// ValidStat reports whether Evaluate accepts stat; empty means count.
+func ValidStat(stat string) bool {
+	switch stat {
+	case "", StatCount, StatSum, StatAvg, StatMin, StatMax:
+		return true
+	}
+	return false
+}
+
+// ResolutionFor picks the resolution for a query range: minutes for ranges
+// up to a day that are still within minute retention, hours otherwise.
+func ResolutionFor(from, to time.Time) Resolution {
+	fine := Resolutions[0]
+	if to.Sub(from) <= 24*time.Hour && time.Since(from) < fine.Retention {
+		return fine
+	}
+	return Resolutions[1]
+}
+
+// ParseResolution returns the resolution named name.
+func ParseResolution(name string) (Resolution, bool) {
+	for _, r := range Resolutions {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Resolution{}, false
+}
+
+// Quantile estimates the q-quantile (0..1) of a histogram by linear
+// interpolation within the bucket that contains it. The open-ended last
+// bucket is capped at hi and the first starts at lo.
+func Quantile(bounds, counts []float64, q, lo, hi float64) float64 {
+	var total float64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 || len(counts) != len(bounds)+1 {
+		return 0
+	}
+
+	rank := q * total
+	var cum float64
+	for i, c := range counts {
+		if c == 0 || cum+c < rank {
+			cum += c
+			continue
+		}
+		lower := lo
+		if i > 0 {
+			lower = bounds[i-1]
+		}
+		upper := hi
+		if i < len(bounds) {
+			upper = bounds[i]
+		}
+		if lower < lo {
+			lower = lo
+		}
+		if upper > hi {
+			upper = hi
+		}
+		return lower + (upper-lower)*(rank-cum)/c
+	}
+	return hi
+}
+
+// Evaluate computes a single statistic for a metric from the minute rollups
+// lying wholly within [from, to), restricted to series matching labels.
+// Unaligned bounds are rounded inward to whole minutes, so a partial minute
+// at either end is left out rather than counted in full.
+func Evaluate(ctx context.Context, q *queries.Queries, tenantID uuid.UUID, name, stat string, labels map[string]string, from, to time.Time) (float64, error) {
+	m, err := q.GetMetricByName(ctx, tenantID, name)
+	if err != nil {
+		return 0, fmt.Errorf("metric %q: %w", name, err)
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	filter, err := json.Marshal(labels)
+	if err != nil {
+		return 0, err
+	}
+
+	res := Resolutions[0]
+	start := from.Truncate(res.Step)
+	if start.Before(from) {
+		start = start.Add(res.Step)
+	}
+	s, err := q.SummarizeMetricPoints(ctx, m.ID, int32(res.Step/time.Second), start, to.Truncate(res.Step), filter)
+	if err != nil {
+		return 0, err
+	}
+
+	switch stat {
+	case "", StatCount:
+		return s.Count, nil
+	case StatSum:
+		return s.Sum, nil
+	case StatAvg:
+		if s.Count == 0 {
+			return 0, nil
+		}
+		return s.Sum / s.Count, nil
+	case StatMin:
+		return s.Min, nil
+	case StatMax:
+		return s.Max, nil
+	}
+	return 0, fmt.Errorf("unknown stat %q", stat)
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import "fmt"
+
+// Processor types accepted in pipeline definitions.
+const (
+	TypeParseJSON    = "parse_json"
+	TypeNormalize    = "normalize"
+	TypeTraceContext = "tracecontext"
+	TypeUserAgent    = "useragent"
+	TypeLookup       = "lookup"
+	TypeMetrics      = "metrics"
+	TypeRules        = "rules"
+	TypeFlatten      = "flatten"
+	TypePatterns     = "patterns"
+)
+
+// Spec describes one processor in a pipeline definition.
+type Spec struct {
+	Type  string `json:"type"`
+	Field string `json:"field,omitempty"` // useragent: source field
+}
+
+// Definition is an ordered list of processor specs.
+type Definition []Spec
+
+// Build constructs a pipeline from a definition, drawing shared state from deps.
+func Build(def Definition, deps Deps) (*Pipeline, error) {
+	procs := make([]Processor, 0, len(def))
+	for i, spec := range def {
+		switch spec.Type {
+		case TypeParseJSON:
+			procs = append(procs, ProcessorFunc(TypeParseJSON, ParseJSON))
+		case TypeNormalize:
+			procs = append(procs, ProcessorFunc(TypeNormalize, Normalize))
+		case TypeTraceContext:
+			procs = append(procs, ProcessorFunc(TypeTraceContext, ExtractTraceContext))
+		case TypeUserAgent:
+			if deps.UserAgent == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			ua := deps.UserAgent
+			if spec.Field != "" {
+				ua = ua.WithField(spec.Field)
+			}
+			procs = append(procs, ua)
+		case TypeLookup:
+			if deps.Lookups == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			procs = append(procs, NewLookupJoiner(deps.Lookups))
+		case TypeMetrics:
+			if deps.Metrics == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			procs = append(procs, deps.Metrics)
+		case TypeRules:
+			if deps.Rules == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			procs = append(procs, NewRuleFilter(deps.Rules))
+		case TypeFlatten:
+			if deps.Fields == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			procs = append(procs, deps.Fields)
+		case TypePatterns:
+			if deps.Patterns == nil {
+				return nil, fmt.Errorf("processor %d: %s is not available", i, spec.Type)
+			}
+			procs = append(procs, deps.Patterns)
+		default:
+			return nil, fmt.Errorf("processor %d: unknown type %q", i, spec.Type)
+		}
+	}
+	return New(procs...), nil
+}
@@ -0,0 +1,221 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Wildcard marks a template position whose value varies between events.
+const Wildcard = "<*>"
+
+// Maskers replace well-known variable tokens before clustering, so that
+// e.g. request IDs never produce distinct templates.
+var maskers = []struct {
+	re   *regexp.Regexp
+	mask string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{16,}\b`), "<hex>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?\b`), "<num>"},
+}
+
+// Tokenize masks variable tokens in a message and splits it on whitespace.
+func Tokenize(message string) []string {
+	for _, m := range maskers {
+		message = m.re.ReplaceAllString(message, m.mask)
+	}
+	return strings.Fields(message)
+}
+
+// Cluster is a group of messages sharing a template. Route holds the first
+// tokens of the message that created it, which place it in the parse tree;
+// they may differ from the template's once it generalizes.
+type Cluster struct {
+	ID        string
+	Tokens    []string
+	Route     []string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+type drainNode struct {
+	children map[string]*drainNode
+	clusters []*Cluster
+}
+
+func newDrainNode() *drainNode {
+	return &drainNode{children: make(map[string]*drainNode)}
+}
+
+// Drain mines templates online with a fixed-depth parse tree (He et al.,
+// "Drain: An Online Log Parsing Approach with Fixed Depth Tree", ICWS 2017).
+// Messages are routed by token count and their first tokens, then matched
+// against the clusters in the leaf by positional similarity. Drain is not
+// safe for concurrent use.
+type Drain struct {
+	depth       int
+	similarity  float64
+	maxChildren int
+	maxClusters int
+
+	byLength map[int]*drainNode
+	clusters map[string]*Cluster
+}
+
+func NewDrain(depth int, similarity float64, maxChildren, maxClusters int) *Drain {
+	return &Drain{
+		depth:       depth,
+		similarity:  similarity,
+		maxChildren: maxChildren,
+		maxClusters: maxClusters,
+		byLength:    make(map[int]*drainNode),
+		clusters:    make(map[string]*Cluster),
+	}
+}
+
+// Len returns the number of clusters.
+func (d *Drain) Len() int {
+	return len(d.clusters)
+}
+
+// Add assigns tokens to the most similar cluster, generalizing its template,
+// or creates a new cluster whose ID is derived by newID from its initial
+// template. A cluster keeps its ID as its template generalizes. Add returns
+// nil if no cluster matched and the cluster limit has been reached.
+func (d *Drain) Add(tokens []string, at time.Time, newID func(template string) string) *Cluster {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	leaf := d.leaf(tokens)
+	if c := d.match(leaf, tokens); c != nil {
+		for i, tok := range tokens {
+			if c.Tokens[i] != tok {
+				c.Tokens[i] = Wildcard
+			}
+		}
+		c.Count++
+		c.LastSeen = at
+		return c
+	}
+
+	if len(d.clusters) >= d.maxClusters {
+		return nil
+	}
+
+	c := &Cluster{
+		Tokens:    append([]string(nil), tokens...),
+		Route:     append([]string(nil), tokens[:min(d.depth, len(tokens))]...),
+		Count:     1,
+		FirstSeen: at,
+		LastSeen:  at,
+	}
+	c.ID = newID(c.Template())
+	if existing, ok := d.clusters[c.ID]; ok {
+		existing.Count++
+		existing.LastSeen = at
+		return existing
+	}
+	leaf.clusters = append(leaf.clusters, c)
+	d.clusters[c.ID] = c
+	return c
+}
+
+// Insert restores a previously mined cluster into the leaf its Route leads
+// to, or its template's if it has none. A cluster whose ID is already known
+// is not added again; the known template takes on the wildcards of the
+// restored one instead, so replicas converge on the most general template.
+func (d *Drain) Insert(c *Cluster) {
+	if len(c.Tokens) == 0 {
+		return
+	}
+	if known, ok := d.clusters[c.ID]; ok {
+		if len(known.Tokens) == len(c.Tokens) {
+			for i, tok := range c.Tokens {
+				if tok == Wildcard {
+					known.Tokens[i] = Wildcard
+				}
+			}
+		}
+		return
+	}
+
+	route := c.Route
+	if len(route) == 0 {
+		route = c.Tokens
+	}
+	tokens := make([]string, len(c.Tokens))
+	copy(tokens, route)
+	leaf := d.leaf(tokens)
+	leaf.clusters = append(leaf.clusters, c)
+	d.clusters[c.ID] = c
+}
+
+// leaf walks (and grows) the tree to the leaf for tokens.
+func (d *Drain) leaf(tokens []string) *drainNode {
+	node, ok := d.byLength[len(tokens)]
+	if !ok {
+		node = newDrainNode()
+		d.byLength[len(tokens)] = node
+	}
+
+	for i := 0; i < d.depth && i < len(tokens); i++ {
+		key := tokens[i]
+		if hasDigit(key) {
+			key = Wildcard
+		}
+
+		child, ok := node.children[key]
+		if !ok {
+			// Keep one slot for the wildcard branch that absorbs overflow.
+			if key == Wildcard || len(node.children) < d.maxChildren-1 {
+				child = newDrainNode()
+				node.children[key] = child
+			} else if child, ok = node.children[Wildcard]; !ok {
+				child = newDrainNode()
+				node.children[Wildcard] = child
+			}
+		}
+		node = child
+	}
+	return node
+}
+
+func (d *Drain) match(leaf *drainNode, tokens []string) *Cluster {
+	var (
+		best      *Cluster
+		bestScore = -1.0
+		bestWild  = -1
+	)
+	for _, c := range leaf.clusters {
+		same, wild := 0, 0
+		for i, tok := range c.Tokens {
+			switch {
+			case tok == Wildcard:
+				wild++
+			case tok == tokens[i]:
+				same++
+			}
+		}
+		score := float64(same) / float64(len(tokens))
+		if score > bestScore || (score == bestScore && wild > bestWild) {
+			best, bestScore, bestWild = c, score, wild
+		}
+	}
+	if best == nil || bestScore < d.similarity {
+		return nil
+	}
+	return best
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainKeepsIDAndRouteAcrossReload(t *testing.T) {
+	newID := func(template string) string { return PatternID("t1", template) }
+	at := time.Now()
+
+	// With two children per node, alice takes the only named slot and bob
+	// and carol overflow into the wildcard branch, where they share a
+	// cluster whose first token generalizes.
+	d := NewDrain(drainDepth, drainSimilarity, 2, 100)
+	d.Add(Tokenize("alice logged in from web"), at, newID)
+	first := d.Add(Tokenize("bob logged in from web"), at, newID)
+	second := d.Add(Tokenize("carol logged in from web"), at, newID)
+	if second != first {
+		t.Fatal("messages did not join one cluster")
+	}
+	if first.ID != PatternID("t1", "bob logged in from web") {
+		t.Errorf("ID changed as the template generalized: %s", first.ID)
+	}
+	if got := first.Template(); got != "<*> logged in from web" {
+		t.Errorf("template = %q", got)
+	}
+
+	// After a restart, the cluster is restored where its creating message
+	// was routed rather than by its template, so messages like it still
+	// join it.
+	restored := NewDrain(drainDepth, drainSimilarity, 2, 100)
+	restored.Insert(&Cluster{ID: first.ID, Tokens: append([]string(nil), first.Tokens...), Route: first.Route})
+	if c := restored.Add(Tokenize("bob logged in from web"), at, newID); c == nil || c.ID != first.ID {
+		t.Errorf("restored cluster not matched: %+v", c)
+	}
+	if restored.Len() != 1 {
+		t.Errorf("got %d clusters, want 1", restored.Len())
+	}
+}
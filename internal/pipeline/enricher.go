@@ -1,8 +0,0 @@
-package pipeline
-
-import "github.com/felipemonteiro/mintlog/pkg/logmodel"
-
-// Enrich is a stub for future enrichment (GeoIP, User-Agent parsing, etc.).
-func Enrich(event *logmodel.LogEvent) {
-	// Placeholder: add enrichment logic here in the future.
-}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// GetField resolves a field reference against an event. Top-level keys
+// ("level", "service", "host", ...) map to LogEvent attributes; anything under
+// "fields." walks the Fields map, descending into nested objects.
+func GetField(event *logmodel.LogEvent, path string) (any, bool) {
+	switch path {
+	case "id":
+		return event.ID, event.ID != ""
+	case "tenant_id":
+		return event.TenantID, event.TenantID != ""
+	case "level":
+		return event.Level, event.Level != ""
+	case "message":
+		return event.Message, event.Message != ""
+	case "service":
+		return event.Service, event.Service != ""
+	case "host":
+		return event.Host, event.Host != ""
+	case "trace_id":
+		return event.TraceID, event.TraceID != ""
+	case "span_id":
+		return event.SpanID, event.SpanID != ""
+	}
+
+	rest, ok := strings.CutPrefix(path, "fields.")
+	if !ok || event.Fields == nil {
+		return nil, false
+	}
+
+	// Prefer an exact (possibly dotted) key before walking nested objects.
+	if v, ok := event.Fields[rest]; ok {
+		return v, true
+	}
+
+	var cur any = event.Fields
+	for _, part := range strings.Split(rest, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// GetString is GetField restricted to string values.
+func GetString(event *logmodel.LogEvent, path string) (string, bool) {
+	v, ok := GetField(event, path)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok && s != ""
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/fields"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Fields keys holding values kept out of the index mapping. Both are mapped
+// with "enabled": false, so they are stored but never indexed.
+const (
+	ConflictsKey = "_conflicts"
+	OverflowKey  = "_overflow"
+)
+
+const fieldResolveTimeout = 5 * time.Second
+
+// FieldTypes resolves a tenant's field types, registering new fields;
+// *fields.Registry implements it.
+type FieldTypes interface {
+	Resolve(ctx context.Context, tenantID, name, observed string) (string, error)
+}
+
+// FieldGuard keeps Fields mappable: nested objects are flattened to dotted
+// keys, values are coerced to the tenant's registered field types, and
+// values that cannot be coerced or would exceed the tenant's field cap are
+// moved to fields._conflicts and fields._overflow. When a catalog is set,
+// every field within the cap is recorded in it with its type as received.
+type FieldGuard struct {
+	registry FieldTypes
+	catalog  *fields.Catalog
+	maxDepth int
+}
+
+// NewFieldGuard flattens objects up to maxDepth levels; deeper objects and
+// arrays of objects are stored as JSON strings. catalog may be nil.
+func NewFieldGuard(registry FieldTypes, catalog *fields.Catalog, maxDepth int) *FieldGuard {
+	return &FieldGuard{registry: registry, catalog: catalog, maxDepth: maxDepth}
+}
+
+func (g *FieldGuard) Name() string { return TypeFlatten }
+
+func (g *FieldGuard) Process(event *logmodel.LogEvent) error {
+	if len(event.Fields) == 0 {
+		return nil
+	}
+
+	flat := make(map[string]any, len(event.Fields))
+	conflicts := map[string]any{}
+	overflow := map[string]any{}
+	for k, v := range event.Fields {
+		switch k {
+		case ConflictsKey:
+			mergeObject(conflicts, v)
+		case OverflowKey:
+			mergeObject(overflow, v)
+		default:
+			g.flatten(flat, k, v, 1)
+		}
+	}
+
+	// Sorted so that which fields fall over the cap is deterministic.
+	names := make([]string, 0, len(flat))
+	for k := range flat {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fieldResolveTimeout)
+	defer cancel()
+
+	out := make(map[string]any, len(flat)+2)
+	for _, name := range names {
+		v := flat[name]
+		observed, ok := fields.TypeOf(v)
+		if !ok {
+			if arr, isArr := v.([]any); isArr && len(arr) == 0 {
+				out[name] = v
+			} else {
+				conflicts[name] = v
+			}
+			continue
+		}
+
+		t, err := g.registry.Resolve(ctx, event.TenantID, name, observed)
+		switch {
+		case errors.Is(err, fields.ErrTooManyFields):
+			overflow[name] = v
+			continue
+		case errors.Is(err, fields.ErrPathConflict):
+			if g.catalog != nil {
+				g.catalog.Observe(event.TenantID, name, observed, v, event.Timestamp)
+			}
+			conflicts[name] = v
+			continue
+		case err != nil:
+			return err
+		}
+		if g.catalog != nil {
+			g.catalog.Observe(event.TenantID, name, observed, v, event.Timestamp)
+		}
+
+		if cv, ok := fields.Coerce(v, t); ok {
+			out[name] = cv
+		} else {
+			conflicts[name] = v
+		}
+	}
+
+	if len(conflicts) > 0 {
+		out[ConflictsKey] = conflicts
+	}
+	if len(overflow) > 0 {
+		out[OverflowKey] = overflow
+	}
+	event.Fields = out
+	return nil
+}
+
+func (g *FieldGuard) flatten(out map[string]any, key string, v any, depth int) {
+	switch v := v.(type) {
+	case nil:
+		return
+	case map[string]any:
+		if depth >= g.maxDepth {
+			out[key] = encodeJSON(v)
+			return
+		}
+		for k, child := range v {
+			g.flatten(out, key+"."+k, child, depth+1)
+		}
+	case []any:
+		for _, e := range v {
+			switch e.(type) {
+			case map[string]any, []any:
+				out[key] = encodeJSON(v)
+				return
+			}
+		}
+		out[key] = v
+	default:
+		out[key] = v
+	}
+}
+
+func mergeObject(dst map[string]any, v any) {
+	if m, ok := v.(map[string]any); ok {
+		for k, e := range m {
+			dst[k] = e
+		}
+	}
+}
+
+func encodeJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
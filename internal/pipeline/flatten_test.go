@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/felipemonteiro/mintlog/internal/fields"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// typeTable resolves fields like the registry: registered names keep their
+// type, new names are registered as observed until max is reached, and a
+// name that is both a leaf and a prefix of another is refused.
+type typeTable struct {
+	types map[string]string
+	max   int
+}
+
+func (tt *typeTable) Resolve(_ context.Context, _, name, observed string) (string, error) {
+	if t, ok := tt.types[name]; ok {
+		return t, nil
+	}
+	for other := range tt.types {
+		if strings.HasPrefix(other, name+".") || strings.HasPrefix(name, other+".") {
+			return "", fields.ErrPathConflict
+		}
+	}
+	if len(tt.types) >= tt.max {
+		return "", fields.ErrTooManyFields
+	}
+	tt.types[name] = observed
+	return observed, nil
+}
+
+func TestFieldGuard(t *testing.T) {
+	tests := []struct {
+		name   string
+		types  map[string]string
+		max    int
+		fields map[string]any
+		want   map[string]any
+	}{
+		{
+			name:   "flatten nested objects",
+			fields: map[string]any{"http": map[string]any{"status": 200.0, "req": map[string]any{"method": "GET"}}},
+			want:   map[string]any{"http.status": 200.0, "http.req.method": "GET"},
+		},
+		{
+			name:   "objects deeper than the limit are JSON",
+			fields: map[string]any{"a": map[string]any{"b": map[string]any{"c": map[string]any{"d": 1.0}}}},
+			want:   map[string]any{"a.b.c": `{"d":1}`},
+		},
+		{
+			name:   "arrays of objects are JSON",
+			fields: map[string]any{"items": []any{map[string]any{"id": 1.0}}},
+			want:   map[string]any{"items": `[{"id":1}]`},
+		},
+		{
+			name:   "nulls are dropped",
+			fields: map[string]any{"a": nil, "b": "x"},
+			want:   map[string]any{"b": "x"},
+		},
+		{
+			name:   "coerce to registered types",
+			types:  map[string]string{"status": fields.TypeNumber, "code": fields.TypeString, "ok": fields.TypeBoolean},
+			fields: map[string]any{"status": "503", "code": 42.0, "ok": "true"},
+			want:   map[string]any{"status": 503.0, "code": "42", "ok": true},
+		},
+		{
+			name:   "values that do not coerce go to _conflicts",
+			types:  map[string]string{"status": fields.TypeNumber},
+			fields: map[string]any{"status": "unavailable"},
+			want:   map[string]any{ConflictsKey: map[string]any{"status": "unavailable"}},
+		},
+		{
+			name:   "mixed arrays go to _conflicts",
+			fields: map[string]any{"ids": []any{1.0, "two"}},
+			want:   map[string]any{ConflictsKey: map[string]any{"ids": []any{1.0, "two"}}},
+		},
+		{
+			name:   "empty arrays are kept",
+			fields: map[string]any{"ids": []any{}},
+			want:   map[string]any{"ids": []any{}},
+		},
+		{
+			name:   "path conflicts go to _conflicts",
+			types:  map[string]string{"user": fields.TypeString},
+			fields: map[string]any{"user": map[string]any{"id": "u1"}},
+			want:   map[string]any{ConflictsKey: map[string]any{"user.id": "u1"}},
+		},
+		{
+			name:   "fields over the cap go to _overflow in name order",
+			types:  map[string]string{"a": fields.TypeString},
+			max:    2,
+			fields: map[string]any{"c": "3", "b": "2", "a": "1"},
+			want:   map[string]any{"a": "1", "b": "2", OverflowKey: map[string]any{"c": "3"}},
+		},
+		{
+			name:   "existing _conflicts and _overflow are merged",
+			types:  map[string]string{"n": fields.TypeNumber},
+			max:    1,
+			fields: map[string]any{"n": "x", "m": "y", ConflictsKey: map[string]any{"old": 1.0}, OverflowKey: map[string]any{"older": 2.0}},
+			want: map[string]any{
+				ConflictsKey: map[string]any{"old": 1.0, "n": "x"},
+				OverflowKey:  map[string]any{"older": 2.0, "m": "y"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types := map[string]string{}
+			for k, v := range tt.types {
+				types[k] = v
+			}
+			max := tt.max
+			if max == 0 {
+				max = 100
+			}
+			g := NewFieldGuard(&typeTable{types: types, max: max}, nil, 3)
+			e := &logmodel.LogEvent{TenantID: "t1", Fields: tt.fields}
+			if err := g.Process(e); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(e.Fields, tt.want) {
+				t.Errorf("got %#v, want %#v", e.Fields, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const maxSimulateEvents = 100
+
+type Handler struct {
+	deps Deps
+}
+
+func NewHandler(deps Deps) *Handler {
+	return &Handler{deps: deps}
+}
+
+// Simulate runs sample events through a pipeline without publishing or
+// indexing them, returning the resulting events and a per-processor trace.
+func (h *Handler) Simulate(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if len(req.Events) == 0 {
+		apierror.Write(w, apierror.BadRequest("events array is empty"))
+		return
+	}
+	if len(req.Events) > maxSimulateEvents {
+		apierror.Write(w, apierror.BadRequest(fmt.Sprintf("at most %d events can be simulated", maxSimulateEvents)))
+		return
+	}
+
+	p := Standard(h.deps)
+	if len(req.Pipeline) > 0 {
+		var err error
+		p, err = Build(req.Pipeline, h.deps)
+		if err != nil {
+			apierror.Write(w, apierror.BadRequest("invalid pipeline: "+err.Error()))
+			return
+		}
+	}
+
+	resp := SimulateResponse{Results: make([]SimulateResult, len(req.Events))}
+	for i := range req.Events {
+		event := req.Events[i]
+		event.TenantID = info.ID.String()
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+
+		trace, err := p.Trace(&event)
+		resp.Results[i] = SimulateResult{Event: event, Trace: trace}
+		switch {
+		case errors.Is(err, ErrDrop):
+			resp.Results[i].Dropped = true
+		case err != nil:
+			resp.Results[i].Error = err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
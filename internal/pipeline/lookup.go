@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"github.com/felipemonteiro/mintlog/internal/lookup"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// LookupTables returns a tenant's lookup tables; *lookup.Cache implements
+// it.
+type LookupTables interface {
+	Tables(tenantID string) []*lookup.Table
+}
+
+// LookupJoiner joins events against the tenant's lookup tables. Each matching
+// row is copied into Fields under the table name. An event field of that
+// name is kept, and the row goes to fields._conflicts instead.
+type LookupJoiner struct {
+	tables LookupTables
+}
+
+func NewLookupJoiner(tables LookupTables) *LookupJoiner {
+	return &LookupJoiner{tables: tables}
+}
+
+func (j *LookupJoiner) Name() string { return TypeLookup }
+
+func (j *LookupJoiner) Process(event *logmodel.LogEvent) error {
+	for _, t := range j.tables.Tables(event.TenantID) {
+		v, ok := GetField(event, t.MatchField)
+		if !ok {
+			continue
+		}
+		row, ok := t.Lookup(lookup.KeyString(v))
+		if !ok {
+			continue
+		}
+
+		joined := make(map[string]any, len(row))
+		for k, v := range row {
+			joined[k] = v
+		}
+		if event.Fields == nil {
+			event.Fields = make(map[string]any)
+		}
+		if _, taken := event.Fields[t.Name]; !taken {
+			event.Fields[t.Name] = joined
+			continue
+		}
+		conflicts, ok := event.Fields[ConflictsKey].(map[string]any)
+		if !ok {
+			conflicts = make(map[string]any)
+			event.Fields[ConflictsKey] = conflicts
+		}
+		conflicts[t.Name] = joined
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/felipemonteiro/mintlog/internal/lookup"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+type staticTables []*lookup.Table
+
+func (s staticTables) Tables(string) []*lookup.Table { return s }
+
+func TestLookupJoinerKeepsEventFields(t *testing.T) {
+	owners := &lookup.Table{
+		Name:       "owners",
+		MatchField: "host",
+		Rows:       lookup.Rows{"web-1": {"team": "storefront"}},
+	}
+	j := NewLookupJoiner(staticTables{owners})
+
+	t.Run("no collision", func(t *testing.T) {
+		e := &logmodel.LogEvent{TenantID: "t1", Host: "web-1"}
+		if err := j.Process(e); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{"owners": map[string]any{"team": "storefront"}}
+		if !reflect.DeepEqual(e.Fields, want) {
+			t.Errorf("got %v, want %v", e.Fields, want)
+		}
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		e := &logmodel.LogEvent{
+			TenantID: "t1",
+			Host:     "web-1",
+			Fields: map[string]any{
+				"owners":     "alice",
+				ConflictsKey: map[string]any{"status": "oops"},
+			},
+		}
+		if err := j.Process(e); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{
+			"owners": "alice",
+			ConflictsKey: map[string]any{
+				"status": "oops",
+				"owners": map[string]any{"team": "storefront"},
+			},
+		}
+		if !reflect.DeepEqual(e.Fields, want) {
+			t.Errorf("got %v, want %v", e.Fields, want)
+		}
+	})
+}
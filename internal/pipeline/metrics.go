@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/felipemonteiro/mintlog/internal/metrics"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// MetricRecorder feeds events into the tenant's log-derived metrics. It
+// never changes the event. It runs before drop and sampling rules, so
+// metrics count every event received.
+type MetricRecorder struct {
+	cache      *metrics.Cache
+	aggregator *metrics.Aggregator
+}
+
+func NewMetricRecorder(cache *metrics.Cache, aggregator *metrics.Aggregator) *MetricRecorder {
+	return &MetricRecorder{cache: cache, aggregator: aggregator}
+}
+
+func (r *MetricRecorder) Name() string { return TypeMetrics }
+
+func (r *MetricRecorder) Process(event *logmodel.LogEvent) error {
+	defs := r.cache.Metrics(event.TenantID)
+	if len(defs) == 0 {
+		return nil
+	}
+
+	weight := 1.0
+	if event.SampleRate > 0 {
+		weight = 1 / event.SampleRate
+	}
+
+	for _, m := range defs {
+		if !Matches(&m.Match, event) {
+			continue
+		}
+
+		var value float64
+		if m.Type == metrics.TypeHistogram {
+			v, ok := GetField(event, m.Field)
+			if !ok {
+				continue
+			}
+			if value, ok = toFloat(v); !ok {
+				continue
+			}
+		}
+
+		labels := make(map[string]string, len(m.GroupBy))
+		for _, ref := range m.GroupBy {
+			if v, ok := GetField(event, ref); ok {
+				labels[ref] = fmt.Sprint(v)
+			} else {
+				labels[ref] = ""
+			}
+		}
+
+		r.aggregator.Record(m, labels, value, weight, event.Timestamp)
+	}
+	return nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,19 @@
+package pipeline
+
+import "github.com/felipemonteiro/mintlog/pkg/logmodel"
+
+type SimulateRequest struct {
+	Events   []logmodel.LogEvent `json:"events"`
+	Pipeline Definition          `json:"pipeline,omitempty"` // defaults to the pipelined chain
+}
+
+type SimulateResult struct {
+	Event   logmodel.LogEvent `json:"event"`
+	Trace   []Step            `json:"trace"`
+	Dropped bool              `json:"dropped,omitempty"` // discarded by a drop or sampling rule
+	Error   string            `json:"error,omitempty"`
+}
+
+type SimulateResponse struct {
+	Results []SimulateResult `json:"results"`
+}
@@ -0,0 +1,325 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Drain tuning: messages are routed by their length and first drainDepth
+// tokens, and join a cluster when at least half their tokens match.
+const (
+	drainDepth       = 3
+	drainSimilarity  = 0.5
+	drainMaxChildren = 100
+
+	patternLoadTimeout = 5 * time.Second
+	patternLoadRetry   = 30 * time.Second
+	// Patterns other replicas persisted are picked up this often.
+	patternReload = 5 * time.Minute
+)
+
+// PatternMiner assigns every event a pattern_id and template mined per
+// tenant with Drain. Pattern state is loaded from Postgres on first use and
+// reloaded periodically, and match counts are flushed back, so IDs survive
+// restarts and replicas converge on the same patterns.
+type PatternMiner struct {
+	queries     *queries.Queries
+	maxPatterns int
+	interval    time.Duration
+	readOnly    bool
+
+	mu      sync.Mutex // guards tenants; each tenant has its own lock
+	tenants map[string]*tenantPatterns
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+type tenantPatterns struct {
+	mu       sync.Mutex
+	drain    *Drain
+	created  time.Time
+	loadedAt time.Time // zero until loaded
+	retryAt  time.Time
+	dirty    map[string]*patternDelta
+}
+
+type patternDelta struct {
+	count     int64
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// NewPatternMiner returns a miner keeping at most maxPatterns patterns per
+// tenant and flushing counts every interval once started.
+func NewPatternMiner(q *queries.Queries, maxPatterns int, interval time.Duration) *PatternMiner {
+	return &PatternMiner{
+		queries:     q,
+		maxPatterns: maxPatterns,
+		interval:    interval,
+		tenants:     make(map[string]*tenantPatterns),
+	}
+}
+
+// NewPatternPreview returns a miner that reads persisted patterns but never
+// writes them back. Its in-memory state is discarded every interval so
+// previews track the patterns mined by pipelined.
+func NewPatternPreview(q *queries.Queries, maxPatterns int, interval time.Duration) *PatternMiner {
+	m := NewPatternMiner(q, maxPatterns, interval)
+	m.readOnly = true
+	return m
+}
+
+func (m *PatternMiner) Name() string { return TypePatterns }
+
+// Process tokenizes the message and records the matching pattern on the event.
+func (m *PatternMiner) Process(event *logmodel.LogEvent) error {
+	if event.Message == "" || event.TenantID == "" {
+		return nil
+	}
+	tokens := Tokenize(event.Message)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	state := m.tenant(event.TenantID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	m.load(event.TenantID, state)
+
+	c := state.drain.Add(tokens, at, func(template string) string {
+		return PatternID(event.TenantID, template)
+	})
+	if c == nil {
+		return nil
+	}
+	event.PatternID = c.ID
+	event.Pattern = c.Template()
+
+	if !m.readOnly {
+		d, ok := state.dirty[c.ID]
+		if !ok {
+			d = &patternDelta{firstSeen: at}
+			state.dirty[c.ID] = d
+		}
+		d.count++
+		if at.Before(d.firstSeen) {
+			d.firstSeen = at
+		}
+		if at.After(d.lastSeen) {
+			d.lastSeen = at
+		}
+	}
+	return nil
+}
+
+// addDelta adds unflushed counts to a pattern. Caller holds s.mu.
+func (s *tenantPatterns) addDelta(patternID string, d patternDelta) {
+	cur, ok := s.dirty[patternID]
+	if !ok {
+		s.dirty[patternID] = &d
+		return
+	}
+	cur.count += d.count
+	if d.firstSeen.Before(cur.firstSeen) {
+		cur.firstSeen = d.firstSeen
+	}
+	if d.lastSeen.After(cur.lastSeen) {
+		cur.lastSeen = d.lastSeen
+	}
+}
+
+// PatternID derives the ID of a new pattern from its initial template, so
+// replicas that start the same pattern from the same message agree on it.
+// The ID is kept as the template generalizes.
+func PatternID(tenantID, template string) string {
+	sum := sha1.Sum([]byte(tenantID + "\x00" + template))
+	return hex.EncodeToString(sum[:8])
+}
+
+// tenant returns the tenant's state. Preview state is replaced every
+// interval.
+func (m *PatternMiner) tenant(tenantID string) *tenantPatterns {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	state, ok := m.tenants[tenantID]
+	if ok && m.readOnly && now.Sub(state.created) > m.interval {
+		ok = false
+	}
+	if !ok {
+		state = &tenantPatterns{
+			drain:   NewDrain(drainDepth, drainSimilarity, drainMaxChildren, m.maxPatterns),
+			created: now,
+			dirty:   make(map[string]*patternDelta),
+		}
+		m.tenants[tenantID] = state
+	}
+	return state
+}
+
+// load adds the tenant's persisted patterns to its state on first use and
+// every patternReload after, so patterns persisted by other replicas are
+// matched here too. A failed load is retried later; mining continues in the
+// meantime. Caller holds state.mu.
+func (m *PatternMiner) load(tenantID string, state *tenantPatterns) {
+	now := time.Now()
+	if m.queries == nil || now.Before(state.retryAt) {
+		return
+	}
+	if !state.loadedAt.IsZero() && (m.readOnly || now.Sub(state.loadedAt) < patternReload) {
+		return
+	}
+
+	if err := m.loadPatterns(tenantID, state.drain); err != nil {
+		slog.Error("pattern miner: failed to load patterns", "tenant_id", tenantID, "error", err)
+		state.retryAt = now.Add(patternLoadRetry)
+		return
+	}
+	state.loadedAt = now
+}
+
+func (m *PatternMiner) loadPatterns(tenantID string, drain *Drain) error {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return fmt.Errorf("invalid tenant id: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), patternLoadTimeout)
+	defer cancel()
+
+	rows, err := m.queries.ListLogPatterns(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, p := range rows {
+		drain.Insert(&Cluster{
+			ID:        p.PatternID,
+			Tokens:    splitTemplate(p.Template, int(p.TokenCount)),
+			Route:     strings.Fields(p.Route.String),
+			Count:     p.MatchCount,
+			FirstSeen: p.FirstSeen,
+			LastSeen:  p.LastSeen,
+		})
+	}
+	return nil
+}
+
+// Start flushes pattern counts to Postgres every interval until Stop is
+// called. It is a no-op for preview miners.
+func (m *PatternMiner) Start(ctx context.Context) {
+	if m.readOnly || m.queries == nil {
+		return
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.Flush(context.Background())
+				return
+			case <-ticker.C:
+				m.Flush(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the flush loop after a final flush.
+func (m *PatternMiner) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+}
+
+// Flush writes the patterns matched since the last flush. Whatever fails
+// to write is kept for the next attempt.
+func (m *PatternMiner) Flush(ctx context.Context) {
+	type pending struct {
+		tenantID string
+		cluster  Cluster
+		delta    patternDelta
+	}
+
+	m.mu.Lock()
+	states := make(map[string]*tenantPatterns, len(m.tenants))
+	for tenantID, state := range m.tenants {
+		states[tenantID] = state
+	}
+	m.mu.Unlock()
+
+	var batch []pending
+	for tenantID, state := range states {
+		state.mu.Lock()
+		for id, d := range state.dirty {
+			c := state.drain.clusters[id]
+			batch = append(batch, pending{
+				tenantID: tenantID,
+				cluster: Cluster{
+					ID:     id,
+					Tokens: append([]string(nil), c.Tokens...),
+					Route:  append([]string(nil), c.Route...),
+				},
+				delta: *d,
+			})
+		}
+		state.dirty = make(map[string]*patternDelta)
+		state.mu.Unlock()
+	}
+
+	failed := 0
+	for _, p := range batch {
+		if err := m.write(ctx, p.tenantID, &p.cluster, p.delta); err != nil {
+			failed++
+			state := states[p.tenantID]
+			state.mu.Lock()
+			state.addDelta(p.cluster.ID, p.delta)
+			state.mu.Unlock()
+		}
+	}
+	if failed > 0 {
+		slog.Error("pattern miner: flush incomplete", "failed", failed, "total", len(batch))
+	}
+}
+
+func (m *PatternMiner) write(ctx context.Context, tenantID string, c *Cluster, d patternDelta) error {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return err
+	}
+	return m.queries.UpsertLogPattern(ctx, id, c.ID, c.Template(), strings.Join(c.Route, " "), int32(len(c.Tokens)), d.count, d.firstSeen, d.lastSeen)
+}
+
+// splitTemplate restores a template's tokens. Templates are joined with
+// single spaces and tokens never contain whitespace, so this is exact unless
+// the stored token count disagrees, in which case the row is skipped.
+func splitTemplate(template string, tokenCount int) []string {
+	tokens := strings.Fields(template)
+	if len(tokens) != tokenCount {
+		return nil
+	}
+	return tokens
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/felipemonteiro/mintlog/internal/lookup"
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Processor is a single pipeline stage that transforms an event in place.
+type Processor interface {
+	Name() string
+	Process(event *logmodel.LogEvent) error
+}
+
+type funcProcessor struct {
+	name string
+	fn   func(*logmodel.LogEvent)
+}
+
+func (p funcProcessor) Name() string { return p.name }
+
+func (p funcProcessor) Process(event *logmodel.LogEvent) error {
+	p.fn(event)
+	return nil
+}
+
+// ProcessorFunc adapts a plain function into a Processor.
+func ProcessorFunc(name string, fn func(*logmodel.LogEvent)) Processor {
+	return funcProcessor{name: name, fn: fn}
+}
+
+// Pipeline runs a fixed sequence of processors.
+type Pipeline struct {
+	processors []Processor
+}
+
+func New(processors ...Processor) *Pipeline {
+	return &Pipeline{processors: processors}
+}
+
+func (p *Pipeline) Processors() []Processor {
+	return p.processors
+}
+
+// Process runs every processor in order and stops at the first error.
+func (p *Pipeline) Process(event *logmodel.LogEvent) error {
+	for _, proc := range p.processors {
+		if err := proc.Process(event); err != nil {
+			return fmt.Errorf("%s: %w", proc.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Deps holds the shared state used by the standard processors. Nil
+// dependencies disable the processors that need them.
+type Deps struct {
+	UserAgent *UserAgentParser
+	Lookups   *lookup.Cache
+	Metrics   *MetricRecorder
+	Rules     *rules.Cache
+	Fields    *FieldGuard
+	Patterns  *PatternMiner
+}
+
+// Standard returns the processing chain run by pipelined.
+func Standard(deps Deps) *Pipeline {
+	procs := []Processor{
+		ProcessorFunc(TypeParseJSON, ParseJSON),
+		ProcessorFunc(TypeNormalize, Normalize),
+		ProcessorFunc(TypeTraceContext, ExtractTraceContext),
+	}
+	if deps.UserAgent != nil {
+		procs = append(procs, deps.UserAgent)
+	}
+	if deps.Lookups != nil {
+		procs = append(procs, NewLookupJoiner(deps.Lookups))
+	}
+	if deps.Metrics != nil {
+		procs = append(procs, deps.Metrics)
+	}
+	if deps.Rules != nil {
+		procs = append(procs, NewRuleFilter(deps.Rules))
+	}
+	if deps.Fields != nil {
+		procs = append(procs, deps.Fields)
+	}
+	if deps.Patterns != nil {
+		procs = append(procs, deps.Patterns)
+	}
+	return New(procs...)
+}
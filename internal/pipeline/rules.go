@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// ErrDrop is returned by processors that discard an event. Workers
+// acknowledge dropped events without publishing them.
+var ErrDrop = errors.New("event dropped")
+
+// RuleSource returns a tenant's active rules in evaluation order;
+// *rules.Cache implements it.
+type RuleSource interface {
+	Rules(tenantID string) []*rules.Rule
+}
+
+// RuleFilter applies the tenant's drop and sampling rules. The first rule
+// whose match holds decides the event's fate.
+type RuleFilter struct {
+	cache RuleSource
+}
+
+func NewRuleFilter(cache RuleSource) *RuleFilter {
+	return &RuleFilter{cache: cache}
+}
+
+func (f *RuleFilter) Name() string { return TypeRules }
+
+func (f *RuleFilter) Process(event *logmodel.LogEvent) error {
+	for _, rule := range f.cache.Rules(event.TenantID) {
+		if !Matches(&rule.Match, event) {
+			continue
+		}
+
+		switch rule.Action {
+		case rules.ActionDrop:
+			return ErrDrop
+		case rules.ActionSample:
+			if !sampled(event, rule) {
+				return ErrDrop
+			}
+			if event.SampleRate > 0 {
+				event.SampleRate *= rule.SampleRate
+			} else {
+				event.SampleRate = rule.SampleRate
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// Matches reports whether event satisfies every condition set in m.
+func Matches(m *rules.Match, event *logmodel.LogEvent) bool {
+	if m.Level != "" && m.Level != event.Level {
+		return false
+	}
+	if m.Service != "" && m.Service != event.Service {
+		return false
+	}
+	if m.Host != "" && m.Host != event.Host {
+		return false
+	}
+	if m.Message != "" && !strings.Contains(event.Message, m.Message) {
+		return false
+	}
+	for path, want := range m.Fields {
+		v, ok := GetField(event, path)
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// sampled decides deterministically from the rule's hash field, so every
+// event sharing a trace ID gets the same decision. Events without the field
+// are sampled on their ID.
+func sampled(event *logmodel.LogEvent, rule *rules.Rule) bool {
+	key, ok := GetString(event, rule.HashField)
+	if !ok {
+		key = event.ID
+	}
+	return float64(xxhash.Sum64String(key)) < rule.SampleRate*math.MaxUint64
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+type staticRules map[string][]*rules.Rule
+
+func (r staticRules) Rules(tenantID string) []*rules.Rule { return r[tenantID] }
+
+func TestRuleFilterDrop(t *testing.T) {
+	f := NewRuleFilter(staticRules{"t1": {
+		{Name: "drop debug", Action: rules.ActionDrop, Match: rules.Match{Level: "debug"}},
+	}})
+
+	if err := f.Process(&logmodel.LogEvent{TenantID: "t1", Level: "debug"}); !errors.Is(err, ErrDrop) {
+		t.Errorf("debug event: got %v, want ErrDrop", err)
+	}
+	if err := f.Process(&logmodel.LogEvent{TenantID: "t1", Level: "error"}); err != nil {
+		t.Errorf("error event: got %v", err)
+	}
+	if err := f.Process(&logmodel.LogEvent{TenantID: "t2", Level: "debug"}); err != nil {
+		t.Errorf("other tenant: got %v", err)
+	}
+}
+
+func TestRuleFilterSample(t *testing.T) {
+	f := NewRuleFilter(staticRules{"t1": {
+		{Name: "sample info", Action: rules.ActionSample, Match: rules.Match{Level: "info"}, SampleRate: 0.25, HashField: "trace_id"},
+	}})
+
+	const n = 10000
+	kept := 0
+	for i := range n {
+		e := &logmodel.LogEvent{TenantID: "t1", Level: "info", TraceID: fmt.Sprintf("trace-%d", i)}
+		err := f.Process(e)
+		if errors.Is(err, ErrDrop) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept++
+		if e.SampleRate != 0.25 {
+			t.Fatalf("kept event has sample_rate %v, want 0.25", e.SampleRate)
+		}
+	}
+	if rate := float64(kept) / n; math.Abs(rate-0.25) > 0.02 {
+		t.Errorf("kept %.3f of events, want about 0.25", rate)
+	}
+
+	// Every event of a trace gets the same decision.
+	for i := range 100 {
+		trace := fmt.Sprintf("trace-%d", i)
+		first := f.Process(&logmodel.LogEvent{TenantID: "t1", Level: "info", TraceID: trace, ID: "a"})
+		for _, id := range []string{"b", "c", "d"} {
+			if got := f.Process(&logmodel.LogEvent{TenantID: "t1", Level: "info", TraceID: trace, ID: id}); errors.Is(got, ErrDrop) != errors.Is(first, ErrDrop) {
+				t.Fatalf("trace %s: events got different decisions", trace)
+			}
+		}
+	}
+}
+
+func TestRuleFilterSampleCompounds(t *testing.T) {
+	f := NewRuleFilter(staticRules{"t1": {
+		{Name: "sample all", Action: rules.ActionSample, SampleRate: 0.5},
+	}})
+	// An event sampled upstream keeps the product of both rates.
+	for i := range 100 {
+		e := &logmodel.LogEvent{TenantID: "t1", ID: fmt.Sprintf("e%d", i), SampleRate: 0.5}
+		if err := f.Process(e); err != nil {
+			continue
+		}
+		if e.SampleRate != 0.25 {
+			t.Fatalf("sample_rate %v, want 0.25", e.SampleRate)
+		}
+		return
+	}
+	t.Fatal("no event kept")
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Step records what a single processor changed on an event.
+type Step struct {
+	Processor string   `json:"processor"`
+	Changes   []Change `json:"changes,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Change is a single field difference. Before is omitted for added fields and
+// After for removed ones.
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Trace runs the pipeline like Process but records the changes made by each
+// processor. It stops at the first failing processor.
+func (p *Pipeline) Trace(event *logmodel.LogEvent) ([]Step, error) {
+	steps := make([]Step, 0, len(p.processors))
+	before := snapshot(event)
+
+	for _, proc := range p.processors {
+		step := Step{Processor: proc.Name()}
+		err := proc.Process(event)
+
+		after := snapshot(event)
+		step.Changes = diff(before, after)
+		before = after
+
+		if err != nil {
+			step.Error = err.Error()
+			steps = append(steps, step)
+			return steps, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// snapshot flattens an event into field references (see GetField) mapped to
+// leaf values.
+func snapshot(event *logmodel.LogEvent) map[string]any {
+	s := map[string]any{
+		"id":         event.ID,
+		"tenant_id":  event.TenantID,
+		"level":      event.Level,
+		"message":    event.Message,
+		"service":    event.Service,
+		"host":       event.Host,
+		"trace_id":   event.TraceID,
+		"span_id":    event.SpanID,
+		"pattern_id": event.PatternID,
+		"pattern":    event.Pattern,
+		"raw":        event.Raw,
+	}
+	if event.SampleRate != 0 {
+		s["sample_rate"] = event.SampleRate
+	}
+	if !event.Timestamp.IsZero() {
+		s["timestamp"] = event.Timestamp.Format(time.RFC3339Nano)
+	}
+	if len(event.Tags) > 0 {
+		s["tags"] = append([]string(nil), event.Tags...)
+	}
+	flattenInto(s, "fields", event.Fields)
+	return s
+}
+
+func flattenInto(dst map[string]any, prefix string, m map[string]any) {
+	for k, v := range m {
+		key := prefix + "." + k
+		switch t := v.(type) {
+		case map[string]any:
+			flattenInto(dst, key, t)
+		case []any:
+			dst[key] = append([]any(nil), t...)
+		default:
+			dst[key] = v
+		}
+	}
+}
+
+func diff(before, after map[string]any) []Change {
+	var changes []Change
+	for k, b := range before {
+		a, ok := after[k]
+		switch {
+		case !ok:
+			if !isEmpty(b) {
+				changes = append(changes, Change{Field: k, Before: b})
+			}
+		case !reflect.DeepEqual(a, b):
+			changes = append(changes, Change{Field: k, Before: b, After: a})
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok && !isEmpty(a) {
+			changes = append(changes, Change{Field: k, After: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func isEmpty(v any) bool {
+	s, ok := v.(string)
+	return v == nil || (ok && s == "")
+}
@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/felipemonteiro/mintlog/internal/tracecontext"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Propagation keys looked up in Fields, lowercased. A key matches at any
+// depth up to maxTraceKeyDepth, so "traceparent", "headers.traceparent" and
+// "http.request.headers.traceparent" all count.
+const (
+	keyTraceparent   = "traceparent"
+	keyB3            = "b3"
+	keyB3TraceID     = "x-b3-traceid"
+	keyB3SpanID      = "x-b3-spanid"
+	keyXRay          = "x-amzn-trace-id"
+	keyXRayAlt       = "aws.xray.trace_id"
+	keyDDTraceID     = "dd.trace_id"
+	keyDDSpanID      = "dd.span_id"
+	keyDDTraceHeader = "x-datadog-trace-id"
+	keyDDSpanHeader  = "x-datadog-parent-id"
+	keyDDHighBits    = "_dd.p.tid"
+)
+
+const maxTraceKeyDepth = 4
+
+var traceKeys = []string{
+	keyTraceparent, keyB3, keyB3TraceID, keyB3SpanID, keyXRay, keyXRayAlt,
+	keyDDTraceID, keyDDSpanID, keyDDTraceHeader, keyDDSpanHeader, keyDDHighBits,
+}
+
+// ExtractTraceContext normalizes TraceID and SpanID to lowercase hex (32 and
+// 16 digits) and fills them from propagation headers logged in Fields when
+// they are missing. Formats are tried in order: W3C traceparent, B3 single,
+// B3 multi, AWS X-Ray, Datadog. Source fields are left in place.
+func ExtractTraceContext(event *logmodel.LogEvent) {
+	if event.TraceID != "" {
+		if id, span, ok := tracecontext.Parse(event.TraceID); ok {
+			event.TraceID = id
+			if event.SpanID == "" {
+				event.SpanID = span
+			}
+		}
+	}
+	if event.SpanID != "" {
+		event.SpanID, _ = tracecontext.NormalizeSpan(event.SpanID)
+	}
+	if event.TraceID != "" && event.SpanID != "" {
+		return
+	}
+
+	found := make(map[string]string)
+	collectTraceKeys(found, "", event.Fields, 1)
+	if len(found) == 0 {
+		return
+	}
+
+	var traceID, spanID string
+	var ok bool
+	if v := found[keyTraceparent]; v != "" {
+		traceID, spanID, ok = tracecontext.FromTraceparent(v)
+	}
+	if !ok && found[keyB3] != "" {
+		traceID, spanID, ok = tracecontext.FromB3(found[keyB3])
+	}
+	if !ok && found[keyB3TraceID] != "" {
+		if traceID, ok = tracecontext.TraceID(found[keyB3TraceID]); ok {
+			spanID, _ = tracecontext.SpanID(found[keyB3SpanID])
+		}
+	}
+	for _, k := range []string{keyXRay, keyXRayAlt} {
+		if !ok && found[k] != "" {
+			traceID, spanID, ok = tracecontext.FromXRay(found[k])
+		}
+	}
+	if !ok && found[keyDDTraceID] != "" {
+		traceID, spanID, ok = tracecontext.FromDatadog(found[keyDDTraceID], found[keyDDSpanID], found[keyDDHighBits])
+	}
+	if !ok && found[keyDDTraceHeader] != "" {
+		traceID, spanID, ok = tracecontext.FromDatadog(found[keyDDTraceHeader], found[keyDDSpanHeader], found[keyDDHighBits])
+	}
+	if !ok {
+		return
+	}
+
+	if event.TraceID == "" {
+		event.TraceID = traceID
+	}
+	// A span ID only belongs to the trace it came with.
+	if event.SpanID == "" && event.TraceID == traceID {
+		event.SpanID = spanID
+	}
+}
+
+// collectTraceKeys records the first value found for each propagation key.
+// Keys are compared case-insensitively on their trailing path segments.
+func collectTraceKeys(found map[string]string, prefix string, m map[string]any, depth int) {
+	for k, v := range m {
+		path := prefix + strings.ToLower(k)
+		if child, ok := v.(map[string]any); ok {
+			if depth < maxTraceKeyDepth {
+				collectTraceKeys(found, path+".", child, depth+1)
+			}
+			continue
+		}
+		s, ok := traceValue(v)
+		if !ok {
+			continue
+		}
+		for _, key := range traceKeys {
+			if _, seen := found[key]; seen {
+				continue
+			}
+			if path == key || strings.HasSuffix(path, "."+key) {
+				found[key] = s
+			}
+		}
+	}
+}
+
+// traceValue accepts strings and integral JSON numbers. Numbers above 2^53
+// have already lost precision when decoded, so they are ignored; Datadog
+// IDs should be logged as strings.
+func traceValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case []any:
+		if len(v) == 1 {
+			return traceValue(v[0])
+		}
+	case float64:
+		if v > 0 && v <= 1<<53 && v == math.Trunc(v) {
+			return strconv.FormatUint(uint64(v), 10), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ua-parser/uap-go/uaparser"
+
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// UserAgentKey is the Fields key under which parsed User-Agent data is stored.
+const UserAgentKey = "ua"
+
+// Device types assigned by the User-Agent parser.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+var mobileOS = map[string]bool{
+	"iOS":                  true,
+	"Android":              true,
+	"Windows Phone":        true,
+	"BlackBerry OS":        true,
+	"Symbian OS":           true,
+	"KaiOS":                true,
+	"Firefox OS":           true,
+	"HarmonyOS":            true,
+	"Windows Mobile":       true,
+	"Tizen":                true,
+	"Sailfish":             true,
+	"webOS":                true,
+	"Ubuntu Touch":         true,
+	"Amazon Fire OS":       true,
+	"Fire OS":              true,
+	"BlackBerry Tablet OS": true,
+}
+
+var desktopOS = map[string]bool{
+	"Windows":    true,
+	"Mac OS X":   true,
+	"macOS":      true,
+	"Linux":      true,
+	"Ubuntu":     true,
+	"Debian":     true,
+	"Fedora":     true,
+	"Chrome OS":  true,
+	"FreeBSD":    true,
+	"OpenBSD":    true,
+	"NetBSD":     true,
+	"Solaris":    true,
+	"Red Hat":    true,
+	"SUSE":       true,
+	"Arch Linux": true,
+	"Mint":       true,
+}
+
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "headless", "curl/", "wget/", "python-requests", "go-http-client"}
+
+// UserAgentParser classifies User-Agent strings using the uap-core regex
+// database embedded in uap-go.
+type UserAgentParser struct {
+	field  string
+	parser *uaparser.Parser
+}
+
+// NewUserAgentParser returns a parser reading the User-Agent from field,
+// a reference such as "fields.user_agent" (see GetField).
+func NewUserAgentParser(field string) (*UserAgentParser, error) {
+	p, err := uaparser.New()
+	if err != nil {
+		return nil, fmt.Errorf("load uap-core definitions: %w", err)
+	}
+	return &UserAgentParser{field: field, parser: p}, nil
+}
+
+// WithField returns a parser sharing the same regex database but reading
+// the User-Agent from a different field.
+func (p *UserAgentParser) WithField(field string) *UserAgentParser {
+	return &UserAgentParser{field: field, parser: p.parser}
+}
+
+func (p *UserAgentParser) Name() string { return TypeUserAgent }
+
+// Process stores browser, OS, device and bot classification under Fields["ua"].
+func (p *UserAgentParser) Process(event *logmodel.LogEvent) error {
+	raw, ok := GetString(event, p.field)
+	if !ok {
+		return nil
+	}
+
+	client := p.parser.Parse(raw)
+	deviceType := classifyDevice(client, raw)
+
+	ua := map[string]any{
+		"browser":     client.UserAgent.Family,
+		"os":          client.Os.Family,
+		"device":      client.Device.Family,
+		"device_type": deviceType,
+		"is_bot":      deviceType == DeviceBot,
+	}
+	if v := joinVersion(client.UserAgent.Major, client.UserAgent.Minor, client.UserAgent.Patch); v != "" {
+		ua["browser_version"] = v
+	}
+	if v := joinVersion(client.Os.Major, client.Os.Minor, client.Os.Patch); v != "" {
+		ua["os_version"] = v
+	}
+
+	if event.Fields == nil {
+		event.Fields = make(map[string]any)
+	}
+	event.Fields[UserAgentKey] = ua
+	return nil
+}
+
+func classifyDevice(client *uaparser.Client, raw string) string {
+	lower := strings.ToLower(raw)
+
+	if client.Device.Family == "Spider" {
+		return DeviceBot
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return DeviceBot
+		}
+	}
+
+	device := strings.ToLower(client.Device.Family)
+	if strings.Contains(device, "ipad") || strings.Contains(device, "tablet") ||
+		strings.Contains(device, "kindle") || strings.Contains(lower, "tablet") {
+		return DeviceTablet
+	}
+	if client.Os.Family == "Android" && !strings.Contains(lower, "mobile") {
+		return DeviceTablet
+	}
+
+	if mobileOS[client.Os.Family] || strings.Contains(lower, "mobile") {
+		return DeviceMobile
+	}
+	if desktopOS[client.Os.Family] {
+		return DeviceDesktop
+	}
+	return DeviceOther
+}
+
+func joinVersion(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p == "" {
+			break
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, ".")
+}
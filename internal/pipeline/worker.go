@@ -1,70 +1,237 @@
 package pipeline
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/felipemonteiro/mintlog/internal/bus"
 	"github.com/felipemonteiro/mintlog/pkg/logmodel"
 )
 
+// stageName identifies the pipeline worker in dead-letter headers.
+const stageName = "pipeline"
+
+const (
+	workerStream   = "LOGS_RAW"
+	workerConsumer = "pipeline-worker"
+	fetchWait      = time.Second
+	publishTimeout = 10 * time.Second
+)
+
+// Worker consumes logs.raw through a pull consumer. A fetch loop pulls
+// batches and hands messages to a fixed pool of goroutines, which run the
+// pipeline and publish to logs.parsed without waiting for the server. An
+// acker acks each source message only once its publish is confirmed, so an
+// event leaves logs.raw only after it is stored in LOGS_PARSED.
+//
+// At most maxPending publishes are awaiting confirmation; when the acker
+// falls behind, the pool and then the fetch loop block.
 type Worker struct {
-	js  nats.JetStreamContext
-	pub *bus.Publisher
-	sub *nats.Subscription
+	js         jetstream.JetStream
+	pub        *bus.Publisher
+	pipeline   *Pipeline
+	codec      *bus.Codec
+	workers    int
+	batchSize  int
+	maxPending int
+
+	dlq    *nats.Subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// published is a source message waiting for its result's publish ack.
+type published struct {
+	src jetstream.Msg
+	ack jetstream.PubAckFuture
 }
 
-func NewWorker(js nats.JetStreamContext, pub *bus.Publisher) *Worker {
-	return &Worker{js: js, pub: pub}
+// NewWorker runs pipeline on workers goroutines (GOMAXPROCS if zero),
+// fetching batchSize messages at a time and publishing with codec. js
+// should allow maxPending async publishes
+// (jetstream.WithPublishAsyncMaxPending).
+func NewWorker(js jetstream.JetStream, pub *bus.Publisher, pipeline *Pipeline, codec *bus.Codec, workers, batchSize, maxPending int) *Worker {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Worker{
+		js:         js,
+		pub:        pub,
+		pipeline:   pipeline,
+		codec:      codec,
+		workers:    workers,
+		batchSize:  batchSize,
+		maxPending: maxPending,
+	}
 }
 
-func (w *Worker) Start() error {
-	sub, err := w.js.QueueSubscribe(
-		"logs.raw.>",
-		"pipeline-workers",
-		w.handleMessage,
-		nats.Durable("pipeline-worker"),
-		nats.ManualAck(),
-		nats.AckWait(30_000_000_000), // 30s
-		nats.MaxDeliver(3),
-	)
+func (w *Worker) Start(ctx context.Context) error {
+	cons, err := w.ensureConsumer(ctx)
+	if err != nil {
+		return err
+	}
+	w.dlq, err = w.pub.WatchMaxDeliveries(workerStream, workerConsumer, stageName)
 	if err != nil {
-		return fmt.Errorf("subscribe logs.raw: %w", err)
+		return err
 	}
-	w.sub = sub
-	slog.Info("pipeline worker started", "subject", "logs.raw.>")
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	jobs := make(chan jetstream.Msg, w.batchSize)
+	pending := make(chan published, w.maxPending)
+
+	var pool sync.WaitGroup
+	for range w.workers {
+		pool.Add(1)
+		go func() {
+			defer pool.Done()
+			for msg := range jobs {
+				w.handleMessage(msg, pending)
+			}
+		}()
+	}
+
+	acked := make(chan struct{})
+	go func() {
+		defer close(acked)
+		w.ackPublished(pending)
+	}()
+
+	go func() {
+		defer close(w.done)
+		w.fetch(ctx, cons, jobs)
+		close(jobs)
+		pool.Wait()
+		close(pending)
+		<-acked
+	}()
+
+	slog.Info("pipeline worker started", "subject", "logs.raw.>", "workers", w.workers, "batch_size", w.batchSize)
 	return nil
 }
 
+// Stop stops fetching and waits until every fetched message is processed
+// and its publish confirmed.
 func (w *Worker) Stop() {
-	if w.sub != nil {
-		w.sub.Unsubscribe()
+	if w.cancel == nil {
+		return
 	}
+	w.cancel()
+	<-w.done
+	w.dlq.Unsubscribe()
 }
 
-func (w *Worker) handleMessage(msg *nats.Msg) {
+// ensureConsumer creates or updates the durable pull consumer. Earlier
+// versions used a push consumer of the same name, which cannot be turned
+// into a pull consumer; it is replaced. LOGS_RAW is a work queue, so
+// messages it had not acked stay in the stream for the new consumer.
+func (w *Worker) ensureConsumer(ctx context.Context) (jetstream.Consumer, error) {
+	cfg := jetstream.ConsumerConfig{
+		Durable:       workerConsumer,
+		FilterSubject: "logs.raw.>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       30 * time.Second,
+		MaxDeliver:    bus.MaxDeliver,
+		MaxAckPending: w.maxPending + 2*w.batchSize + w.workers,
+	}
+	cons, err := w.js.CreateOrUpdateConsumer(ctx, workerStream, cfg)
+	if err == nil {
+		return cons, nil
+	}
+	if _, infoErr := w.js.Consumer(ctx, workerStream, workerConsumer); infoErr != nil {
+		return nil, fmt.Errorf("create consumer %s: %w", workerConsumer, err)
+	}
+	slog.Warn("pipeline: replacing consumer", "consumer", workerConsumer, "reason", err)
+	if err := w.js.DeleteConsumer(ctx, workerStream, workerConsumer); err != nil {
+		return nil, fmt.Errorf("delete consumer %s: %w", workerConsumer, err)
+	}
+	cons, err = w.js.CreateOrUpdateConsumer(ctx, workerStream, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create consumer %s: %w", workerConsumer, err)
+	}
+	return cons, nil
+}
+
+func (w *Worker) fetch(ctx context.Context, cons jetstream.Consumer, jobs chan<- jetstream.Msg) {
+	for ctx.Err() == nil {
+		batch, err := cons.Fetch(w.batchSize, jetstream.FetchMaxWait(fetchWait))
+		if err != nil {
+			slog.Error("pipeline: fetch failed", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(fetchWait):
+			}
+			continue
+		}
+		for msg := range batch.Messages() {
+			jobs <- msg
+		}
+		if err := batch.Error(); err != nil {
+			slog.Warn("pipeline: fetch ended early", "error", err)
+		}
+	}
+}
+
+func (w *Worker) handleMessage(msg jetstream.Msg, pending chan<- published) {
 	var event logmodel.LogEvent
-	if err := json.Unmarshal(msg.Data, &event); err != nil {
-		slog.Error("pipeline: failed to unmarshal event", "error", err)
-		msg.Nak()
+	if err := bus.DecodeEvent(msg.Headers(), msg.Data(), &event); err != nil {
+		slog.Error("pipeline: failed to decode event", "error", err)
+		w.pub.RejectJS(msg, stageName, fmt.Errorf("decode: %w", err))
 		return
 	}
 
-	// Parse, normalize, enrich
-	ParseJSON(&event)
-	Normalize(&event)
-	Enrich(&event)
+	// Parse, normalize, enrich, filter
+	if err := w.pipeline.Process(&event); err != nil {
+		if errors.Is(err, ErrDrop) {
+			msg.Ack()
+			return
+		}
+		slog.Error("pipeline: failed to process event", "error", err)
+		w.pub.FailJS(msg, stageName, err)
+		return
+	}
 
-	// Publish to logs.parsed
-	subject := fmt.Sprintf("logs.parsed.%s", event.TenantID)
-	if err := w.pub.Publish(subject, &event); err != nil {
+	// Publish to logs.parsed; the acker acks msg once the stream confirms.
+	out, err := w.codec.Encode(fmt.Sprintf("logs.parsed.%s", event.TenantID), &event)
+	if err != nil {
+		w.pub.RejectJS(msg, stageName, err)
+		return
+	}
+	ack, err := w.js.PublishMsgAsync(out)
+	if err != nil {
 		slog.Error("pipeline: failed to publish parsed event", "error", err)
-		msg.Nak()
+		w.pub.FailJS(msg, stageName, err)
 		return
 	}
+	pending <- published{src: msg, ack: ack}
+}
 
-	msg.Ack()
+// ackPublished acks source messages as their publishes are confirmed, in
+// publish order. A failed or unconfirmed publish leaves the event to be
+// redelivered.
+func (w *Worker) ackPublished(pending <-chan published) {
+	timer := time.NewTimer(publishTimeout)
+	defer timer.Stop()
+	for p := range pending {
+		timer.Reset(publishTimeout)
+		select {
+		case <-p.ack.Ok():
+			p.src.Ack()
+		case err := <-p.ack.Err():
+			slog.Error("pipeline: failed to publish parsed event", "error", err)
+			w.pub.FailJS(p.src, stageName, err)
+		case <-timer.C:
+			slog.Error("pipeline: publish not confirmed", "subject", p.ack.Msg().Subject, "timeout", publishTimeout)
+			w.pub.FailJS(p.src, stageName, errors.New("publish not confirmed"))
+		}
+	}
 }
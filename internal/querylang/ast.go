@@ -0,0 +1,371 @@
+package querylang
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Expr is a parsed query. Validate it before matching or compiling it.
+type Expr struct {
+	Root Node
+}
+
+var _ logstore.Expr = (*Expr)(nil)
+
+// Node is a node of a parsed query: And, Or, Not, Term, Range, Exists or
+// All.
+type Node interface {
+	dsl() map[string]any
+	match(e *logmodel.LogEvent) bool
+}
+
+// And matches events every node matches.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches events any node matches.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches events the node does not.
+type Not struct {
+	Node Node
+}
+
+// Term matches a value of a field, or message words when Field is empty.
+type Term struct {
+	Field    string
+	Value    string
+	Pattern  string // Value with wildcards, escapes kept
+	Phrase   bool   // quoted
+	Wildcard bool   // Pattern has * or ?
+
+	pos, end           int
+	fieldPos, fieldEnd int
+
+	// Set by Validate.
+	field field
+	num   float64
+	flag  bool
+	glob  *regexp.Regexp
+}
+
+// Range matches values between From and To; an empty bound is open.
+type Range struct {
+	Field       string
+	From        string
+	To          string
+	IncludeFrom bool
+	IncludeTo   bool
+
+	pos, end           int
+	fieldPos, fieldEnd int
+
+	// Set by Validate.
+	field            field
+	numFrom, numTo   float64
+	timeFrom, timeTo time.Time
+}
+
+// Exists matches events with a value for Field.
+type Exists struct {
+	Field string
+
+	fieldPos, fieldEnd int
+
+	field field // set by Validate
+}
+
+// All matches every event.
+type All struct{}
+
+// Matches reports whether an event matches.
+func (x *Expr) Matches(e *logmodel.LogEvent) bool {
+	return x.Root.match(e)
+}
+
+// DSL returns the query as an OpenSearch query.
+func (x *Expr) DSL() map[string]any {
+	return x.Root.dsl()
+}
+
+// Terms returns message words every matching event has, for pruning
+// archived objects by their term filter.
+func (x *Expr) Terms() []string {
+	return requiredTerms(x.Root)
+}
+
+func requiredTerms(n Node) []string {
+	switch n := n.(type) {
+	case *And:
+		var terms []string
+		for _, c := range n.Nodes {
+			terms = append(terms, requiredTerms(c)...)
+		}
+		return terms
+	case *Term:
+		if n.field.typ == typeText && !n.Wildcard {
+			return logstore.Terms(n.Value)
+		}
+	}
+	return nil
+}
+
+func (n *And) dsl() map[string]any {
+	must := make([]map[string]any, len(n.Nodes))
+	for i, c := range n.Nodes {
+		must[i] = c.dsl()
+	}
+	return map[string]any{"bool": map[string]any{"must": must}}
+}
+
+func (n *And) match(e *logmodel.LogEvent) bool {
+	for _, c := range n.Nodes {
+		if !c.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Or) dsl() map[string]any {
+	should := make([]map[string]any, len(n.Nodes))
+	for i, c := range n.Nodes {
+		should[i] = c.dsl()
+	}
+	return map[string]any{"bool": map[string]any{"should": should, "minimum_should_match": 1}}
+}
+
+func (n *Or) match(e *logmodel.LogEvent) bool {
+	for _, c := range n.Nodes {
+		if c.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Not) dsl() map[string]any {
+	return map[string]any{"bool": map[string]any{"must_not": []map[string]any{n.Node.dsl()}}}
+}
+
+func (n *Not) match(e *logmodel.LogEvent) bool {
+	return !n.Node.match(e)
+}
+
+func (n *Term) dsl() map[string]any {
+	path := n.field.path
+	switch n.field.typ {
+	case typeText:
+		switch {
+		case n.Wildcard:
+			// On the words of the message, which are lower-cased.
+			return map[string]any{"wildcard": map[string]any{path: map[string]any{"value": strings.ToLower(n.Pattern), "case_insensitive": true}}}
+		case n.Phrase:
+			return map[string]any{"match_phrase": map[string]any{path: n.Value}}
+		}
+		return map[string]any{"match": map[string]any{path: map[string]any{"query": n.Value, "operator": "and"}}}
+	case typeNumber:
+		return map[string]any{"term": map[string]any{path: n.num}}
+	case typeBoolean:
+		return map[string]any{"term": map[string]any{path: n.flag}}
+	}
+	return n.field.exact(func(path string) map[string]any {
+		if n.Wildcard {
+			return map[string]any{"wildcard": map[string]any{path: map[string]any{"value": n.Pattern}}}
+		}
+		return map[string]any{"term": map[string]any{path: n.Value}}
+	})
+}
+
+// exact runs a query on the exact values of a keyword field. A dynamic
+// field is queried on its keyword subfield, or on itself in indices that
+// map it as a keyword and so have no subfield; the analyzed text would
+// miss values with capitals, hyphens or spaces.
+func (f field) exact(query func(path string) map[string]any) map[string]any {
+	if !f.dynamic {
+		return query(f.path)
+	}
+	sub := f.path + ".keyword"
+	return map[string]any{"bool": map[string]any{
+		"should": []map[string]any{
+			query(sub),
+			{"bool": map[string]any{
+				"filter":   []map[string]any{query(f.path)},
+				"must_not": []map[string]any{{"exists": map[string]any{"field": sub}}},
+			}},
+		},
+		"minimum_should_match": 1,
+	}}
+}
+
+func (n *Term) match(e *logmodel.LogEvent) bool {
+	if n.field.typ == typeText {
+		words := logstore.Terms(e.Message)
+		switch {
+		case n.Wildcard:
+			for _, w := range words {
+				if n.glob.MatchString(w) {
+					return true
+				}
+			}
+			return false
+		case n.Phrase:
+			return logstore.ContainsRun(words, logstore.Terms(n.Value))
+		}
+		for _, t := range logstore.Terms(n.Value) {
+			if !logstore.ContainsRun(words, []string{t}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return anyValue(e, n.field.path, func(v any) bool {
+		switch n.field.typ {
+		case typeNumber:
+			f, ok := toFloat(v)
+			return ok && f == n.num
+		case typeBoolean:
+			return logstore.FormatValue(v) == strconv.FormatBool(n.flag)
+		}
+		if n.Wildcard {
+			return n.glob.MatchString(logstore.FormatValue(v))
+		}
+		return logstore.FormatValue(v) == n.Value
+	})
+}
+
+func (n *Range) dsl() map[string]any {
+	bounds := map[string]any{}
+	from, to := any(n.From), any(n.To)
+	switch n.field.typ {
+	case typeNumber:
+		from, to = n.numFrom, n.numTo
+	case typeDate:
+		from, to = n.timeFrom.Format(time.RFC3339Nano), n.timeTo.Format(time.RFC3339Nano)
+	}
+	if n.From != "" {
+		bounds[cmp("gt", n.IncludeFrom)] = from
+	}
+	if n.To != "" {
+		bounds[cmp("lt", n.IncludeTo)] = to
+	}
+	return n.field.exact(func(path string) map[string]any {
+		return map[string]any{"range": map[string]any{path: bounds}}
+	})
+}
+
+func cmp(op string, inclusive bool) string {
+	if inclusive {
+		return op + "e"
+	}
+	return op
+}
+
+func (n *Range) match(e *logmodel.LogEvent) bool {
+	return anyValue(e, n.field.path, func(v any) bool {
+		var c func(bound string, num float64, t time.Time) int
+		switch n.field.typ {
+		case typeNumber:
+			f, ok := toFloat(v)
+			if !ok {
+				return false
+			}
+			c = func(_ string, num float64, _ time.Time) int { return compare(f, num) }
+		case typeDate:
+			t, ok := v.(time.Time)
+			if !ok {
+				return false
+			}
+			c = func(_ string, _ float64, b time.Time) int { return t.Compare(b) }
+		default:
+			s := logstore.FormatValue(v)
+			c = func(bound string, _ float64, _ time.Time) int { return strings.Compare(s, bound) }
+		}
+		if n.From != "" {
+			d := c(n.From, n.numFrom, n.timeFrom)
+			if d < 0 || d == 0 && !n.IncludeFrom {
+				return false
+			}
+		}
+		if n.To != "" {
+			d := c(n.To, n.numTo, n.timeTo)
+			if d > 0 || d == 0 && !n.IncludeTo {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (n *Exists) dsl() map[string]any {
+	return map[string]any{"exists": map[string]any{"field": n.field.path}}
+}
+
+func (n *Exists) match(e *logmodel.LogEvent) bool {
+	v, ok := logstore.FieldValue(e, n.field.path)
+	return ok && v != ""
+}
+
+func (*All) dsl() map[string]any {
+	return map[string]any{"match_all": map[string]any{}}
+}
+
+func (*All) match(*logmodel.LogEvent) bool {
+	return true
+}
+
+// anyValue reports whether fn holds for a field's value, or for an array
+// any of its elements.
+func anyValue(e *logmodel.LogEvent, path string, fn func(any) bool) bool {
+	v, ok := logstore.FieldValue(e, path)
+	if !ok {
+		return false
+	}
+	switch v := v.(type) {
+	case []string:
+		for _, s := range v {
+			if fn(s) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		for _, item := range v {
+			if fn(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return fn(v)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,199 @@
+package querylang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokTo
+	tokColon
+	tokCmp // >, >=, < or <=
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokLBrace
+	tokRBrace
+)
+
+type token struct {
+	kind tokenKind
+	text string // a word or phrase unescaped, or the operator
+	pos  int
+	end  int
+
+	// pattern is a word with the escapes of *, ? and \ kept, for
+	// wildcard matching; wildcard is set if it has unescaped * or ?.
+	pattern  string
+	wildcard bool
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokWord:
+		return fmt.Sprintf("%q", t.text)
+	case tokPhrase:
+		return "phrase"
+	}
+	return t.text
+}
+
+// special ends a word.
+const special = `()[]{}"`
+
+// lex splits a query into tokens, ending with tokEOF. Positions count
+// characters. A colon only separates a field from its value: within a
+// value, such as a time or a URL, it is part of the word.
+func lex(input []rune) ([]token, error) {
+	var toks []token
+	inRange := false
+	i := 0
+	for {
+		for i < len(input) && unicode.IsSpace(input[i]) {
+			i++
+		}
+		if i == len(input) {
+			return append(toks, token{kind: tokEOF, text: "end of query", pos: i, end: i}), nil
+		}
+
+		// A value follows a colon or comparison, or sits in a range.
+		value := inRange
+		if n := len(toks); n > 0 && (toks[n-1].kind == tokColon || toks[n-1].kind == tokCmp) {
+			value = true
+		}
+		start := i
+		r := input[i]
+		next := rune(0)
+		if i+1 < len(input) {
+			next = input[i+1]
+		}
+		single := func(kind tokenKind) {
+			toks = append(toks, token{kind: kind, text: string(r), pos: start, end: start + 1})
+			i++
+		}
+
+		switch {
+		case r == '(':
+			single(tokLParen)
+		case r == ')':
+			single(tokRParen)
+		case r == '[':
+			single(tokLBracket)
+			inRange = true
+		case r == '{':
+			single(tokLBrace)
+			inRange = true
+		case r == ']':
+			single(tokRBracket)
+			inRange = false
+		case r == '}':
+			single(tokRBrace)
+			inRange = false
+		case r == ':' && !value:
+			single(tokColon)
+		case r == '"':
+			t, err := lexPhrase(input, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, t)
+			i = t.end
+		case value && !inRange && (r == '>' || r == '<'):
+			op := string(r)
+			if next == '=' {
+				op += "="
+			}
+			toks = append(toks, token{kind: tokCmp, text: op, pos: start, end: start + len(op)})
+			i += len(op)
+		case r == '&' && next == '&':
+			toks = append(toks, token{kind: tokAnd, text: "&&", pos: start, end: start + 2})
+			i += 2
+		case r == '|' && next == '|':
+			toks = append(toks, token{kind: tokOr, text: "||", pos: start, end: start + 2})
+			i += 2
+		case !value && (r == '-' || r == '!') && next != 0 && !unicode.IsSpace(next):
+			single(tokNot)
+		case !value && r == '+' && next != 0 && !unicode.IsSpace(next):
+			i++ // required, as every clause is by default
+		default:
+			t := lexWord(input, i, value)
+			switch {
+			case inRange && t.text == "TO" && t.pattern == "TO":
+				t.kind = tokTo
+			case !value && t.pattern == "AND":
+				t.kind = tokAnd
+			case !value && t.pattern == "OR":
+				t.kind = tokOr
+			case !value && t.pattern == "NOT":
+				t.kind = tokNot
+			}
+			toks = append(toks, t)
+			i = t.end
+		}
+	}
+}
+
+// lexWord reads a word from input[i:]. A backslash escapes the character
+// after it.
+func lexWord(input []rune, i int, value bool) token {
+	t := token{kind: tokWord, pos: i}
+	var text, pattern strings.Builder
+	for i < len(input) {
+		r := input[i]
+		if unicode.IsSpace(r) || strings.ContainsRune(special, r) || r == ':' && !value {
+			break
+		}
+		if r == '\\' && i+1 < len(input) {
+			c := input[i+1]
+			text.WriteRune(c)
+			if c == '*' || c == '?' || c == '\\' {
+				pattern.WriteRune('\\')
+			}
+			pattern.WriteRune(c)
+			i += 2
+			continue
+		}
+		if r == '*' || r == '?' {
+			t.wildcard = true
+		}
+		text.WriteRune(r)
+		pattern.WriteRune(r)
+		i++
+	}
+	t.text, t.pattern, t.end = text.String(), pattern.String(), i
+	return t
+}
+
+// lexPhrase reads a quoted phrase starting at input[i]. A backslash
+// escapes the character after it.
+func lexPhrase(input []rune, i int) (token, error) {
+	t := token{kind: tokPhrase, pos: i}
+	var text strings.Builder
+	for i++; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 < len(input) {
+				i++
+			}
+			text.WriteRune(input[i])
+		case '"':
+			t.text, t.end = text.String(), i+1
+			return t, nil
+		default:
+			text.WriteRune(input[i])
+		}
+	}
+	return t, &Error{Pos: t.pos, End: len(input), Msg: "unterminated phrase"}
+}
@@ -0,0 +1,121 @@
+package querylang_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/querylang"
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+)
+
+// TestKeywordFieldsOnRealMappings runs queries on catalog string fields
+// against indices mapped by the current template, where such fields are
+// dynamic text with a keyword subfield, and by a tenant override mapping
+// one as a keyword. It needs OpenSearch at MINTLOG_TEST_OPENSEARCH_URL.
+func TestKeywordFieldsOnRealMappings(t *testing.T) {
+	url := os.Getenv("MINTLOG_TEST_OPENSEARCH_URL")
+	if url == "" {
+		t.Skip("MINTLOG_TEST_OPENSEARCH_URL not set")
+	}
+
+	tmpl := osstore.CurrentTemplate()
+	dynamic := fmt.Sprintf("mintlog-querylang-test-%d", time.Now().UnixNano())
+	keyword := dynamic + "-keyword"
+
+	for _, index := range []struct {
+		name    string
+		mapping map[string]any // of fields.env, or nil for dynamic
+	}{{dynamic, nil}, {keyword, map[string]any{"type": "keyword"}}} {
+		var mappings map[string]any
+		data, _ := json.Marshal(tmpl.Mappings)
+		if err := json.Unmarshal(data, &mappings); err != nil {
+			t.Fatal(err)
+		}
+		if index.mapping != nil {
+			fields := mappings["properties"].(map[string]any)["fields"].(map[string]any)
+			fields["properties"].(map[string]any)["env"] = index.mapping
+		}
+		do(t, url, http.MethodPut, "/"+index.name, map[string]any{"settings": tmpl.Settings, "mappings": mappings})
+		t.Cleanup(func() { do(t, url, http.MethodDelete, "/"+index.name, nil) })
+
+		for i, env := range []string{"prod-eu", "Prod EU", "prod"} {
+			do(t, url, http.MethodPut, fmt.Sprintf("/%s/_doc/%d", index.name, i), map[string]any{
+				"message": "deployed",
+				"fields":  map[string]any{"env": env},
+			})
+		}
+		do(t, url, http.MethodPost, "/"+index.name+"/_refresh", nil)
+	}
+
+	types := map[string]string{"env": "string"}
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"env:prod-eu", 1},
+		{`env:"Prod EU"`, 1},
+		{"env:prod", 1},
+		{"env:prod*", 2},
+		{"env:*EU", 1},
+		{"env:[prod TO prod-eu]", 2},
+		{"NOT env:prod-eu", 2},
+	}
+	for _, tt := range tests {
+		expr, err := querylang.Parse(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if err := expr.Validate(types); err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		for _, index := range []string{dynamic, keyword} {
+			resp := do(t, url, http.MethodPost, "/"+index+"/_search", map[string]any{"query": expr.DSL()})
+			var result struct {
+				Hits struct {
+					Total struct {
+						Value int `json:"value"`
+					} `json:"total"`
+				} `json:"hits"`
+			}
+			if err := json.Unmarshal(resp, &result); err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Hits.Total.Value; got != tt.want {
+				t.Errorf("%s on %s: got %d hits, want %d", tt.query, index, got, tt.want)
+			}
+		}
+	}
+}
+
+func do(t *testing.T, url, method, path string, body any) []byte {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(url, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	if resp.StatusCode >= 300 {
+		t.Fatalf("%s %s: %s: %s", method, path, resp.Status, buf.String())
+	}
+	return buf.Bytes()
+}
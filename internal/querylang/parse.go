@@ -0,0 +1,289 @@
+// Package querylang parses the search query language, a subset of Lucene
+// query syntax:
+//
+//	timeout                         message words
+//	"connection reset"              a phrase in the message
+//	time*                           message words by wildcard
+//	service:api                     a field's value; fields.status or status
+//	service:api*                    by wildcard
+//	service:*                       any value
+//	fields.status:>=500             a comparison
+//	timestamp:[2026-01-01 TO *}     a range, inclusive [ ] or exclusive { }
+//	service:(api OR web)            several values of a field
+//	a AND b, a b, a && b            both
+//	a OR b, a || b                  either
+//	NOT a, -a, !a                   not
+//	(a OR b) AND c                  grouping
+//
+// A query is parsed into an Expr, validated against the tenant's fields,
+// then compiled to OpenSearch DSL or matched against events directly.
+package querylang
+
+import "fmt"
+
+// Error is a query that does not parse or validate. Pos and End delimit
+// the offending text, in characters from the start of the query.
+type Error struct {
+	Pos int
+	End int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+func errorAt(t token, format string, args ...any) *Error {
+	return &Error{Pos: t.pos, End: t.end, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses a query. An empty query returns nil.
+func Parse(input string) (*Expr, error) {
+	toks, err := lex([]rune(input))
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorAt(t, "unexpected %s", t)
+	}
+	return &Expr{Root: root}, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+
+	// field is the field of a group such as service:(api OR web).
+	field *token
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// parseOr reads clauses joined by OR.
+func (p *parser) parseOr() (Node, error) {
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{n}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+// parseAnd reads clauses joined by AND or nothing.
+func (p *parser) parseAnd() (Node, error) {
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{n}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokPhrase, tokLParen, tokNot:
+		default:
+			if len(nodes) == 1 {
+				return nodes[0], nil
+			}
+			return &And{Nodes: nodes}, nil
+		}
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.peek().kind != tokNot {
+		return p.parsePrimary()
+	}
+	p.next()
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &Not{Node: n}, nil
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokWord:
+		if p.peek().kind == tokColon {
+			return p.parseField(t)
+		}
+		return p.term(t), nil
+	case tokPhrase:
+		return p.term(t), nil
+	case tokEOF:
+		return nil, errorAt(t, "unexpected end of query")
+	}
+	return nil, errorAt(t, "unexpected %s", t)
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if t := p.peek(); t.kind != kind {
+		return errorAt(t, "expected %s, found %s", what, t)
+	}
+	p.next()
+	return nil
+}
+
+// parseField reads what follows field: a value, a comparison, a range or
+// a group of values.
+func (p *parser) parseField(field token) (Node, error) {
+	if p.field != nil {
+		return nil, errorAt(field, "a field group cannot hold other fields")
+	}
+	if field.wildcard {
+		return nil, errorAt(field, "field names cannot have wildcards")
+	}
+	p.next() // the colon
+
+	t := p.next()
+	switch t.kind {
+	case tokWord, tokPhrase:
+		p.field = &field
+		n := p.term(t)
+		p.field = nil
+		return n, nil
+
+	case tokCmp:
+		v := p.next()
+		if v.kind != tokWord && v.kind != tokPhrase {
+			return nil, errorAt(v, "expected a value after %s", t.text)
+		}
+		r := &Range{Field: field.text, fieldPos: field.pos, fieldEnd: field.end, pos: t.pos, end: v.end}
+		switch t.text {
+		case ">":
+			r.From = v.text
+		case ">=":
+			r.From, r.IncludeFrom = v.text, true
+		case "<":
+			r.To = v.text
+		case "<=":
+			r.To, r.IncludeTo = v.text, true
+		}
+		return r, nil
+
+	case tokLBracket, tokLBrace:
+		from, err := p.bound()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokTo, "TO"); err != nil {
+			return nil, err
+		}
+		to, err := p.bound()
+		if err != nil {
+			return nil, err
+		}
+		end := p.next()
+		if end.kind != tokRBracket && end.kind != tokRBrace {
+			return nil, errorAt(end, "expected ']' or '}', found %s", end)
+		}
+		return &Range{
+			Field:       field.text,
+			From:        from,
+			To:          to,
+			IncludeFrom: t.kind == tokLBracket && from != "",
+			IncludeTo:   end.kind == tokRBracket && to != "",
+			fieldPos:    field.pos,
+			fieldEnd:    field.end,
+			pos:         t.pos,
+			end:         end.end,
+		}, nil
+
+	case tokLParen:
+		p.field = &field
+		n, err := p.parseOr()
+		p.field = nil
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, errorAt(t, "expected a value after ':', found %s", t)
+}
+
+// bound reads a range bound; "" for *, an open end.
+func (p *parser) bound() (string, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokWord && t.pattern == "*":
+		return "", nil
+	case t.kind == tokWord && !t.wildcard, t.kind == tokPhrase:
+		return t.text, nil
+	}
+	return "", errorAt(t, "expected a range bound, found %s", t)
+}
+
+// term makes a word or phrase into a node, in the field of the enclosing
+// group if any.
+func (p *parser) term(t token) Node {
+	var field string
+	fieldPos, fieldEnd := t.pos, t.end
+	if p.field != nil {
+		field, fieldPos, fieldEnd = p.field.text, p.field.pos, p.field.end
+	}
+	if t.kind == tokWord && t.pattern == "*" {
+		if field == "" {
+			return &All{}
+		}
+		return &Exists{Field: field, fieldPos: fieldPos, fieldEnd: fieldEnd}
+	}
+	return &Term{
+		Field:    field,
+		Value:    t.text,
+		Pattern:  t.pattern,
+		Phrase:   t.kind == tokPhrase,
+		Wildcard: t.kind == tokWord && t.wildcard,
+		fieldPos: fieldPos,
+		fieldEnd: fieldEnd,
+		pos:      t.pos,
+		end:      t.end,
+	}
+}
@@ -0,0 +1,228 @@
+package querylang
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/fields"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+)
+
+type fieldType int
+
+const (
+	typeText fieldType = iota + 1
+	typeKeyword
+	typeNumber
+	typeBoolean
+	typeDate
+)
+
+func (t fieldType) String() string {
+	switch t {
+	case typeText:
+		return "text"
+	case typeNumber:
+		return "number"
+	case typeBoolean:
+		return "boolean"
+	case typeDate:
+		return "date"
+	}
+	return "keyword"
+}
+
+// field is a resolved field: its path in an event and its type. A
+// string under fields is dynamic: indexed as text with an exact keyword
+// subfield unless a template maps it as a keyword.
+type field struct {
+	path    string
+	typ     fieldType
+	dynamic bool
+}
+
+// eventFields are the fields every event has, by path.
+var eventFields = map[string]fieldType{
+	"id":          typeKeyword,
+	"timestamp":   typeDate,
+	"level":       typeKeyword,
+	"message":     typeText,
+	"service":     typeKeyword,
+	"host":        typeKeyword,
+	"trace_id":    typeKeyword,
+	"span_id":     typeKeyword,
+	"pattern_id":  typeKeyword,
+	"pattern":     typeKeyword,
+	"sample_rate": typeNumber,
+	"tags":        typeKeyword,
+}
+
+// Validate resolves the query's fields against the tenant's field types,
+// by name under fields as the field registry keeps them, and checks each
+// value against its field's type. A field under fields may be named
+// without the prefix: status for fields.status.
+func (x *Expr) Validate(types map[string]string) error {
+	return validate(x.Root, types)
+}
+
+func validate(n Node, types map[string]string) error {
+	switch n := n.(type) {
+	case *And:
+		for _, c := range n.Nodes {
+			if err := validate(c, types); err != nil {
+				return err
+			}
+		}
+	case *Or:
+		for _, c := range n.Nodes {
+			if err := validate(c, types); err != nil {
+				return err
+			}
+		}
+	case *Not:
+		return validate(n.Node, types)
+	case *Term:
+		return n.validate(types)
+	case *Range:
+		return n.validate(types)
+	case *Exists:
+		f, err := resolve(n.Field, n.fieldPos, n.fieldEnd, types)
+		n.field = f
+		return err
+	}
+	return nil
+}
+
+func resolve(name string, pos, end int, types map[string]string) (field, error) {
+	if name == "" {
+		return field{path: "message", typ: typeText}, nil
+	}
+	if t, ok := eventFields[name]; ok {
+		return field{path: name, typ: t}, nil
+	}
+	key, prefixed := strings.CutPrefix(name, "fields.")
+	t, ok := types[key]
+	if !ok {
+		return field{}, &Error{Pos: pos, End: end, Msg: "unknown field " + strconv.Quote(name)}
+	}
+	if !prefixed {
+		name = "fields." + name
+	}
+	switch t {
+	case fields.TypeNumber:
+		return field{path: name, typ: typeNumber}, nil
+	case fields.TypeBoolean:
+		return field{path: name, typ: typeBoolean}, nil
+	}
+	return field{path: name, typ: typeKeyword, dynamic: true}, nil
+}
+
+func (n *Term) validate(types map[string]string) error {
+	f, err := resolve(n.Field, n.fieldPos, n.fieldEnd, types)
+	if err != nil {
+		return err
+	}
+	n.field = f
+
+	fail := func(msg string) error {
+		return &Error{Pos: n.pos, End: n.end, Msg: msg}
+	}
+	if n.Wildcard && f.typ != typeText && f.typ != typeKeyword {
+		return fail("wildcards only apply to text and keyword fields; " + f.path + " is a " + f.typ.String() + " field")
+	}
+	switch f.typ {
+	case typeText:
+		if n.Wildcard {
+			n.glob = logstore.Glob(strings.ToLower(n.Pattern))
+		}
+	case typeKeyword:
+		if n.Wildcard {
+			n.glob = logstore.Glob(n.Pattern)
+		}
+	case typeNumber:
+		num, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return fail(f.path + " is a number field; " + strconv.Quote(n.Value) + " is not a number")
+		}
+		n.num = num
+	case typeBoolean:
+		b, err := strconv.ParseBool(strings.ToLower(n.Value))
+		if err != nil || n.Value == "1" || n.Value == "0" {
+			return fail(f.path + " is a boolean field; use true or false")
+		}
+		n.flag = b
+	case typeDate:
+		return fail(f.path + " is a date field; use a range such as " + f.path + ":[2026-01-01 TO 2026-01-02}")
+	}
+	return nil
+}
+
+func (n *Range) validate(types map[string]string) error {
+	f, err := resolve(n.Field, n.fieldPos, n.fieldEnd, types)
+	if err != nil {
+		return err
+	}
+	n.field = f
+
+	fail := func(msg string) error {
+		return &Error{Pos: n.pos, End: n.end, Msg: msg}
+	}
+	switch f.typ {
+	case typeText, typeBoolean:
+		return fail("ranges don't apply to " + f.typ.String() + " field " + f.path)
+	case typeNumber:
+		for _, b := range []struct {
+			s string
+			v *float64
+		}{{n.From, &n.numFrom}, {n.To, &n.numTo}} {
+			if b.s == "" {
+				continue
+			}
+			num, err := strconv.ParseFloat(b.s, 64)
+			if err != nil {
+				return fail(f.path + " is a number field; " + strconv.Quote(b.s) + " is not a number")
+			}
+			*b.v = num
+		}
+	case typeDate:
+		// A bound without a time of day covers the whole day.
+		if n.From != "" {
+			start, end, ok := parseTime(n.From)
+			if !ok {
+				return fail(badTime(n.From))
+			}
+			n.timeFrom = start
+			if !n.IncludeFrom {
+				n.timeFrom = end
+			}
+		}
+		if n.To != "" {
+			start, end, ok := parseTime(n.To)
+			if !ok {
+				return fail(badTime(n.To))
+			}
+			n.timeTo = end
+			if !n.IncludeTo {
+				n.timeTo = start
+			}
+		}
+	}
+	return nil
+}
+
+// parseTime reads an RFC 3339 time or a UTC day, returning the first and
+// last instant it covers.
+func parseTime(s string) (start, end time.Time, ok bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, t, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, t.Add(24*time.Hour - time.Nanosecond), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func badTime(s string) string {
+	return strconv.Quote(s) + " is not a time; use 2026-01-02 or 2026-01-02T15:04:05Z"
+}
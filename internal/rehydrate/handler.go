@@ -0,0 +1,173 @@
+package rehydrate
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const (
+	defaultTTLHours = 7 * 24
+	maxTTLHours     = 30 * 24
+	maxRange        = 31 * 24 * time.Hour
+)
+
+type Handler struct {
+	queries *queries.Queries
+	client  *opensearchapi.Client
+}
+
+func NewHandler(q *queries.Queries, client *opensearchapi.Client) *Handler {
+	return &Handler{queries: q, client: client}
+}
+
+// Create queues a job; lifecycled runs it.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := validate(&req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	j, err := h.queries.CreateRehydrationJob(r.Context(), info.ID, req.From.UTC(), req.To.UTC(), match, req.TTLHours)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to create rehydration job"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toJobResponse(j))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid job ID"))
+		return
+	}
+
+	j, err := h.queries.GetRehydrationJob(r.Context(), id, info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("rehydration job not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toJobResponse(j))
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	list, err := h.queries.ListRehydrationJobs(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list rehydration jobs"))
+		return
+	}
+
+	resp := make([]JobResponse, len(list))
+	for i, j := range list {
+		resp[i] = toJobResponse(j)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Delete cancels a pending or running job, whose runner then drops the
+// partial index, or drops the index of a completed job ahead of its expiry.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid job ID"))
+		return
+	}
+
+	j, err := h.queries.GetRehydrationJob(r.Context(), id, info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("rehydration job not found"))
+		return
+	}
+
+	switch j.Status {
+	case StatusPending, StatusRunning:
+		if _, err := h.queries.CancelRehydrationJob(r.Context(), id, info.ID); err != nil {
+			slog.Warn("rehydrate: cancel found no active job", "job_id", id, "error", err)
+		}
+	case StatusCompleted:
+		if err := osstore.DeleteIndex(r.Context(), h.client, IndexName(info.ID.String(), id)); err != nil {
+			apierror.Write(w, apierror.Internal("failed to delete rehydrated index"))
+			return
+		}
+		if err := h.queries.MarkRehydrationExpired(r.Context(), id); err != nil {
+			apierror.Write(w, apierror.Internal("failed to update rehydration job"))
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate checks a job request and fills in defaults. It returns an error
+// message, or "" if the request is valid.
+func validate(req *JobRequest) string {
+	if req.From.IsZero() || req.To.IsZero() {
+		return "from and to are required"
+	}
+	if !req.From.Before(req.To) {
+		return "from must be before to"
+	}
+	if req.To.Sub(req.From) > maxRange {
+		return "time range must be at most 31 days"
+	}
+	if req.TTLHours == 0 {
+		req.TTLHours = defaultTTLHours
+	}
+	if req.TTLHours < 1 || req.TTLHours > maxTTLHours {
+		return "ttl_hours must be between 1 and 720"
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package rehydrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Job statuses.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired" // index deleted after the job's TTL
+)
+
+// IndexName returns the index a job restores into. It is created in the
+// tenant's read alias, so restored events are searched with the live ones.
+func IndexName(tenantID string, jobID uuid.UUID) string {
+	return fmt.Sprintf("mintlog-%s-rehydrated-%s", tenantID, jobID)
+}
+
+type JobRequest struct {
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Match    rules.Match `json:"match"`
+	TTLHours int32       `json:"ttl_hours,omitempty"` // default 168 (7 days)
+}
+
+type JobResponse struct {
+	ID             uuid.UUID          `json:"id"`
+	Status         string             `json:"status"`
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	Match          rules.Match        `json:"match"`
+	TTLHours       int32              `json:"ttl_hours"`
+	Index          string             `json:"index"`
+	ObjectsTotal   int32              `json:"objects_total"`
+	ObjectsDone    int32              `json:"objects_done"`
+	EventsScanned  int64              `json:"events_scanned"`
+	EventsRestored int64              `json:"events_restored"`
+	Error          string             `json:"error,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	FinishedAt     pgtype.Timestamptz `json:"finished_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+}
+
+func toJobResponse(j queries.RehydrationJob) JobResponse {
+	resp := JobResponse{
+		ID:             j.ID,
+		Status:         j.Status,
+		From:           j.FromTime,
+		To:             j.ToTime,
+		TTLHours:       j.TtlHours,
+		Index:          IndexName(j.TenantID.String(), j.ID),
+		ObjectsTotal:   j.ObjectsTotal,
+		ObjectsDone:    j.ObjectsDone,
+		EventsScanned:  j.EventsScanned,
+		EventsRestored: j.EventsRestored,
+		Error:          j.Error,
+		CreatedAt:      j.CreatedAt,
+		StartedAt:      j.StartedAt,
+		FinishedAt:     j.FinishedAt,
+		ExpiresAt:      j.ExpiresAt,
+	}
+	json.Unmarshal(j.Match, &resp.Match)
+	return resp
+}
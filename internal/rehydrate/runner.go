@@ -0,0 +1,229 @@
+package rehydrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	"github.com/felipemonteiro/mintlog/internal/archive"
+	"github.com/felipemonteiro/mintlog/internal/pipeline"
+	"github.com/felipemonteiro/mintlog/internal/rules"
+	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const bulkSize = 1000
+
+// errCancelled stops a job cancelled through the API.
+var errCancelled = errors.New("cancelled")
+
+// Runner claims queued jobs and restores the archived events they select,
+// one job at a time. It also deletes the indices of jobs past their expiry.
+//
+// Events are indexed under their own ID, so a job resumed after a crash
+// starts over without duplicating anything.
+type Runner struct {
+	queries *queries.Queries
+	client  *opensearchapi.Client
+	reader  *archive.Reader
+	poll    time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRunner(q *queries.Queries, client *opensearchapi.Client, reader *archive.Reader, poll time.Duration) *Runner {
+	return &Runner{queries: q, client: client, reader: reader, poll: poll}
+}
+
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.poll)
+		defer ticker.Stop()
+		for {
+			r.Run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop interrupts a job in progress; it is picked up again once its claim
+// goes stale.
+func (r *Runner) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// Run expires old jobs, then runs queued jobs until none is left.
+func (r *Runner) Run(ctx context.Context) {
+	r.expire(ctx)
+	for ctx.Err() == nil {
+		job, err := r.queries.ClaimRehydrationJob(ctx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			slog.Error("rehydrate: failed to claim job", "error", err)
+			return
+		}
+		r.runJob(ctx, job)
+	}
+}
+
+func (r *Runner) runJob(ctx context.Context, job queries.RehydrationJob) {
+	index := IndexName(job.TenantID.String(), job.ID)
+	log := slog.With("job_id", job.ID, "tenant_id", job.TenantID, "index", index)
+	log.Info("rehydration started", "from", job.FromTime, "to", job.ToTime)
+
+	p, err := r.restore(ctx, job, index)
+	if ctx.Err() != nil {
+		return
+	}
+
+	status, errMsg := StatusCompleted, ""
+	var expiresAt pgtype.Timestamptz
+	switch {
+	case errors.Is(err, errCancelled):
+		log.Info("rehydration cancelled")
+		if err := osstore.DeleteIndex(ctx, r.client, index); err != nil {
+			log.Error("rehydrate: failed to delete index", "error", err)
+		}
+		return
+	case err != nil:
+		log.Error("rehydration failed", "error", err)
+		status, errMsg = StatusFailed, err.Error()
+		if err := osstore.DeleteIndex(ctx, r.client, index); err != nil {
+			log.Error("rehydrate: failed to delete index", "error", err)
+		}
+	default:
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(time.Duration(job.TtlHours) * time.Hour), Valid: true}
+		log.Info("rehydration completed", "objects", p.objectsDone, "scanned", p.scanned, "restored", p.restored)
+	}
+	if err := r.queries.FinishRehydrationJob(ctx, job.ID, status, errMsg, expiresAt); err != nil {
+		log.Error("rehydrate: failed to finish job", "error", err)
+	}
+}
+
+type progress struct {
+	objectsTotal, objectsDone int32
+	scanned, restored         int64
+}
+
+func (r *Runner) restore(ctx context.Context, job queries.RehydrationJob, index string) (progress, error) {
+	var p progress
+	var match rules.Match
+	if err := json.Unmarshal(job.Match, &match); err != nil {
+		return p, fmt.Errorf("invalid match: %w", err)
+	}
+
+	manifests, err := r.reader.Manifests(ctx, job.TenantID.String(), job.FromTime, job.ToTime)
+	if err != nil {
+		return p, fmt.Errorf("list archive: %w", err)
+	}
+	var selected []*archive.Manifest
+	for _, m := range manifests {
+		if m.MayMatch(&match) {
+			selected = append(selected, m)
+		}
+	}
+	p.objectsTotal = int32(len(selected))
+	if err := r.report(ctx, job, p); err != nil {
+		return p, err
+	}
+
+	if err := osstore.EnsureIndex(ctx, r.client, index, osstore.ReadAlias(job.TenantID.String())); err != nil {
+		return p, err
+	}
+
+	ids := make([]string, 0, bulkSize)
+	docs := make([]json.RawMessage, 0, bulkSize)
+	flush := func() error {
+		if len(docs) == 0 {
+			return nil
+		}
+		if err := osstore.BulkIndex(ctx, r.client, index, ids, docs); err != nil {
+			return err
+		}
+		p.restored += int64(len(docs))
+		ids, docs = ids[:0], docs[:0]
+		return nil
+	}
+
+	for _, m := range selected {
+		err := r.reader.Events(ctx, m, func(e *logmodel.LogEvent, line []byte) error {
+			p.scanned++
+			if e.Timestamp.Before(job.FromTime) || e.Timestamp.After(job.ToTime) || !pipeline.Matches(&match, e) {
+				return nil
+			}
+			ids = append(ids, e.ID)
+			docs = append(docs, append(json.RawMessage(nil), line...))
+			if len(docs) >= bulkSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			return p, err
+		}
+		p.objectsDone++
+		if err := r.report(ctx, job, p); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// report records progress, returning errCancelled if the job was cancelled.
+func (r *Runner) report(ctx context.Context, job queries.RehydrationJob, p progress) error {
+	status, err := r.queries.UpdateRehydrationProgress(ctx, job.ID, p.objectsTotal, p.objectsDone, p.scanned, p.restored)
+	if err != nil {
+		return fmt.Errorf("update progress: %w", err)
+	}
+	if status == StatusCancelled {
+		return errCancelled
+	}
+	return nil
+}
+
+// expire deletes the indices of completed jobs past their expiry.
+func (r *Runner) expire(ctx context.Context) {
+	jobs, err := r.queries.ListExpiredRehydrationJobs(ctx)
+	if err != nil {
+		slog.Error("rehydrate: failed to list expired jobs", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		index := IndexName(job.TenantID.String(), job.ID)
+		if err := osstore.DeleteIndex(ctx, r.client, index); err != nil {
+			slog.Error("rehydrate: failed to delete index", "index", index, "error", err)
+			continue
+		}
+		if err := r.queries.MarkRehydrationExpired(ctx, job.ID); err != nil {
+			slog.Error("rehydrate: failed to expire job", "job_id", job.ID, "error", err)
+			continue
+		}
+		slog.Info("rehydrated index expired", "job_id", job.ID, "index", index)
+	}
+}
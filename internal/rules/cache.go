@@ -0,0 +1,102 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+)
+
+// Cache keeps every tenant's active rules in memory, in evaluation order,
+// reloading them every interval.
+type Cache struct {
+	queries  *queries.Queries
+	interval time.Duration
+
+	mu       sync.RWMutex
+	byTenant map[string][]*Rule
+	cancel   context.CancelFunc
+}
+
+func NewCache(q *queries.Queries, interval time.Duration) *Cache {
+	return &Cache{
+		queries:  q,
+		interval: interval,
+		byTenant: make(map[string][]*Rule),
+	}
+}
+
+// Start loads all rules and keeps refreshing them until Stop is called.
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					slog.Error("rule cache: refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	slog.Info("pipeline rule cache started")
+	return nil
+}
+
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Rules returns the tenant's active rules in evaluation order.
+func (c *Cache) Rules(tenantID string) []*Rule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.byTenant[tenantID]
+}
+
+// Refresh reloads all active rules. Rules whose match cannot be decoded are
+// skipped.
+func (c *Cache) Refresh(ctx context.Context) error {
+	rows, err := c.queries.ListActivePipelineRules(ctx)
+	if err != nil {
+		return fmt.Errorf("list pipeline rules: %w", err)
+	}
+
+	byTenant := make(map[string][]*Rule)
+	for _, row := range rows {
+		var m Match
+		if err := json.Unmarshal(row.Match, &m); err != nil {
+			slog.Error("rule cache: invalid match", "rule_id", row.ID, "error", err)
+			continue
+		}
+		tenantID := row.TenantID.String()
+		byTenant[tenantID] = append(byTenant[tenantID], &Rule{
+			ID:         row.ID,
+			Name:       row.Name,
+			Action:     row.Action,
+			Match:      m,
+			SampleRate: row.SampleRate,
+			HashField:  row.HashField,
+		})
+	}
+
+	c.mu.Lock()
+	c.byTenant = byTenant
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,205 @@
+package rules
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/storage/postgres/queries"
+	"github.com/felipemonteiro/mintlog/internal/tenant"
+	"github.com/felipemonteiro/mintlog/pkg/apierror"
+)
+
+const defaultHashField = "trace_id"
+
+type Handler struct {
+	queries *queries.Queries
+}
+
+func NewHandler(q *queries.Queries) *Handler {
+	return &Handler{queries: q}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := validate(&req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	rule, err := h.queries.CreatePipelineRule(r.Context(), info.ID, req.Name, req.Action, match, req.SampleRate, req.HashField, req.Priority)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to create pipeline rule"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toRuleResponse(rule))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid rule ID"))
+		return
+	}
+
+	rule, err := h.queries.GetPipelineRule(r.Context(), id, info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("pipeline rule not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRuleResponse(rule))
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	rules, err := h.queries.ListPipelineRules(r.Context(), info.ID)
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to list pipeline rules"))
+		return
+	}
+
+	resp := make([]RuleResponse, len(rules))
+	for i, rule := range rules {
+		resp[i] = toRuleResponse(rule)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid rule ID"))
+		return
+	}
+
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+	if msg := validate(&req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	match, err := json.Marshal(req.Match)
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid match"))
+		return
+	}
+
+	rule, err := h.queries.UpdatePipelineRule(r.Context(), id, info.ID, req.Name, req.Action, match, req.SampleRate, req.HashField, req.Priority, isActive)
+	if err != nil {
+		apierror.Write(w, apierror.NotFound("pipeline rule not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRuleResponse(rule))
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid rule ID"))
+		return
+	}
+
+	if err := h.queries.DeletePipelineRule(r.Context(), id, info.ID); err != nil {
+		apierror.Write(w, apierror.Internal("failed to delete pipeline rule"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate checks a rule request and fills in defaults. It returns an error
+// message, or "" if the request is valid.
+func validate(req *RuleRequest) string {
+	if req.Name == "" {
+		return "name is required"
+	}
+	switch req.Action {
+	case ActionDrop:
+		req.SampleRate = 0
+	case ActionSample:
+		if req.SampleRate <= 0 || req.SampleRate >= 1 {
+			return "sample_rate must be between 0 and 1 (exclusive)"
+		}
+	default:
+		return "action must be one of: drop, sample"
+	}
+	if req.HashField == "" {
+		req.HashField = defaultHashField
+	}
+	return ""
+}
+
+func toRuleResponse(r queries.PipelineRule) RuleResponse {
+	resp := RuleResponse{
+		ID:         r.ID,
+		Name:       r.Name,
+		Action:     r.Action,
+		SampleRate: r.SampleRate,
+		HashField:  r.HashField,
+		Priority:   r.Priority,
+		IsActive:   r.IsActive,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+	json.Unmarshal(r.Match, &resp.Match)
+	return resp
+}
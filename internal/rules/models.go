@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule actions.
+const (
+	ActionDrop   = "drop"
+	ActionSample = "sample"
+)
+
+// Match selects the events a rule applies to. All set conditions must hold.
+type Match struct {
+	Level   string            `json:"level,omitempty"`
+	Service string            `json:"service,omitempty"`
+	Host    string            `json:"host,omitempty"`
+	Message string            `json:"message,omitempty"` // substring
+	Fields  map[string]string `json:"fields,omitempty"`  // field reference ("fields.env") -> exact value
+}
+
+// Rule is an active rule as applied by the pipeline.
+type Rule struct {
+	ID         uuid.UUID
+	Name       string
+	Action     string
+	Match      Match
+	SampleRate float64
+	HashField  string
+}
+
+type RuleRequest struct {
+	Name       string  `json:"name"`
+	Action     string  `json:"action"` // "drop" or "sample"
+	Match      Match   `json:"match"`
+	SampleRate float64 `json:"sample_rate,omitempty"` // fraction kept, 0 < rate < 1
+	HashField  string  `json:"hash_field,omitempty"`  // defaults to "trace_id"
+	Priority   int32   `json:"priority,omitempty"`    // lower runs first
+	IsActive   *bool   `json:"is_active,omitempty"`
+}
+
+type RuleResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Action     string    `json:"action"`
+	Match      Match     `json:"match"`
+	SampleRate float64   `json:"sample_rate"`
+	HashField  string    `json:"hash_field"`
+	Priority   int32     `json:"priority"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
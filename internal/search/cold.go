@@ -0,0 +1,268 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felipemonteiro/mintlog/internal/archive"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/querylang"
+	redisstore "github.com/felipemonteiro/mintlog/internal/storage/redis"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Cold search statuses.
+const (
+	ColdRunning   = "running"
+	ColdCompleted = "completed"
+	ColdFailed    = "failed"
+	ColdCancelled = "cancelled"
+)
+
+const (
+	defaultColdSize = 1000
+	maxColdSize     = 10000
+	maxColdRange    = 31 * 24 * time.Hour
+	coldTimeout     = 30 * time.Minute
+
+	// maxColdSearches bounds the cold searches one API process runs at once.
+	maxColdSearches = 4
+)
+
+var errColdCancelled = errors.New("cancelled")
+
+// ColdSearcher runs searches over archive objects in the background. Only
+// objects whose hour, timestamps and manifest filters allow a match are
+// read. Hits are appended to a result store as each object is scanned, so
+// clients page through them while the search runs.
+type ColdSearcher struct {
+	reader  *archive.Reader
+	results *redisstore.ResultStore
+	slots   chan struct{}
+}
+
+func NewColdSearcher(reader *archive.Reader, results *redisstore.ResultStore) *ColdSearcher {
+	return &ColdSearcher{reader: reader, results: results, slots: make(chan struct{}, maxColdSearches)}
+}
+
+// errColdBusy is returned by Start when every slot is in use.
+var errColdBusy = errors.New("too many cold searches running")
+
+// Start starts searching the tenant's archive for the events query selects.
+func (c *ColdSearcher) Start(ctx context.Context, tenantID string, req *SearchRequest, query *logstore.Query) (*ColdSearch, error) {
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		return nil, errColdBusy
+	}
+
+	state := &ColdSearch{
+		ID:        uuid.New(),
+		Status:    ColdRunning,
+		From:      req.From.UTC(),
+		To:        req.To.UTC(),
+		Size:      req.Size,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := c.results.SetState(ctx, coldKey(tenantID, state.ID), state); err != nil {
+		<-c.slots
+		return nil, err
+	}
+
+	go func() {
+		defer func() { <-c.slots }()
+		ctx, cancel := context.WithTimeout(context.Background(), coldTimeout)
+		defer cancel()
+		c.run(ctx, tenantID, req, query, state)
+	}()
+	return state, nil
+}
+
+// Get returns a search's state and up to limit hits from offset.
+func (c *ColdSearcher) Get(ctx context.Context, tenantID string, id uuid.UUID, offset, limit int) (*ColdSearchResponse, error) {
+	key := coldKey(tenantID, id)
+	var resp ColdSearchResponse
+	if err := c.results.GetState(ctx, key, &resp.ColdSearch); err != nil {
+		return nil, err
+	}
+	hits, err := c.results.Results(ctx, key, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp.Results = hits
+	resp.Next = offset + len(hits)
+	return &resp, nil
+}
+
+// Cancel stops a running search after the object it is scanning. Hits found
+// so far are kept.
+func (c *ColdSearcher) Cancel(ctx context.Context, tenantID string, id uuid.UUID) error {
+	key := coldKey(tenantID, id)
+	var state ColdSearch
+	if err := c.results.GetState(ctx, key, &state); err != nil {
+		return err
+	}
+	return c.results.Cancel(ctx, key)
+}
+
+func (c *ColdSearcher) run(ctx context.Context, tenantID string, req *SearchRequest, query *logstore.Query, state *ColdSearch) {
+	key := coldKey(tenantID, state.ID)
+	log := slog.With("tenant_id", tenantID, "search_id", state.ID)
+
+	err := c.scan(ctx, tenantID, newColdFilter(query), req.Sort == "asc", state)
+	switch {
+	case errors.Is(err, errColdCancelled):
+		state.Status = ColdCancelled
+	case err != nil:
+		log.Error("cold search failed", "error", err)
+		state.Status, state.Error = ColdFailed, err.Error()
+	default:
+		state.Status = ColdCompleted
+	}
+	now := time.Now().UTC()
+	state.FinishedAt = &now
+	if err := c.results.SetState(context.Background(), key, state); err != nil {
+		log.Error("cold search: failed to store state", "error", err)
+	}
+	log.Info("cold search finished", "status", state.Status, "objects", state.ObjectsScanned, "hits", state.Hits)
+}
+
+func (c *ColdSearcher) scan(ctx context.Context, tenantID string, f *coldFilter, asc bool, state *ColdSearch) error {
+	key := coldKey(tenantID, state.ID)
+	manifests, err := c.reader.Manifests(ctx, tenantID, state.From, state.To)
+	if err != nil {
+		return fmt.Errorf("list archive: %w", err)
+	}
+	if !asc {
+		slices.Reverse(manifests)
+	}
+	state.ObjectsTotal = len(manifests)
+
+	for _, m := range manifests {
+		if cancelled, err := c.results.Cancelled(ctx, key); err != nil {
+			return err
+		} else if cancelled {
+			return errColdCancelled
+		}
+		if !m.MayContain(f.manifest) {
+			state.ObjectsPruned++
+			continue
+		}
+
+		var hits []coldHit
+		err := c.reader.Events(ctx, m, func(e *logmodel.LogEvent, line []byte) error {
+			state.EventsScanned++
+			if f.matches(e) {
+				hits = append(hits, coldHit{ts: e.Timestamp, id: e.ID, doc: append(json.RawMessage(nil), line...)})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		state.ObjectsScanned++
+
+		sort.Slice(hits, func(i, j int) bool {
+			if !hits[i].ts.Equal(hits[j].ts) {
+				return hits[i].ts.Before(hits[j].ts) == asc
+			}
+			return (hits[i].id < hits[j].id) == asc
+		})
+		if room := state.Size - state.Hits; len(hits) >= room {
+			hits, state.Truncated = hits[:room], true
+		}
+		docs := make([]json.RawMessage, len(hits))
+		for i, h := range hits {
+			docs[i] = h.doc
+		}
+		if err := c.results.Append(ctx, key, docs); err != nil {
+			return err
+		}
+		state.Hits += len(docs)
+		if err := c.results.SetState(ctx, key, state); err != nil {
+			return err
+		}
+		if state.Truncated {
+			return nil
+		}
+	}
+	return nil
+}
+
+type coldHit struct {
+	ts  time.Time
+	id  string
+	doc json.RawMessage
+}
+
+// coldFilter matches archived events with the query a hot search runs,
+// plus the manifest filter that prunes objects.
+type coldFilter struct {
+	query    *logstore.Query
+	manifest archive.Filter
+}
+
+func newColdFilter(q *logstore.Query) *coldFilter {
+	// A message matching by words, phrase or exactly contains every word
+	// of the query; the other modes can't prune on words. A query in the
+	// query language prunes on the words it requires.
+	var terms []string
+	switch {
+	case q.Expr != nil:
+		if expr, ok := q.Expr.(*querylang.Expr); ok {
+			terms = expr.Terms()
+		}
+	case q.Match == logstore.MatchWords, q.Match == logstore.MatchPhrase, q.Match == logstore.MatchExact:
+		terms = archive.Terms(q.Text)
+	}
+	return &coldFilter{
+		query: q,
+		manifest: archive.Filter{
+			Level:    q.Level,
+			Service:  q.Service,
+			Host:     q.Host,
+			TraceIDs: q.TraceIDs,
+			Terms:    terms,
+		},
+	}
+}
+
+func (f *coldFilter) matches(e *logmodel.LogEvent) bool {
+	return f.query.Matches(e)
+}
+
+// validateCold checks a cold search request and fills in defaults. It
+// returns an error message, or "" if the request is valid.
+func validateCold(req *SearchRequest) string {
+	if req.From.IsZero() || req.To.IsZero() {
+		return "from and to are required for cold search"
+	}
+	if !req.From.Before(req.To) {
+		return "from must be before to"
+	}
+	if req.To.Sub(req.From) > maxColdRange {
+		return "cold search range must be at most 31 days"
+	}
+	if len(req.SearchAfter) > 0 {
+		return "search_after is not supported for cold search"
+	}
+	if req.Size <= 0 {
+		req.Size = defaultColdSize
+	}
+	if req.Size > maxColdSize {
+		return "size must be at most 10000 for cold search"
+	}
+	return ""
+}
+
+func coldKey(tenantID string, id uuid.UUID) string {
+	return tenantID + ":" + id.String()
+}
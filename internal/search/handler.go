@@ -2,22 +2,71 @@ package search
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
-	osstore "github.com/felipemonteiro/mintlog/internal/storage/opensearch"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/felipemonteiro/mintlog/internal/fields"
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/querylang"
 	"github.com/felipemonteiro/mintlog/internal/tenant"
 	"github.com/felipemonteiro/mintlog/pkg/apierror"
 )
 
+const (
+	defaultColdPage = 100
+	maxColdPage     = 1000
+)
+
 type Handler struct {
-	searcher *osstore.Searcher
+	store  logstore.LogStore
+	cold   *ColdSearcher
+	fields *fields.Registry
+}
+
+func NewHandler(store logstore.LogStore, cold *ColdSearcher, registry *fields.Registry) *Handler {
+	return &Handler{store: store, cold: cold, fields: registry}
+}
+
+// fieldTypes returns the tenant's field types for validating a query in
+// the query language, or nil for plain text. It writes an error response
+// and returns false if they can't be loaded.
+func (h *Handler) fieldTypes(w http.ResponseWriter, r *http.Request, tenantID, syntax string) (map[string]string, bool) {
+	if syntax != SyntaxLucene {
+		return nil, true
+	}
+	types, err := h.fields.Types(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("failed to load field types", "error", err)
+		apierror.Write(w, apierror.Internal("failed to load field types"))
+		return nil, false
+	}
+	return types, true
 }
 
-func NewHandler(searcher *osstore.Searcher) *Handler {
-	return &Handler{searcher: searcher}
+// writeQueryError writes a request that failed to build, with the position
+// of the fault for a query that does not parse or validate.
+func writeQueryError(w http.ResponseWriter, err error) {
+	var qerr *querylang.Error
+	if !errors.As(err, &qerr) {
+		apierror.Write(w, apierror.BadRequest(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(QueryErrorResponse{
+		Code:     http.StatusBadRequest,
+		Message:  qerr.Error(),
+		Position: qerr.Pos,
+		End:      qerr.End,
+	})
 }
 
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
@@ -33,10 +82,31 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := BuildSearchQuery(info.ID.String(), &req)
-	indices := []string{fmt.Sprintf("mintlog-%s-*", info.ID.String())}
+	types, ok := h.fieldTypes(w, r, info.ID.String(), req.Syntax)
+	if !ok {
+		return
+	}
+	query, err := BuildSearchQuery(info.ID.String(), &req, types)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	switch req.Mode {
+	case "", "hot":
+	case "cold":
+		h.startCold(w, r, info.ID.String(), &req, query)
+		return
+	default:
+		apierror.Write(w, apierror.BadRequest("mode must be hot or cold"))
+		return
+	}
 
-	result, err := h.searcher.Search(r.Context(), indices, query)
+	result, err := h.store.Search(r.Context(), query, BuildSearchOptions(&req))
+	if errors.Is(err, logstore.ErrInvalidCursor) {
+		apierror.Write(w, apierror.BadRequest(err.Error()))
+		return
+	}
 	if err != nil {
 		slog.Error("search failed", "error", err)
 		apierror.Write(w, apierror.Internal("search failed"))
@@ -44,14 +114,110 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := SearchResponse{
-		Hits:  result.Hits,
-		Total: result.Total,
+		Hits:        result.Hits,
+		Total:       result.Total,
+		SearchAfter: result.Cursor,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// startCold starts a cold search and returns its state. Results are read
+// with ColdResults as they are found.
+func (h *Handler) startCold(w http.ResponseWriter, r *http.Request, tenantID string, req *SearchRequest, query *logstore.Query) {
+	if msg := validateCold(req); msg != "" {
+		apierror.Write(w, apierror.BadRequest(msg))
+		return
+	}
+
+	state, err := h.cold.Start(r.Context(), tenantID, req, query)
+	if errors.Is(err, errColdBusy) {
+		apierror.Write(w, apierror.TooManyRequests(err.Error()))
+		return
+	}
+	if err != nil {
+		slog.Error("cold search failed to start", "error", err)
+		apierror.Write(w, apierror.Internal("failed to start cold search"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(state)
+}
+
+// ColdResults returns a cold search's progress and a page of its results,
+// from ?offset= (default 0), at most ?limit= (default 100).
+func (h *Handler) ColdResults(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid search ID"))
+		return
+	}
+	offset, limit := 0, defaultColdPage
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			apierror.Write(w, apierror.BadRequest("invalid offset"))
+			return
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxColdPage {
+			apierror.Write(w, apierror.BadRequest("limit must be between 1 and 1000"))
+			return
+		}
+	}
+
+	resp, err := h.cold.Get(r.Context(), info.ID.String(), id, offset, limit)
+	if errors.Is(err, redis.Nil) {
+		apierror.Write(w, apierror.NotFound("cold search not found"))
+		return
+	}
+	if err != nil {
+		slog.Error("cold search lookup failed", "error", err)
+		apierror.Write(w, apierror.Internal("failed to get cold search"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CancelCold stops a running cold search. Results found so far remain
+// readable until they expire.
+func (h *Handler) CancelCold(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid search ID"))
+		return
+	}
+
+	err = h.cold.Cancel(r.Context(), info.ID.String(), id)
+	if errors.Is(err, redis.Nil) {
+		apierror.Write(w, apierror.NotFound("cold search not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, apierror.Internal("failed to cancel cold search"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) Tail(w http.ResponseWriter, r *http.Request) {
 	info := tenant.FromContext(r.Context())
 	if info == nil {
@@ -65,6 +231,16 @@ func (h *Handler) Tail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	types, ok := h.fieldTypes(w, r, info.ID.String(), req.Syntax)
+	if !ok {
+		return
+	}
+	query, err := BuildTailQuery(info.ID.String(), &req, types)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		apierror.Write(w, apierror.Internal("streaming not supported"))
@@ -75,28 +251,15 @@ func (h *Handler) Tail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	since := time.Now().UTC().Add(-10 * time.Second)
-	indices := []string{fmt.Sprintf("mintlog-%s-*", info.ID.String())}
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case <-ticker.C:
-			query := BuildTailQuery(info.ID.String(), &req, since)
-			result, err := h.searcher.Search(r.Context(), indices, query)
-			if err != nil {
-				slog.Error("tail search failed", "error", err)
-				continue
-			}
-			for _, hit := range result.Hits {
-				fmt.Fprintf(w, "data: %s\n\n", hit)
-				flusher.Flush()
-			}
-			since = time.Now().UTC()
+	err = h.store.Tail(r.Context(), query, func(hit json.RawMessage) error {
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", hit); err != nil {
+			return err
 		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		slog.Error("tail failed", "error", err)
 	}
 }
 
@@ -113,18 +276,121 @@ func (h *Handler) Aggregate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := BuildAggregateQuery(info.ID.String(), &req)
-	indices := []string{fmt.Sprintf("mintlog-%s-*", info.ID.String())}
+	types, ok := h.fieldTypes(w, r, info.ID.String(), req.Syntax)
+	if !ok {
+		return
+	}
+	query, agg, err := BuildAggregateQuery(info.ID.String(), &req, types)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
 
-	aggs, err := h.searcher.Aggregate(r.Context(), indices, query)
+	result, err := h.store.Aggregate(r.Context(), query, agg)
 	if err != nil {
 		slog.Error("aggregate failed", "error", err)
 		apierror.Write(w, apierror.Internal("aggregation failed"))
 		return
 	}
 
-	resp := AggregateResponse{Buckets: aggs}
+	resp := AggregateResponse{Buckets: aggregateBuckets(result)}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+// aggregateBuckets renders an aggregation in the shape OpenSearch returns
+// it, which the API has always used.
+func aggregateBuckets(result *logstore.AggregateResult) AggregateBuckets {
+	var b AggregateBuckets
+	if result.EstimatedTotal != nil {
+		b.EstimatedTotal = &AggregateValue{Value: result.EstimatedTotal}
+	}
+	if result.Groups != nil {
+		b.GroupBy = &AggregateBucketList{Buckets: make([]AggregateBucket, 0, len(result.Groups))}
+		for _, g := range result.Groups {
+			b.GroupBy.Buckets = append(b.GroupBy.Buckets, AggregateBucket{
+				Key:            g.Key,
+				DocCount:       g.Count,
+				EstimatedCount: estimatedValue(g.EstimatedCount),
+			})
+		}
+	}
+	if result.OverTime != nil {
+		b.OverTime = &AggregateBucketList{Buckets: make([]AggregateBucket, 0, len(result.OverTime))}
+		for _, t := range result.OverTime {
+			b.OverTime.Buckets = append(b.OverTime.Buckets, AggregateBucket{
+				Key:            t.Time.UnixMilli(),
+				KeyAsString:    t.Time.Format(time.RFC3339Nano),
+				DocCount:       t.Count,
+				EstimatedCount: estimatedValue(t.EstimatedCount),
+			})
+		}
+	}
+	return b
+}
+
+func estimatedValue(v *float64) *AggregateValue {
+	if v == nil {
+		return nil
+	}
+	return &AggregateValue{Value: v}
+}
+
+func (h *Handler) Patterns(w http.ResponseWriter, r *http.Request) {
+	info := tenant.FromContext(r.Context())
+	if info == nil {
+		apierror.Write(w, apierror.Unauthorized("not authenticated"))
+		return
+	}
+
+	var req PatternsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, apierror.BadRequest("invalid JSON: "+err.Error()))
+		return
+	}
+
+	types, ok := h.fieldTypes(w, r, info.ID.String(), req.Syntax)
+	if !ok {
+		return
+	}
+	query, agg, err := BuildPatternsQuery(info.ID.String(), &req, types)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	result, err := h.store.Aggregate(r.Context(), query, agg)
+	if err != nil {
+		slog.Error("patterns aggregate failed", "error", err)
+		apierror.Write(w, apierror.Internal("aggregation failed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patternsResponse(result))
+}
+
+func patternsResponse(result *logstore.AggregateResult) *PatternsResponse {
+	resp := &PatternsResponse{
+		Patterns: make([]Pattern, 0, len(result.Groups)),
+		Total:    result.Total,
+	}
+	for _, g := range result.Groups {
+		p := Pattern{
+			PatternID: g.Key,
+			Count:     g.Count,
+			FirstSeen: g.FirstSeen,
+			LastSeen:  g.LastSeen,
+		}
+		var sample struct {
+			Pattern string `json:"pattern"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(g.Sample, &sample) == nil {
+			p.Template = sample.Pattern
+			p.Sample = sample.Message
+		}
+		resp.Patterns = append(resp.Patterns, p)
+	}
+	return resp
+}
@@ -3,10 +3,14 @@ package search
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type SearchRequest struct {
 	Query     string         `json:"query"`
+	Match     string         `json:"match,omitempty"` // how query matches the message: words (default), phrase, exact, prefix, wildcard or regex
+	Syntax    string         `json:"syntax,omitempty"` // "lucene" for the query language instead of plain text
 	Level     string         `json:"level,omitempty"`
 	Service   string         `json:"service,omitempty"`
 	Host      string         `json:"host,omitempty"`
@@ -16,6 +20,16 @@ type SearchRequest struct {
 	Size      int            `json:"size,omitempty"`
 	SearchAfter []any        `json:"search_after,omitempty"`
 	Sort      string         `json:"sort,omitempty"` // "asc" or "desc"
+	Mode      string         `json:"mode,omitempty"` // "hot" (default, OpenSearch) or "cold" (archive)
+}
+
+// QueryErrorResponse is a query that does not parse or validate, with
+// the characters of the query at fault from position to end.
+type QueryErrorResponse struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	Position int    `json:"position"`
+	End      int    `json:"end"`
 }
 
 type SearchResponse struct {
@@ -24,22 +38,100 @@ type SearchResponse struct {
 	SearchAfter []any             `json:"search_after,omitempty"`
 }
 
+// ColdSearch is the state of a cold search.
+type ColdSearch struct {
+	ID             uuid.UUID  `json:"id"`
+	Status         string     `json:"status"` // running, completed, failed or cancelled
+	From           time.Time  `json:"from"`
+	To             time.Time  `json:"to"`
+	Size           int        `json:"size"`
+	ObjectsTotal   int        `json:"objects_total"`
+	ObjectsScanned int        `json:"objects_scanned"`
+	ObjectsPruned  int        `json:"objects_pruned"` // skipped on their manifest
+	EventsScanned  int64      `json:"events_scanned"`
+	Hits           int        `json:"hits"`
+	Truncated      bool       `json:"truncated"` // stopped after size hits
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+type ColdSearchResponse struct {
+	ColdSearch
+	Results []json.RawMessage `json:"results"`
+	Next    int               `json:"next"` // offset of the next page
+}
+
 type TailRequest struct {
 	Query   string `json:"query,omitempty"`
+	Match   string `json:"match,omitempty"`
+	Syntax  string `json:"syntax,omitempty"`
 	Level   string `json:"level,omitempty"`
 	Service string `json:"service,omitempty"`
 }
 
 type AggregateRequest struct {
 	Query    string `json:"query,omitempty"`
+	Match    string `json:"match,omitempty"`
+	Syntax   string `json:"syntax,omitempty"`
 	Level    string `json:"level,omitempty"`
 	Service  string `json:"service,omitempty"`
 	From     time.Time `json:"from,omitempty"`
 	To       time.Time `json:"to,omitempty"`
-	GroupBy  string    `json:"group_by,omitempty"`  // "level", "service", "host"
+	GroupBy  string    `json:"group_by,omitempty"`  // "level", "service", "host", "fields.ua.browser", ...
 	Interval string    `json:"interval,omitempty"`  // "1m", "5m", "1h", "1d"
+	Weighted bool      `json:"weighted,omitempty"`  // add estimated_count (sum of 1/sample_rate) to every bucket
 }
 
 type AggregateResponse struct {
-	Buckets json.RawMessage `json:"buckets"`
+	Buckets AggregateBuckets `json:"buckets"`
+}
+
+// AggregateBuckets has the shape of an OpenSearch aggregation response.
+type AggregateBuckets struct {
+	GroupBy        *AggregateBucketList `json:"group_by,omitempty"`
+	OverTime       *AggregateBucketList `json:"over_time,omitempty"`
+	EstimatedTotal *AggregateValue      `json:"estimated_total,omitempty"`
+}
+
+type AggregateBucketList struct {
+	Buckets []AggregateBucket `json:"buckets"`
+}
+
+type AggregateBucket struct {
+	Key            any             `json:"key"` // group value, or bucket start in epoch milliseconds
+	KeyAsString    string          `json:"key_as_string,omitempty"`
+	DocCount       int64           `json:"doc_count"`
+	EstimatedCount *AggregateValue `json:"estimated_count,omitempty"`
+}
+
+type AggregateValue struct {
+	Value *float64 `json:"value"`
+}
+
+type PatternsRequest struct {
+	Query   string    `json:"query,omitempty"`
+	Match   string    `json:"match,omitempty"`
+	Syntax  string    `json:"syntax,omitempty"`
+	Level   string    `json:"level,omitempty"`
+	Service string    `json:"service,omitempty"`
+	Host    string    `json:"host,omitempty"`
+	TraceID string    `json:"trace_id,omitempty"`
+	From    time.Time `json:"from,omitempty"`
+	To      time.Time `json:"to,omitempty"`
+	Size    int       `json:"size,omitempty"` // number of patterns, default 50
+}
+
+type Pattern struct {
+	PatternID string    `json:"pattern_id"`
+	Template  string    `json:"template"`
+	Count     int64     `json:"count"`
+	Sample    string    `json:"sample,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type PatternsResponse struct {
+	Patterns []Pattern `json:"patterns"`
+	Total    int64     `json:"total"` // matching events, including those without a pattern
 }
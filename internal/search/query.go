@@ -1,179 +1,174 @@
 package search
 
-import "time"
-
-// BuildSearchQuery constructs an OpenSearch DSL query from a SearchRequest.
-func BuildSearchQuery(tenantID string, req *SearchRequest) map[string]any {
-	must := []map[string]any{
-		{"term": map[string]any{"tenant_id": tenantID}},
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/internal/querylang"
+	"github.com/felipemonteiro/mintlog/internal/tracecontext"
+)
+
+// SyntaxLucene selects the query language for a request's query.
+const SyntaxLucene = "lucene"
+
+// setQuery sets what the query string of a request selects. With syntax
+// lucene it is in the query language, validated against the tenant's
+// field types; otherwise it is plain text matching the message by match.
+func setQuery(q *logstore.Query, query, match, syntax string, types map[string]string) error {
+	switch syntax {
+	case "":
+	case SyntaxLucene:
+		if match != "" {
+			return errors.New("match does not apply to lucene syntax")
+		}
+		expr, err := querylang.Parse(query)
+		if err != nil || expr == nil {
+			return err
+		}
+		if err := expr.Validate(types); err != nil {
+			return err
+		}
+		q.Expr = expr
+		return nil
+	default:
+		return errors.New("syntax must be lucene or unset")
 	}
 
-	if req.Query != "" {
-		must = append(must, map[string]any{
-			"match": map[string]any{
-				"message": map[string]any{
-					"query":    req.Query,
-					"operator": "and",
-				},
-			},
-		})
+	q.Text = query
+	if match != "words" {
+		q.Match = logstore.Match(match)
 	}
+	return q.Compile()
+}
 
-	if req.Level != "" {
-		must = append(must, map[string]any{"term": map[string]any{"level": req.Level}})
-	}
-	if req.Service != "" {
-		must = append(must, map[string]any{"term": map[string]any{"service": req.Service}})
-	}
-	if req.Host != "" {
-		must = append(must, map[string]any{"term": map[string]any{"host": req.Host}})
+// BuildSearchQuery selects the events a SearchRequest asks for. By
+// default the query matches events whose message contains every word of
+// it; types are the tenant's field types by name, for lucene syntax.
+func BuildSearchQuery(tenantID string, req *SearchRequest, types map[string]string) (*logstore.Query, error) {
+	q := &logstore.Query{
+		TenantID: tenantID,
+		Level:    req.Level,
+		Service:  req.Service,
+		Host:     req.Host,
+		From:     req.From,
+		To:       req.To,
 	}
 	if req.TraceID != "" {
-		must = append(must, map[string]any{"term": map[string]any{"trace_id": req.TraceID}})
-	}
-
-	// Time range filter
-	timeRange := map[string]any{}
-	if !req.From.IsZero() {
-		timeRange["gte"] = req.From.Format(time.RFC3339Nano)
-	}
-	if !req.To.IsZero() {
-		timeRange["lte"] = req.To.Format(time.RFC3339Nano)
+		// Match both the ID as given and its canonical form, so any
+		// propagation format finds events stored before normalization too.
+		q.TraceIDs = []string{req.TraceID}
+		if id, ok := tracecontext.Normalize(req.TraceID); ok && id != req.TraceID {
+			q.TraceIDs = append(q.TraceIDs, id)
+		}
 	}
-	if len(timeRange) > 0 {
-		must = append(must, map[string]any{
-			"range": map[string]any{"timestamp": timeRange},
-		})
+	if err := setQuery(q, req.Query, req.Match, req.Syntax, types); err != nil {
+		return nil, err
 	}
+	return q, nil
+}
 
+// BuildSearchOptions pages a search: 50 events by default and at most
+// 1000, newest first unless sort is "asc".
+func BuildSearchOptions(req *SearchRequest) logstore.SearchOptions {
 	size := req.Size
 	if size <= 0 || size > 1000 {
 		size = 50
 	}
-
-	sortOrder := "desc"
-	if req.Sort == "asc" {
-		sortOrder = "asc"
-	}
-
-	query := map[string]any{
-		"query": map[string]any{
-			"bool": map[string]any{
-				"must": must,
-			},
-		},
-		"size": size,
-		"sort": []map[string]any{
-			{"timestamp": map[string]any{"order": sortOrder}},
-			{"id": map[string]any{"order": sortOrder}},
-		},
-	}
-
-	if len(req.SearchAfter) > 0 {
-		query["search_after"] = req.SearchAfter
+	return logstore.SearchOptions{
+		Size:        size,
+		Asc:         req.Sort == "asc",
+		SearchAfter: req.SearchAfter,
 	}
-
-	return query
 }
 
-// BuildAggregateQuery constructs an OpenSearch aggregation DSL.
-func BuildAggregateQuery(tenantID string, req *AggregateRequest) map[string]any {
-	must := []map[string]any{
-		{"term": map[string]any{"tenant_id": tenantID}},
-	}
-
-	if req.Query != "" {
-		must = append(must, map[string]any{
-			"match": map[string]any{"message": req.Query},
-		})
-	}
-	if req.Level != "" {
-		must = append(must, map[string]any{"term": map[string]any{"level": req.Level}})
-	}
-	if req.Service != "" {
-		must = append(must, map[string]any{"term": map[string]any{"service": req.Service}})
-	}
-
-	timeRange := map[string]any{}
-	if !req.From.IsZero() {
-		timeRange["gte"] = req.From.Format(time.RFC3339Nano)
-	}
-	if !req.To.IsZero() {
-		timeRange["lte"] = req.To.Format(time.RFC3339Nano)
-	}
-	if len(timeRange) > 0 {
-		must = append(must, map[string]any{
-			"range": map[string]any{"timestamp": timeRange},
-		})
-	}
-
-	aggs := map[string]any{}
-
-	if req.GroupBy != "" {
-		aggs["group_by"] = map[string]any{
-			"terms": map[string]any{
-				"field": req.GroupBy,
-				"size":  20,
-			},
-		}
-	}
-
+// BuildAggregateQuery selects and groups the events an AggregateRequest
+// asks for. Unlike a search, the query matches any of its words by
+// default.
+func BuildAggregateQuery(tenantID string, req *AggregateRequest, types map[string]string) (*logstore.Query, *logstore.Aggregation, error) {
+	q := &logstore.Query{
+		TenantID: tenantID,
+		AnyWord:  true,
+		Level:    req.Level,
+		Service:  req.Service,
+		From:     req.From,
+		To:       req.To,
+	}
+	if err := setQuery(q, req.Query, req.Match, req.Syntax, types); err != nil {
+		return nil, nil, err
+	}
+	agg := &logstore.Aggregation{GroupBy: req.GroupBy, Weighted: req.Weighted}
 	if req.Interval != "" {
-		aggs["over_time"] = map[string]any{
-			"date_histogram": map[string]any{
-				"field":          "timestamp",
-				"fixed_interval": req.Interval,
-			},
+		interval, err := parseInterval(req.Interval)
+		if err != nil {
+			return nil, nil, err
 		}
+		agg.Interval = interval
 	}
+	return q, agg, nil
+}
 
-	// Default: count by level
-	if len(aggs) == 0 {
-		aggs["group_by"] = map[string]any{
-			"terms": map[string]any{
-				"field": "level",
-				"size":  20,
-			},
+// parseInterval reads a histogram interval such as "30s", "5m", "1h" or
+// "1d".
+func parseInterval(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid interval %q", s)
 		}
+		return time.Duration(n) * 24 * time.Hour, nil
 	}
-
-	return map[string]any{
-		"query": map[string]any{
-			"bool": map[string]any{"must": must},
-		},
-		"size":         0,
-		"aggregations": aggs,
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid interval %q", s)
 	}
+	return d, nil
 }
 
-// BuildTailQuery builds a query for the last N seconds of logs.
-func BuildTailQuery(tenantID string, req *TailRequest, since time.Time) map[string]any {
-	must := []map[string]any{
-		{"term": map[string]any{"tenant_id": tenantID}},
-		{"range": map[string]any{
-			"timestamp": map[string]any{"gt": since.Format(time.RFC3339Nano)},
-		}},
-	}
-
-	if req.Query != "" {
-		must = append(must, map[string]any{
-			"match": map[string]any{"message": req.Query},
-		})
+// BuildTailQuery selects the events a TailRequest follows. By default the
+// query matches any of its words.
+func BuildTailQuery(tenantID string, req *TailRequest, types map[string]string) (*logstore.Query, error) {
+	q := &logstore.Query{
+		TenantID: tenantID,
+		AnyWord:  true,
+		Level:    req.Level,
+		Service:  req.Service,
 	}
-	if req.Level != "" {
-		must = append(must, map[string]any{"term": map[string]any{"level": req.Level}})
+	if err := setQuery(q, req.Query, req.Match, req.Syntax, types); err != nil {
+		return nil, err
 	}
-	if req.Service != "" {
-		must = append(must, map[string]any{"term": map[string]any{"service": req.Service}})
+	return q, nil
+}
+
+// BuildPatternsQuery groups matching events by pattern_id, keeping the
+// latest template and a sample message for each pattern.
+func BuildPatternsQuery(tenantID string, req *PatternsRequest, types map[string]string) (*logstore.Query, *logstore.Aggregation, error) {
+	q, err := BuildSearchQuery(tenantID, &SearchRequest{
+		Query:   req.Query,
+		Match:   req.Match,
+		Syntax:  req.Syntax,
+		Level:   req.Level,
+		Service: req.Service,
+		Host:    req.Host,
+		TraceID: req.TraceID,
+		From:    req.From,
+		To:      req.To,
+	}, types)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return map[string]any{
-		"query": map[string]any{
-			"bool": map[string]any{"must": must},
-		},
-		"size": 100,
-		"sort": []map[string]any{
-			{"timestamp": map[string]any{"order": "asc"}},
-		},
+	size := req.Size
+	if size <= 0 || size > 500 {
+		size = 50
 	}
+	return q, &logstore.Aggregation{
+		GroupBy: "pattern_id",
+		Size:    size,
+		Seen:    true,
+		Sample:  []string{"pattern", "message"},
+	}, nil
 }
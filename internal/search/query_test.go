@@ -0,0 +1,81 @@
+package search
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/felipemonteiro/mintlog/internal/querylang"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Plain-text queries must keep working without lucene syntax, even when
+// they would not parse or validate as the query language.
+func TestPlainTextQueries(t *testing.T) {
+	tests := []struct {
+		query   string
+		message string
+	}{
+		{"error: disk full", "error: disk full on /dev/sda1"},
+		{"[ERROR] failed", "[ERROR] failed to open file"},
+		{"http://x/y", "GET http://x/y returned 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e := &logmodel.LogEvent{TenantID: "t1", Message: tt.message}
+
+			q, err := BuildSearchQuery("t1", &SearchRequest{Query: tt.query}, nil)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if q.Expr != nil || q.Text != tt.query {
+				t.Fatalf("search: got text %q, expr %v", q.Text, q.Expr)
+			}
+			if !q.Matches(e) {
+				t.Errorf("search: %q does not match %q", tt.query, tt.message)
+			}
+
+			tq, err := BuildTailQuery("t1", &TailRequest{Query: tt.query}, nil)
+			if err != nil {
+				t.Fatalf("tail: %v", err)
+			}
+			if !tq.Matches(e) {
+				t.Errorf("tail: %q does not match %q", tt.query, tt.message)
+			}
+
+			aq, _, err := BuildAggregateQuery("t1", &AggregateRequest{Query: tt.query}, nil)
+			if err != nil {
+				t.Fatalf("aggregate: %v", err)
+			}
+			if !aq.Matches(e) {
+				t.Errorf("aggregate: %q does not match %q", tt.query, tt.message)
+			}
+		})
+	}
+}
+
+func TestLuceneSyntax(t *testing.T) {
+	types := map[string]string{"status": "number"}
+	e := &logmodel.LogEvent{TenantID: "t1", Message: "upstream timeout", Service: "api", Fields: map[string]any{"status": 503.0}}
+
+	q, err := BuildSearchQuery("t1", &SearchRequest{Query: "service:api AND status:>=500", Syntax: SyntaxLucene}, types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Expr == nil {
+		t.Fatal("expected a parsed query")
+	}
+	if !q.Matches(e) {
+		t.Error("query does not match the event")
+	}
+
+	_, err = BuildSearchQuery("t1", &SearchRequest{Query: "error: disk full", Syntax: SyntaxLucene}, types)
+	var qerr *querylang.Error
+	if !errors.As(err, &qerr) || qerr.Pos != 0 || qerr.End != 5 {
+		t.Errorf("expected an unknown field error at 0-5, got %v", err)
+	}
+
+	_, err = BuildSearchQuery("t1", &SearchRequest{Query: "timeout", Syntax: SyntaxLucene, Match: "phrase"}, types)
+	if err == nil {
+		t.Error("expected match with lucene syntax to fail")
+	}
+}
@@ -0,0 +1,331 @@
+package filestore
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// hit is a matching event by its sort key: timestamp in milliseconds, as
+// OpenSearch sorts dates, then ID.
+type hit struct {
+	millis int64
+	id     string
+	line   json.RawMessage
+}
+
+func (h hit) before(o hit) bool {
+	if h.millis != o.millis {
+		return h.millis < o.millis
+	}
+	return h.id < o.id
+}
+
+// Search scans the range once, keeping only the page: at most opts.Size
+// hits in a heap whose top is the last of them in sort order.
+func (s *Store) Search(ctx context.Context, q *logstore.Query, opts logstore.SearchOptions) (*logstore.SearchResult, error) {
+	var after *hit
+	if len(opts.SearchAfter) > 0 {
+		h, err := parseCursor(opts.SearchAfter)
+		if err != nil {
+			return nil, err
+		}
+		after = &h
+	}
+
+	total := 0
+	p := &page{asc: opts.Asc}
+	err := s.scan(ctx, q, func(e *logmodel.LogEvent, line []byte) {
+		total++
+		h := hit{millis: e.Timestamp.UnixMilli(), id: e.ID}
+		if after != nil && !p.first(*after, h) {
+			return
+		}
+		switch {
+		case p.Len() < opts.Size:
+			h.line = append(json.RawMessage(nil), line...)
+			heap.Push(p, h)
+		case p.Len() > 0 && p.first(h, p.hits[0]):
+			h.line = append(json.RawMessage(nil), line...)
+			p.hits[0] = h
+			heap.Fix(p, 0)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	hits := p.hits
+	sort.Slice(hits, func(i, j int) bool { return p.first(hits[i], hits[j]) })
+
+	result := &logstore.SearchResult{
+		Total: total,
+		Hits:  make([]json.RawMessage, 0, len(hits)),
+	}
+	for _, h := range hits {
+		result.Hits = append(result.Hits, h.line)
+	}
+	if n := len(hits); n > 0 {
+		result.Cursor = []any{hits[n-1].millis, hits[n-1].id}
+	}
+	return result, nil
+}
+
+// page is a heap of hits whose top is the last in sort order, the first to
+// give way to a hit that sorts before it.
+type page struct {
+	hits []hit
+	asc  bool
+}
+
+// first reports whether a sorts before b: older first with asc, newer
+// first otherwise.
+func (p *page) first(a, b hit) bool {
+	if p.asc {
+		return a.before(b)
+	}
+	return b.before(a)
+}
+
+func (p *page) Len() int           { return len(p.hits) }
+func (p *page) Less(i, j int) bool { return p.first(p.hits[j], p.hits[i]) }
+func (p *page) Swap(i, j int)      { p.hits[i], p.hits[j] = p.hits[j], p.hits[i] }
+func (p *page) Push(x any)         { p.hits = append(p.hits, x.(hit)) }
+func (p *page) Pop() any {
+	h := p.hits[len(p.hits)-1]
+	p.hits = p.hits[:len(p.hits)-1]
+	return h
+}
+
+// parseCursor reads a Cursor returned by Search, as decoded from JSON.
+func parseCursor(values []any) (hit, error) {
+	if len(values) != 2 {
+		return hit{}, logstore.ErrInvalidCursor
+	}
+	var h hit
+	switch v := values[0].(type) {
+	case float64:
+		h.millis = int64(v)
+	case int64:
+		h.millis = v
+	case int:
+		h.millis = int64(v)
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return hit{}, logstore.ErrInvalidCursor
+		}
+		h.millis = n
+	default:
+		return hit{}, logstore.ErrInvalidCursor
+	}
+	id, ok := values[1].(string)
+	if !ok {
+		return hit{}, logstore.ErrInvalidCursor
+	}
+	h.id = id
+	return h, nil
+}
+
+func (s *Store) Count(ctx context.Context, q *logstore.Query) (int64, error) {
+	var n int64
+	if err := s.scan(ctx, q, func(*logmodel.LogEvent, []byte) { n++ }); err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) Tail(ctx context.Context, q *logstore.Query, fn func(json.RawMessage) error) error {
+	return logstore.PollTail(ctx, s, q, fn)
+}
+
+// group accumulates one bucket of an aggregation.
+type group struct {
+	bucket   logstore.Bucket
+	estimate float64
+	latest   *logmodel.LogEvent
+}
+
+func (g *group) add(e *logmodel.LogEvent, agg *logstore.Aggregation) {
+	g.bucket.Count++
+	g.estimate += logstore.Weight(e)
+	if agg.Seen {
+		if g.bucket.FirstSeen.IsZero() || e.Timestamp.Before(g.bucket.FirstSeen) {
+			g.bucket.FirstSeen = e.Timestamp.UTC()
+		}
+		if e.Timestamp.After(g.bucket.LastSeen) {
+			g.bucket.LastSeen = e.Timestamp.UTC()
+		}
+	}
+	if len(agg.Sample) > 0 && (g.latest == nil || e.Timestamp.After(g.latest.Timestamp)) {
+		g.latest = e
+	}
+}
+
+func (g *group) result(agg *logstore.Aggregation) logstore.Bucket {
+	b := g.bucket
+	if agg.Weighted {
+		estimate := g.estimate
+		b.EstimatedCount = &estimate
+	}
+	if g.latest != nil {
+		b.Sample = sample(g.latest, agg.Sample)
+	}
+	return b
+}
+
+// Aggregate groups matching events as OpenSearch's terms and
+// date_histogram aggregations would: an event counts once per value of an
+// array field, events without the field are left out, and histogram
+// buckets are aligned to the epoch with empty ones filled in.
+func (s *Store) Aggregate(ctx context.Context, q *logstore.Query, agg *logstore.Aggregation) (*logstore.AggregateResult, error) {
+	groupBy := agg.GroupBy
+	if groupBy == "" && agg.Interval == 0 {
+		groupBy = "level"
+	}
+	interval := agg.Interval.Milliseconds()
+
+	var total int64
+	var estimate float64
+	groups := make(map[string]*group)
+	overTime := make(map[int64]*group)
+	err := s.scan(ctx, q, func(e *logmodel.LogEvent, _ []byte) {
+		total++
+		estimate += logstore.Weight(e)
+		if groupBy != "" {
+			for _, key := range groupKeys(e, groupBy) {
+				g, ok := groups[key]
+				if !ok {
+					g = &group{bucket: logstore.Bucket{Key: key}}
+					groups[key] = g
+				}
+				g.add(e, agg)
+			}
+		}
+		if interval > 0 {
+			start := floorDiv(e.Timestamp.UnixMilli(), interval) * interval
+			g, ok := overTime[start]
+			if !ok {
+				g = &group{bucket: logstore.Bucket{Time: time.UnixMilli(start).UTC()}}
+				overTime[start] = g
+			}
+			g.add(e, &logstore.Aggregation{Weighted: agg.Weighted})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aggregate: %w", err)
+	}
+
+	result := &logstore.AggregateResult{Total: total}
+	if agg.Weighted {
+		result.EstimatedTotal = &estimate
+	}
+	if groupBy != "" {
+		result.Groups = make([]logstore.Bucket, 0, len(groups))
+		for _, g := range groups {
+			result.Groups = append(result.Groups, g.result(agg))
+		}
+		sort.Slice(result.Groups, func(i, j int) bool {
+			a, b := result.Groups[i], result.Groups[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Key < b.Key
+		})
+		size := agg.Size
+		if size <= 0 {
+			size = 20
+		}
+		if len(result.Groups) > size {
+			result.Groups = result.Groups[:size]
+		}
+	}
+	if interval > 0 {
+		result.OverTime = histogram(overTime, interval, agg)
+	}
+	return result, nil
+}
+
+// histogram lists buckets from the first to the last, oldest first,
+// filling the gaps with empty ones.
+func histogram(buckets map[int64]*group, interval int64, agg *logstore.Aggregation) []logstore.Bucket {
+	out := []logstore.Bucket{}
+	if len(buckets) == 0 {
+		return out
+	}
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	first, last := slices.Min(starts), slices.Max(starts)
+	for start := first; start <= last; start += interval {
+		g, ok := buckets[start]
+		if !ok {
+			g = &group{bucket: logstore.Bucket{Time: time.UnixMilli(start).UTC()}}
+		}
+		out = append(out, g.result(&logstore.Aggregation{Weighted: agg.Weighted}))
+	}
+	return out
+}
+
+// groupKeys returns the values an event is grouped under.
+func groupKeys(e *logmodel.LogEvent, path string) []string {
+	v, ok := logstore.FieldValue(e, path)
+	if !ok {
+		return nil
+	}
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		keys := make([]string, 0, len(v))
+		for _, item := range v {
+			keys = append(keys, logstore.FormatValue(item))
+		}
+		return keys
+	case map[string]any:
+		return nil
+	}
+	return []string{logstore.FormatValue(v)}
+}
+
+// sample renders the given field paths of an event as a JSON object, the
+// way OpenSearch filters a document's source.
+func sample(e *logmodel.LogEvent, paths []string) json.RawMessage {
+	doc := map[string]any{}
+	for _, path := range paths {
+		v, ok := logstore.FieldValue(e, path)
+		if !ok {
+			continue
+		}
+		keys := strings.Split(path, ".")
+		m := doc
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := m[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[key] = next
+			}
+			m = next
+		}
+		m[keys[len(keys)-1]] = v
+	}
+	b, _ := json.Marshal(doc)
+	return b
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,217 @@
+// Package filestore is an embedded log store for single-node deployments:
+// each tenant's events are appended to one newline-delimited JSON file per
+// day and queries scan the files of the days they cover.
+package filestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	fileExt   = ".ndjson"
+
+	// maxLine bounds one stored event.
+	maxLine = 16 << 20
+)
+
+// Store keeps events under dir as <tenant>/<YYYY-MM-DD>.ndjson, by the UTC
+// day of their timestamp. One process writes; any number may read, and a
+// line still being appended is skipped until it is complete.
+type Store struct {
+	dir string
+	mu  sync.Mutex // serializes writes
+}
+
+var _ logstore.LogStore = (*Store)(nil)
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) tenantDir(tenantID string) (string, error) {
+	if tenantID == "" || tenantID != filepath.Base(tenantID) || strings.HasPrefix(tenantID, ".") {
+		return "", fmt.Errorf("invalid tenant id %q", tenantID)
+	}
+	return filepath.Join(s.dir, tenantID), nil
+}
+
+// Write appends each event to its tenant's file for the day, one write per
+// file so readers never see a batch interleaved with another.
+func (s *Store) Write(ctx context.Context, events []*logmodel.LogEvent) error {
+	files := make(map[string][]byte)
+	for _, e := range events {
+		dir, err := s.tenantDir(e.TenantID)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal event %s: %w", e.ID, err)
+		}
+		path := filepath.Join(dir, e.Timestamp.UTC().Format(dayLayout)+fileExt)
+		files[path] = append(append(files[path], line...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for path, data := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := appendFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("append %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// days lists a tenant's day files, oldest first.
+func (s *Store) days(tenantID string) ([]time.Time, error) {
+	dir, err := s.tenantDir(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []time.Time{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", dir, err)
+	}
+	days := []time.Time{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		day, err := time.Parse(dayLayout, name)
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// scan calls fn with every event matching q and its stored line, reading
+// only the files of days q's time range covers.
+func (s *Store) scan(ctx context.Context, q *logstore.Query, fn func(e *logmodel.LogEvent, line []byte)) error {
+	if err := q.Compile(); err != nil {
+		return err
+	}
+	days, err := s.days(q.TenantID)
+	if err != nil {
+		return err
+	}
+	dir, _ := s.tenantDir(q.TenantID)
+	for _, day := range days {
+		if !overlaps(q, day) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := scanFile(filepath.Join(dir, day.Format(dayLayout)+fileExt), q, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlaps reports whether q's time range includes any of day.
+func overlaps(q *logstore.Query, day time.Time) bool {
+	end := day.Add(24 * time.Hour)
+	if !q.From.IsZero() && !q.From.Before(end) {
+		return false
+	}
+	if !q.After.IsZero() && !q.After.Before(end) {
+		return false
+	}
+	if !q.To.IsZero() && q.To.Before(day) {
+		return false
+	}
+	return true
+}
+
+func scanFile(path string, q *logstore.Query, fn func(e *logmodel.LogEvent, line []byte)) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // expired since listed
+	}
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	for sc.Scan() {
+		var e logmodel.LogEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue // a line still being written
+		}
+		if q.Matches(&e) {
+			fn(&e, sc.Bytes())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+// Expire deletes a tenant's day files holding only events before before,
+// returning how many it deleted.
+func (s *Store) Expire(tenantID string, before time.Time) (int, error) {
+	days, err := s.days(tenantID)
+	if err != nil {
+		return 0, err
+	}
+	dir, _ := s.tenantDir(tenantID)
+	deleted := 0
+	for _, day := range days {
+		if day.Add(24 * time.Hour).After(before) {
+			break
+		}
+		path := filepath.Join(dir, day.Format(dayLayout)+fileExt)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return deleted, fmt.Errorf("delete %s: %w", path, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
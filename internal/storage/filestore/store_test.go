@@ -0,0 +1,241 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+const tenantID = "t1"
+
+func newStore(t *testing.T, events ...*logmodel.LogEvent) *Store {
+	t.Helper()
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.TenantID == "" {
+			e.TenantID = tenantID
+		}
+	}
+	if err := s.Write(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAggregateWeighted(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newStore(t,
+		&logmodel.LogEvent{ID: "a", Timestamp: at, Level: "info", SampleRate: 0.1},
+		&logmodel.LogEvent{ID: "b", Timestamp: at, Level: "info", SampleRate: 0.5},
+		&logmodel.LogEvent{ID: "c", Timestamp: at, Level: "error"},
+	)
+
+	result, err := s.Aggregate(context.Background(), &logstore.Query{TenantID: tenantID}, &logstore.Aggregation{GroupBy: "level", Weighted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.EstimatedTotal == nil || *result.EstimatedTotal != 13 {
+		t.Fatalf("total %d, estimated %v; want 3 and 13", result.Total, result.EstimatedTotal)
+	}
+	want := map[string]float64{"info": 12, "error": 1}
+	for _, b := range result.Groups {
+		if b.EstimatedCount == nil || *b.EstimatedCount != want[b.Key] {
+			t.Errorf("group %s: estimated %v, want %v", b.Key, b.EstimatedCount, want[b.Key])
+		}
+	}
+}
+
+// events returns n events step apart from start, alternating info and
+// error.
+func events(start time.Time, n int, step time.Duration) []*logmodel.LogEvent {
+	out := make([]*logmodel.LogEvent, n)
+	for i := range out {
+		out[i] = &logmodel.LogEvent{
+			ID:        fmt.Sprintf("e%03d", i),
+			Timestamp: start.Add(time.Duration(i) * step),
+			Level:     []string{"info", "error"}[i%2],
+			Message:   fmt.Sprintf("request %d served", i),
+			Service:   "api",
+		}
+	}
+	return out
+}
+
+func ids(t *testing.T, result *logstore.SearchResult) []string {
+	t.Helper()
+	out := make([]string, len(result.Hits))
+	for i, line := range result.Hits {
+		var e logmodel.LogEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatal(err)
+		}
+		out[i] = e.ID
+	}
+	return out
+}
+
+func TestWriteSearch(t *testing.T) {
+	start := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	s := newStore(t, events(start, 10, 30*time.Minute)...)
+	ctx := context.Background()
+
+	result, err := s.Search(ctx, &logstore.Query{TenantID: tenantID, Level: "error"}, logstore.SearchOptions{Size: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 5 {
+		t.Errorf("total %d, want 5", result.Total)
+	}
+	if got, want := ids(t, result), []string{"e009", "e007", "e005"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// The range covers parts of two day files.
+	q := &logstore.Query{TenantID: tenantID, From: start.Add(30 * time.Minute), To: start.Add(90 * time.Minute)}
+	result, err = s.Search(ctx, q, logstore.SearchOptions{Size: 10, Asc: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(t, result), []string{"e001", "e002", "e003"}; !slices.Equal(got, want) {
+		t.Errorf("range: got %v, want %v", got, want)
+	}
+
+	result, err = s.Search(ctx, &logstore.Query{TenantID: "t2"}, logstore.SearchOptions{Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 0 || len(result.Hits) != 0 || result.Cursor != nil {
+		t.Errorf("other tenant: got %+v", result)
+	}
+}
+
+func TestSearchCursor(t *testing.T) {
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	evs := events(start, 25, 10*time.Minute)
+	// Two events share a millisecond and are ordered by ID.
+	evs[11].Timestamp = evs[10].Timestamp
+	s := newStore(t, evs...)
+
+	for _, asc := range []bool{true, false} {
+		var got []string
+		var cursor []any
+		for range 10 {
+			result, err := s.Search(context.Background(), &logstore.Query{TenantID: tenantID}, logstore.SearchOptions{Size: 4, Asc: asc, SearchAfter: cursor})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Hits) == 0 {
+				break
+			}
+			got = append(got, ids(t, result)...)
+			// Round-trip the cursor through JSON as clients do.
+			data, _ := json.Marshal(result.Cursor)
+			cursor = nil
+			if err := json.Unmarshal(data, &cursor); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := make([]string, len(evs))
+		for i, e := range evs {
+			want[i] = e.ID
+		}
+		if !asc {
+			slices.Reverse(want)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("asc %v: paged %v, want %v", asc, got, want)
+		}
+	}
+
+	if _, err := s.Search(context.Background(), &logstore.Query{TenantID: tenantID}, logstore.SearchOptions{Size: 4, SearchAfter: []any{"x"}}); !errors.Is(err, logstore.ErrInvalidCursor) {
+		t.Errorf("bad cursor: got %v", err)
+	}
+}
+
+func TestAggregateGroupsAndHistogram(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newStore(t,
+		&logmodel.LogEvent{ID: "a", Timestamp: start, Level: "error", Tags: []string{"x", "y"}},
+		&logmodel.LogEvent{ID: "b", Timestamp: start.Add(30 * time.Second), Level: "error", Tags: []string{"x"}},
+		&logmodel.LogEvent{ID: "c", Timestamp: start.Add(3 * time.Minute), Level: "info"},
+	)
+
+	result, err := s.Aggregate(context.Background(), &logstore.Query{TenantID: tenantID}, &logstore.Aggregation{GroupBy: "tags", Interval: time.Minute, Seen: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 {
+		t.Errorf("total %d, want 3", result.Total)
+	}
+
+	// An event counts once per tag; events without tags are left out.
+	if len(result.Groups) != 2 {
+		t.Fatalf("got groups %+v", result.Groups)
+	}
+	if g := result.Groups[0]; g.Key != "x" || g.Count != 2 || !g.FirstSeen.Equal(start) || !g.LastSeen.Equal(start.Add(30*time.Second)) {
+		t.Errorf("group x: %+v", g)
+	}
+	if g := result.Groups[1]; g.Key != "y" || g.Count != 1 {
+		t.Errorf("group y: %+v", g)
+	}
+
+	// Empty minutes between the first and last bucket are filled in.
+	var counts []int64
+	for i, b := range result.OverTime {
+		if want := start.Add(time.Duration(i) * time.Minute); !b.Time.Equal(want) {
+			t.Errorf("bucket %d starts at %v, want %v", i, b.Time, want)
+		}
+		counts = append(counts, b.Count)
+	}
+	if want := []int64{2, 0, 0, 1}; !slices.Equal(counts, want) {
+		t.Errorf("histogram %v, want %v", counts, want)
+	}
+
+	// Without a group or interval, events are grouped by level.
+	result, err = s.Aggregate(context.Background(), &logstore.Query{TenantID: tenantID}, &logstore.Aggregation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Groups) != 2 || result.Groups[0].Key != "error" || result.Groups[0].Count != 2 {
+		t.Errorf("by level: %+v", result.Groups)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newStore(t, events(start, 3, 24*time.Hour)...)
+
+	// 2026-03-02 is not over at 2026-03-02T12:00, so only 2026-03-01 goes.
+	deleted, err := s.Expire(tenantID, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d files, want 1", deleted)
+	}
+	n, err := s.Count(context.Background(), &logstore.Query{TenantID: tenantID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("%d events left, want 2", n)
+	}
+
+	if deleted, err := s.Expire("t2", start.Add(100*24*time.Hour)); err != nil || deleted != 0 {
+		t.Errorf("tenant without files: deleted %d, %v", deleted, err)
+	}
+	if _, err := s.Expire("../t1", start); err == nil {
+		t.Error("expected an error for an invalid tenant id")
+	}
+}
@@ -0,0 +1,94 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	mc "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Client is a thin wrapper over an S3-compatible object store.
+type Client struct {
+	mc *mc.Client
+}
+
+func NewClient(endpoint, accessKey, secretKey string, useSSL bool, region string) (*Client, error) {
+	c, err := mc.New(endpoint, &mc.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("minio client: %w", err)
+	}
+	return &Client{mc: c}, nil
+}
+
+// EnsureBucket creates bucket if it does not exist.
+func (c *Client) EnsureBucket(ctx context.Context, bucket string) error {
+	exists, err := c.mc.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("check bucket %s: %w", bucket, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := c.mc.MakeBucket(ctx, bucket, mc.MakeBucketOptions{}); err != nil {
+		return fmt.Errorf("create bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// Put uploads data as a single object.
+func (c *Client) Put(ctx context.Context, bucket, key string, data []byte, contentType, contentEncoding string) error {
+	_, err := c.mc.PutObject(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), mc.PutObjectOptions{
+		ContentType:     contentType,
+		ContentEncoding: contentEncoding,
+	})
+	if err != nil {
+		return fmt.Errorf("put %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PutStream uploads an object of unknown size from r, in parts.
+func (c *Client) PutStream(ctx context.Context, bucket, key string, r io.Reader, contentType, contentEncoding string) error {
+	_, err := c.mc.PutObject(ctx, bucket, key, r, -1, mc.PutObjectOptions{
+		ContentType:     contentType,
+		ContentEncoding: contentEncoding,
+	})
+	if err != nil {
+		return fmt.Errorf("put %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key  string
+	Size int64
+}
+
+// List returns every object whose key starts with prefix, in key order.
+func (c *Client) List(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	for obj := range c.mc.ListObjects(ctx, bucket, mc.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("list %s/%s: %w", bucket, prefix, obj.Err)
+		}
+		objects = append(objects, ObjectInfo{Key: obj.Key, Size: obj.Size})
+	}
+	return objects, nil
+}
+
+// Get opens an object for reading. The caller closes it.
+func (c *Client) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	obj, err := c.mc.GetObject(ctx, bucket, key, mc.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get %s/%s: %w", bucket, key, err)
+	}
+	return obj, nil
+}
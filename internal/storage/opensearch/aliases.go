@@ -0,0 +1,237 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v4"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// A tenant's events are written through its write alias, which points at
+// one backing index, mintlog-<tenant>-NNNNNN, until the lifecycle rolls it
+// over to the next. The read alias covers every index holding the tenant's
+// events: backing indices, daily indices from before rollover, and
+// rehydrated indices.
+
+// WriteAlias returns the alias events are indexed through.
+func WriteAlias(tenantID string) string {
+	return fmt.Sprintf("mintlog-%s-write", tenantID)
+}
+
+// ReadAlias returns the alias a tenant's events are searched through.
+func ReadAlias(tenantID string) string {
+	return fmt.Sprintf("mintlog-%s-read", tenantID)
+}
+
+// TenantPattern matches every index of a tenant.
+func TenantPattern(tenantID string) string {
+	return fmt.Sprintf("mintlog-%s-*", tenantID)
+}
+
+// firstIndex is the first backing index of a tenant; rollover increments
+// the number.
+func firstIndex(tenantID string) string {
+	return fmt.Sprintf("mintlog-%s-000001", tenantID)
+}
+
+// EnsureWriteIndex sets up a tenant's aliases unless the write alias
+// exists: on a first backing index of its own, or on the shared indices.
+func EnsureWriteIndex(ctx context.Context, client *opensearchapi.Client, tenantID string, placement Placement) error {
+	index, err := WriteIndex(ctx, client, tenantID)
+	if err != nil {
+		return err
+	}
+	if index != "" {
+		return nil
+	}
+	if placement == PlacementShared {
+		return joinShared(ctx, client, tenantID)
+	}
+
+	body := fmt.Sprintf(`{"aliases": {%q: {"is_write_index": true}, %q: {}}}`, WriteAlias(tenantID), ReadAlias(tenantID))
+	_, err = client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{
+		Index: firstIndex(tenantID),
+		Body:  strings.NewReader(body),
+	})
+	if err != nil && !isErrorType(err, "resource_already_exists_exception") {
+		return fmt.Errorf("create index %s: %w", firstIndex(tenantID), err)
+	}
+	return nil
+}
+
+// WriteIndex returns the index behind a tenant's write alias, or "" if the
+// alias does not exist.
+func WriteIndex(ctx context.Context, client *opensearchapi.Client, tenantID string) (string, error) {
+	writes, err := WriteIndices(ctx, client, WriteAlias(tenantID))
+	if err != nil {
+		return "", err
+	}
+	return writes[WriteAlias(tenantID)], nil
+}
+
+// WriteIndices returns the write index of every alias matching pattern.
+func WriteIndices(ctx context.Context, client *opensearchapi.Client, pattern string) (map[string]string, error) {
+	resp, err := client.Indices.Alias.Get(ctx, opensearchapi.AliasGetReq{Alias: []string{pattern}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == http.StatusNotFound {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("get alias %s: %w", pattern, err)
+	}
+
+	writes := make(map[string]string)
+	for index, info := range resp.Indices {
+		for alias, raw := range info.Aliases {
+			var a struct {
+				IsWriteIndex *bool `json:"is_write_index"`
+			}
+			json.Unmarshal(raw, &a)
+			// An alias on a single index without the flag writes to it.
+			if a.IsWriteIndex == nil && writes[alias] == "" || a.IsWriteIndex != nil && *a.IsWriteIndex {
+				writes[alias] = index
+			}
+		}
+	}
+	return writes, nil
+}
+
+// TenantWriteIndices returns the write index of every tenant, keyed by
+// tenant ID. For a tenant on the shared indices it is the shared write
+// index.
+func TenantWriteIndices(ctx context.Context, client *opensearchapi.Client) (map[string]string, error) {
+	writes, err := WriteIndices(ctx, client, "mintlog-*-write")
+	if err != nil {
+		return nil, err
+	}
+	byTenant := make(map[string]string, len(writes))
+	for alias, index := range writes {
+		if alias == SharedWriteAlias {
+			continue
+		}
+		tenantID := strings.TrimSuffix(strings.TrimPrefix(alias, "mintlog-"), "-write")
+		byTenant[tenantID] = index
+	}
+	return byTenant, nil
+}
+
+// BackingIndex reports whether name is a backing index of a write alias,
+// mintlog-<tenant>-NNNNNN.
+func BackingIndex(name string) bool {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 || len(name)-i-1 != 6 {
+		return false
+	}
+	for _, c := range name[i+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// AddReadAlias adds indices to a tenant's read alias.
+func AddReadAlias(ctx context.Context, client *opensearchapi.Client, tenantID string, indices []string) error {
+	actions := make([]map[string]any, len(indices))
+	for i, index := range indices {
+		actions[i] = map[string]any{"add": map[string]string{"index": index, "alias": ReadAlias(tenantID)}}
+	}
+	return updateAliases(ctx, client, actions)
+}
+
+// updateAliases applies alias actions in one atomic request.
+func updateAliases(ctx context.Context, client *opensearchapi.Client, actions []map[string]any) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	body, _ := json.Marshal(map[string]any{"actions": actions})
+	if _, err := client.Aliases(ctx, opensearchapi.AliasesReq{Body: strings.NewReader(string(body))}); err != nil {
+		return fmt.Errorf("update aliases: %w", err)
+	}
+	return nil
+}
+
+// Aliased returns the indices matching pattern that belong to alias.
+func Aliased(ctx context.Context, client *opensearchapi.Client, pattern, alias string) (map[string]bool, error) {
+	resp, err := client.Indices.Alias.Get(ctx, opensearchapi.AliasGetReq{Indices: []string{pattern}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == http.StatusNotFound {
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("get aliases of %s: %w", pattern, err)
+	}
+	in := make(map[string]bool, len(resp.Indices))
+	for index, info := range resp.Indices {
+		_, in[index] = info.Aliases[alias]
+	}
+	return in, nil
+}
+
+// RolloverConditions trigger a rollover when any is met. Zero values are
+// left out.
+type RolloverConditions struct {
+	MaxSizeGB int // primary store size
+	MaxDocs   int64
+	MaxAge    time.Duration
+}
+
+// Rollover moves a tenant's write alias to a new backing index, also in
+// the read alias, if the current one meets any condition. It returns the
+// new index, or "" if nothing rolled over.
+func Rollover(ctx context.Context, client *opensearchapi.Client, tenantID string, cond RolloverConditions) (string, error) {
+	return rollover(ctx, client, WriteAlias(tenantID), map[string]any{ReadAlias(tenantID): map[string]any{}}, cond)
+}
+
+func rollover(ctx context.Context, client *opensearchapi.Client, alias string, aliases map[string]any, cond RolloverConditions) (string, error) {
+	conditions := map[string]any{}
+	if cond.MaxSizeGB > 0 {
+		conditions["max_size"] = fmt.Sprintf("%dgb", cond.MaxSizeGB)
+	}
+	if cond.MaxDocs > 0 {
+		conditions["max_docs"] = cond.MaxDocs
+	}
+	if cond.MaxAge > 0 {
+		conditions["max_age"] = fmt.Sprintf("%ds", int64(cond.MaxAge/time.Second))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return rolloverTo(ctx, client, alias, "", aliases, conditions)
+}
+
+// rolloverTo rolls alias over to newIndex, or the next name after the
+// write index if empty. Without conditions it always rolls over.
+func rolloverTo(ctx context.Context, client *opensearchapi.Client, alias, newIndex string, aliases, conditions map[string]any) (string, error) {
+	req := map[string]any{}
+	if len(conditions) > 0 {
+		req["conditions"] = conditions
+	}
+	if len(aliases) > 0 {
+		req["aliases"] = aliases
+	}
+	body, _ := json.Marshal(req)
+
+	resp, err := client.Indices.Rollover(ctx, opensearchapi.IndicesRolloverReq{
+		Alias: alias,
+		Index: newIndex,
+		Body:  strings.NewReader(string(body)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("roll over %s: %w", alias, err)
+	}
+	if !resp.RolledOver {
+		return "", nil
+	}
+	return resp.NewIndex, nil
+}
+
+// isErrorType reports whether err is an OpenSearch error of the given type.
+func isErrorType(err error, errType string) bool {
+	var se *opensearch.StructError
+	return errors.As(err, &se) && se.Err.Type == errType
+}
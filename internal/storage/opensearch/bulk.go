@@ -0,0 +1,82 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// BulkIndex indexes docs into index under the matching ids, replacing any
+// document already stored with the same id. It fails if any document is
+// rejected.
+func BulkIndex(ctx context.Context, client *opensearchapi.Client, index string, ids []string, docs []json.RawMessage) error {
+	var body strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&body, `{"index":{"_index":"%s","_id":"%s"}}`, index, ids[i])
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	resp, err := client.Bulk(ctx, opensearchapi.BulkReq{Body: strings.NewReader(body.String())})
+	if err != nil {
+		return fmt.Errorf("bulk index %s: %w", index, err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	failed := 0
+	var first error
+	for _, item := range resp.Items {
+		if err := itemError(item); err != nil {
+			if failed == 0 {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Errorf("bulk index %s: %d of %d documents rejected: %w", index, failed, len(docs), first)
+}
+
+// BulkItemError is OpenSearch's rejection of one document in a bulk request.
+type BulkItemError struct {
+	Status int
+	Type   string // e.g. "mapper_parsing_exception"
+	Reason string
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("opensearch %d %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// Retryable reports whether the document may be accepted later: the
+// cluster was overloaded (429) or unavailable (5xx).
+func (e *BulkItemError) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// MissingWriteIndex reports whether the document was rejected because its
+// write alias, or the index behind it, no longer exists, as after the
+// tenant's indices were moved or deleted.
+func (e *BulkItemError) MissingWriteIndex() bool {
+	return e.Type == "index_not_found_exception" ||
+		e.Type == "illegal_argument_exception" && strings.Contains(e.Reason, "alias")
+}
+
+// itemError returns the error of one bulk response item, or nil if the
+// document was stored.
+func itemError(item map[string]opensearchapi.BulkRespItem) *BulkItemError {
+	for _, res := range item {
+		if res.Error != nil {
+			return &BulkItemError{Status: res.Status, Type: res.Error.Type, Reason: res.Error.Reason}
+		}
+		if res.Status >= 300 {
+			return &BulkItemError{Status: res.Status, Type: "unknown", Reason: http.StatusText(res.Status)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package opensearch
+
+import "testing"
+
+func TestMissingWriteIndex(t *testing.T) {
+	tests := []struct {
+		err  BulkItemError
+		want bool
+	}{
+		{BulkItemError{Status: 404, Type: "index_not_found_exception", Reason: "no such index [mintlog-t1-write]"}, true},
+		{BulkItemError{Status: 400, Type: "illegal_argument_exception", Reason: "no write index is defined for alias [mintlog-t1-write]"}, true},
+		{BulkItemError{Status: 400, Type: "mapper_parsing_exception", Reason: "failed to parse field [fields.status]"}, false},
+		{BulkItemError{Status: 429, Type: "es_rejected_execution_exception", Reason: "rejected execution"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.err.MissingWriteIndex(); got != tt.want {
+			t.Errorf("%s: MissingWriteIndex = %v, want %v", tt.err.Error(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// Drift is a template or index that differs from what the current template
+// and the tenant's overrides specify.
+type Drift struct {
+	Kind     string   `json:"kind"` // "template" or "index"
+	Name     string   `json:"name"`
+	TenantID string   `json:"tenant_id,omitempty"`
+	Problems []string `json:"problems"`
+
+	// Reindex is set for an index that cannot be brought up to date in
+	// place: its shard count or a field's mapping differs.
+	Reindex bool `json:"reindex,omitempty"`
+
+	// Backfill is set for an index missing fields or subfields the
+	// template maps. Mapping them in place covers the events indexed from
+	// then on; earlier events need to be indexed again.
+	Backfill bool `json:"backfill,omitempty"`
+}
+
+// indexSettings are the settings compared on existing indices; the others
+// only apply when an index is created.
+var indexSettings = []string{"index.number_of_shards", "index.number_of_replicas", "index.refresh_interval"}
+
+// CheckDrift compares the cluster's templates and event indices with the
+// current template and the overrides by tenant ID. Rehydrated indices are
+// left out: they are temporary.
+func CheckDrift(ctx context.Context, client *opensearchapi.Client, overrides map[string]IndexOverrides) ([]Drift, error) {
+	drift, err := templateDrift(ctx, client, overrides)
+	if err != nil {
+		return nil, err
+	}
+	indices, err := indexDrift(ctx, client, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return append(drift, indices...), nil
+}
+
+func templateDrift(ctx context.Context, client *opensearchapi.Client, overrides map[string]IndexOverrides) ([]Drift, error) {
+	installed, err := installedTemplates(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	current := CurrentTemplate()
+
+	drift := []Drift{}
+	check := func(name, tenantID string, o *IndexOverrides) {
+		d := Drift{Kind: "template", Name: name, TenantID: tenantID}
+		t, ok := installed[name]
+		if !ok {
+			d.Problems = []string{"missing"}
+			drift = append(drift, d)
+			return
+		}
+		if t.IndexTemplate.Version != current.Version {
+			d.Problems = append(d.Problems, fmt.Sprintf("version %d, want %d", t.IndexTemplate.Version, current.Version))
+		}
+		settings, mappings := current.index(o)
+		var haveSettings, haveMappings map[string]any
+		json.Unmarshal(t.IndexTemplate.Template.Settings, &haveSettings)
+		json.Unmarshal(t.IndexTemplate.Template.Mappings, &haveMappings)
+
+		want, have := flatten(settings), flatten(haveSettings)
+		for _, k := range sortedKeys(want) {
+			if have[k] != want[k] {
+				d.Problems = append(d.Problems, settingProblem(k, have[k], want[k]))
+			}
+		}
+		problems, _, _ := mappingDiff(mappings, haveMappings, "")
+		d.Problems = append(d.Problems, problems...)
+		if len(d.Problems) > 0 {
+			drift = append(drift, d)
+		}
+	}
+
+	check(indexTemplateName, "", nil)
+	for _, tenantID := range sortedKeys(overrides) {
+		o := overrides[tenantID]
+		check(TenantTemplateName(tenantID), tenantID, &o)
+	}
+	for _, name := range sortedKeys(installed) {
+		tenantID, ok := strings.CutPrefix(name, tenantTemplatePrefix)
+		if _, kept := overrides[tenantID]; ok && !kept {
+			drift = append(drift, Drift{Kind: "template", Name: name, TenantID: tenantID, Problems: []string{"tenant has no overrides"}})
+		}
+	}
+	return drift, nil
+}
+
+func indexDrift(ctx context.Context, client *opensearchapi.Client, overrides map[string]IndexOverrides) ([]Drift, error) {
+	flat := true
+	settingsResp, err := client.Indices.Settings.Get(ctx, &opensearchapi.SettingsGetReq{
+		Indices: []string{"mintlog-*"},
+		Params:  opensearchapi.SettingsGetParams{FlatSettings: &flat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get index settings: %w", err)
+	}
+	mappingResp, err := client.Indices.Mapping.Get(ctx, &opensearchapi.MappingGetReq{Indices: []string{"mintlog-*"}})
+	if err != nil {
+		return nil, fmt.Errorf("get index mappings: %w", err)
+	}
+	current := CurrentTemplate()
+
+	names := make([]string, 0, len(settingsResp.Indices))
+	for name := range settingsResp.Indices {
+		if !strings.Contains(name, "-rehydrated-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	drift := []Drift{}
+	for _, name := range names {
+		tenantID, _ := IndexTenant(name)
+		var o *IndexOverrides
+		if ov, ok := overrides[tenantID]; ok {
+			o = &ov
+		}
+		settings, mappings := current.index(o)
+
+		d := Drift{Kind: "index", Name: name, TenantID: tenantID}
+		var haveMappings map[string]any
+		json.Unmarshal(mappingResp.Indices[name].Mappings, &haveMappings)
+		if v := templateVersion(haveMappings); v != current.Version {
+			d.Problems = append(d.Problems, fmt.Sprintf("template version %d, want %d", v, current.Version))
+		}
+
+		var haveSettings map[string]any
+		json.Unmarshal(settingsResp.Indices[name].Settings, &haveSettings)
+		want, have := flatten(settings), flatten(haveSettings)
+		for _, k := range indexSettings {
+			if have[k] != want[k] {
+				d.Problems = append(d.Problems, settingProblem(k, have[k], want[k]))
+				if k == "index.number_of_shards" {
+					d.Reindex = true
+				}
+			}
+		}
+
+		problems, reindex, missing := mappingDiff(mappings, haveMappings, "")
+		d.Problems = append(d.Problems, problems...)
+		d.Reindex = d.Reindex || reindex
+		d.Backfill = missing
+		if len(d.Problems) > 0 {
+			drift = append(drift, d)
+		}
+	}
+	return drift, nil
+}
+
+// IndexTenant returns the tenant of a dedicated index, mintlog-<tenant>-...;
+// false for the shared indices.
+func IndexTenant(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, "mintlog-")
+	if !ok || len(rest) < 37 || rest[36] != '-' || SharedIndex(name) {
+		return "", false
+	}
+	return rest[:36], true
+}
+
+// templateVersion reads the version recorded in an index's mappings; 0 for
+// indices created before templates were versioned.
+func templateVersion(mappings map[string]any) int {
+	meta, _ := mappings["_meta"].(map[string]any)
+	v, _ := meta["template_version"].(float64)
+	return int(v)
+}
+
+func settingProblem(key, have, want string) string {
+	if have == "" {
+		return fmt.Sprintf("%s unset, want %s", key, want)
+	}
+	return fmt.Sprintf("%s is %s, want %s", key, have, want)
+}
+
+// flatten renders settings as index.* keys with string values, the way
+// OpenSearch reports flat settings.
+func flatten(settings map[string]any) map[string]string {
+	out := make(map[string]string)
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			key := prefix + k
+			if sub, ok := v.(map[string]any); ok {
+				walk(key+".", sub)
+				continue
+			}
+			if !strings.HasPrefix(key, "index.") {
+				key = "index." + key
+			}
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	walk("", settings)
+	return out
+}
+
+// mappingDiff lists the fields mapped in want that have is missing or maps
+// differently, subfields included. Fields only in have, such as
+// dynamically mapped ones, are fine. reindex is set if a field is mapped
+// differently, which an index cannot change in place, and missing if one
+// is not mapped.
+func mappingDiff(want, have map[string]any, prefix string) (problems []string, reindex, missing bool) {
+	wantProps, _ := want["properties"].(map[string]any)
+	haveProps, _ := have["properties"].(map[string]any)
+	for _, name := range sortedKeys(wantProps) {
+		path := prefix + name
+		w, _ := wantProps[name].(map[string]any)
+		h, ok := haveProps[name].(map[string]any)
+		if !ok {
+			problems = append(problems, path+" not mapped")
+			missing = true
+			continue
+		}
+		for _, k := range sortedKeys(w) {
+			if k == "properties" || k == "fields" {
+				continue
+			}
+			hv, wv := mappingParam(h, k), mappingParam(w, k)
+			if !reflect.DeepEqual(hv, wv) {
+				problems = append(problems, fmt.Sprintf("%s %s is %v, want %v", path, k, hv, wv))
+				reindex = reindex || k != "ignore_above"
+			}
+		}
+		var nested [][2]map[string]any
+		if _, ok := w["properties"]; ok {
+			nested = append(nested, [2]map[string]any{w, h})
+		}
+		if _, ok := w["fields"]; ok {
+			// Subfields, such as message.wildcard, have the shape of
+			// properties.
+			nested = append(nested, [2]map[string]any{{"properties": w["fields"]}, {"properties": h["fields"]}})
+		}
+		for _, n := range nested {
+			p, r, m := mappingDiff(n[0], n[1], path+".")
+			problems = append(problems, p...)
+			reindex = reindex || r
+			missing = missing || m
+		}
+	}
+	return problems, reindex, missing
+}
+
+// mappingParam returns a mapping parameter, with the defaults OpenSearch
+// leaves out when it reports an index's mappings.
+func mappingParam(m map[string]any, k string) any {
+	if v, ok := m[k]; ok {
+		return v
+	}
+	switch {
+	case k == "type" && m["properties"] != nil:
+		return "object"
+	case k == "type" && m["enabled"] == false:
+		return "object"
+	case k == "enabled":
+		return true
+	case k == "analyzer" && m["type"] == "text":
+		return "standard"
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
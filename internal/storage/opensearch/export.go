@@ -0,0 +1,61 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+const exportKeepAlive = 2 * time.Minute
+
+// Export scrolls through every document of index in timestamp order, calling fn
+// with each page of sources. It returns the number of documents read.
+func Export(ctx context.Context, client *opensearchapi.Client, index string, pageSize int, fn func(docs []json.RawMessage) error) (int, error) {
+	body := fmt.Sprintf(`{"size": %d, "sort": [{"timestamp": "asc"}, "_doc"]}`, pageSize)
+	resp, err := client.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{index},
+		Body:    strings.NewReader(body),
+		Params:  opensearchapi.SearchParams{Scroll: exportKeepAlive},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("export %s: %w", index, err)
+	}
+
+	scrollID := resp.ScrollID
+	defer func() {
+		if scrollID != nil {
+			client.Scroll.Delete(context.Background(), opensearchapi.ScrollDeleteReq{ScrollIDs: []string{*scrollID}})
+		}
+	}()
+
+	total := 0
+	hits := resp.Hits.Hits
+	for len(hits) > 0 {
+		docs := make([]json.RawMessage, len(hits))
+		for i, hit := range hits {
+			docs[i] = hit.Source
+		}
+		if err := fn(docs); err != nil {
+			return total, err
+		}
+		total += len(docs)
+
+		if scrollID == nil {
+			break
+		}
+		next, err := client.Scroll.Get(ctx, opensearchapi.ScrollGetReq{
+			ScrollID: *scrollID,
+			Params:   opensearchapi.ScrollGetParams{Scroll: exportKeepAlive},
+		})
+		if err != nil {
+			return total, fmt.Errorf("export %s: %w", index, err)
+		}
+		scrollID = next.ScrollID
+		hits = next.Hits.Hits
+	}
+	return total, nil
+}
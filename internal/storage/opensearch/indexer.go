@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -12,120 +13,283 @@ import (
 	"github.com/nats-io/nats.go"
 	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 
+	"github.com/felipemonteiro/mintlog/internal/bus"
 	"github.com/felipemonteiro/mintlog/pkg/logmodel"
 )
 
 const (
-	defaultBatchSize  = 500
-	defaultFlushEvery = 2 * time.Second
+	// retryBase is the redelivery delay after a retryable failure, doubled
+	// on each further delivery.
+	retryBase = 5 * time.Second
+
+	// targetLatency is the bulk request duration the batch size adapts to:
+	// faster requests grow it, requests over twice as slow shrink it.
+	targetLatency = time.Second
+
+	bulkTimeout = 30 * time.Second
 )
 
+// stageName identifies the indexer in dead-letter headers.
+const stageName = "indexer"
+
+// Indexer consumes logs.parsed and bulk-indexes events with a pool of
+// workers. Events are buffered into batches by encoded size; a full batch,
+// or whatever is buffered every flushEvery, is handed to the next idle
+// worker. When every worker is busy the NATS callback blocks, so delivery
+// is paced by bulk capacity and bounded by the consumer's maxPending.
+// Events go to their tenant's write alias, which the lifecycle rolls over.
+//
+// The batch size adapts between minBatch and maxBatch bytes: it grows by a
+// quarter after a full batch is indexed faster than targetLatency, and
+// halves when a request is throttled (429) or takes over twice as long.
 type Indexer struct {
-	client *opensearchapi.Client
-	js     nats.JetStreamContext
-	sub    *nats.Subscription
+	client     *opensearchapi.Client
+	nc         *nats.Conn
+	js         nats.JetStreamContext
+	pub        *bus.Publisher
+	sub        *nats.Subscription
+	dlq        *nats.Subscription
+	changes    *nats.Subscription
+	workers    int
+	minBatch   int
+	maxBatch   int
+	flushEvery time.Duration
+	maxPending int
 
-	mu     sync.Mutex
-	buffer []bulkItem
-	cancel context.CancelFunc
+	mu         sync.Mutex
+	buffer     []bulkItem
+	bufBytes   int
+	batchBytes int // current target batch size
+
+	batches chan batch
+	quit    chan struct{}
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+
+	stats     *indexStats
+	placement PlacementFunc
+	ready     sync.Map // tenant ID -> struct{}, write index bootstrapped
 }
 
+// PlacementFunc returns where a tenant without indices is placed.
+type PlacementFunc func(ctx context.Context, tenantID string) (Placement, error)
+
 type bulkItem struct {
-	index string
-	id    string
-	doc   []byte
-	msg   *nats.Msg
+	tenantID string
+	index    string
+	id       string
+	doc      []byte
+	msg      *nats.Msg
 }
 
-func NewIndexer(client *opensearchapi.Client, js nats.JetStreamContext) *Indexer {
+// batch is a bulk request's worth of items. full is set when it was cut at
+// the target size rather than by the flush timer.
+type batch struct {
+	items []bulkItem
+	bytes int
+	full  bool
+}
+
+func NewIndexer(client *opensearchapi.Client, nc *nats.Conn, js nats.JetStreamContext, pub *bus.Publisher, workers, minBatchBytes, maxBatchBytes int, flushEvery time.Duration, maxPending int, placement PlacementFunc) *Indexer {
+	workers = max(workers, 1)
 	return &Indexer{
-		client: client,
-		js:     js,
-		buffer: make([]bulkItem, 0, defaultBatchSize),
+		client:     client,
+		nc:         nc,
+		js:         js,
+		pub:        pub,
+		workers:    workers,
+		minBatch:   minBatchBytes,
+		maxBatch:   max(maxBatchBytes, minBatchBytes),
+		flushEvery: flushEvery,
+		maxPending: maxPending,
+		batchBytes: minBatchBytes,
+		batches:    make(chan batch),
+		quit:       make(chan struct{}),
+		stats:      newIndexStats(),
+		placement:  placement,
 	}
 }
 
 func (idx *Indexer) Start(ctx context.Context) error {
 	ctx, idx.cancel = context.WithCancel(ctx)
 
+	// Events wait in the buffer and for a worker before their own request.
+	ackWait := idx.flushEvery + 4*bulkTimeout
+	err := bus.EnsureQueueConsumer(idx.js, "LOGS_PARSED", "opensearch-indexer", "opensearch-indexers", "logs.parsed.>", ackWait, idx.maxPending)
+	if err != nil {
+		return err
+	}
+	idx.dlq, err = idx.pub.WatchMaxDeliveries("LOGS_PARSED", "opensearch-indexer", stageName)
+	if err != nil {
+		return err
+	}
+	// Every replica caches which tenants are set up, so this is not a
+	// queue subscription.
+	idx.changes, err = idx.nc.Subscribe(IndicesChangedSubject, func(m *nats.Msg) {
+		idx.Forget(string(m.Data))
+	})
+	if err != nil {
+		return fmt.Errorf("subscribe %s: %w", IndicesChangedSubject, err)
+	}
+
+	for range idx.workers {
+		idx.wg.Add(1)
+		go idx.work()
+	}
+
 	sub, err := idx.js.QueueSubscribe(
 		"logs.parsed.>",
 		"opensearch-indexers",
 		idx.handleMessage,
-		nats.Durable("opensearch-indexer"),
+		nats.Bind("LOGS_PARSED", "opensearch-indexer"),
 		nats.ManualAck(),
-		nats.AckWait(30_000_000_000),
-		nats.MaxDeliver(3),
 	)
 	if err != nil {
 		return fmt.Errorf("subscribe logs.parsed: %w", err)
 	}
+	// The consumer's MaxAckPending bounds what is delivered; the client
+	// must not drop messages before that.
+	sub.SetPendingLimits(-1, -1)
 	idx.sub = sub
 
 	go idx.flushLoop(ctx)
 
-	slog.Info("opensearch indexer started")
+	slog.Info("opensearch indexer started", "workers", idx.workers, "batch_bytes", idx.minBatch)
 	return nil
 }
 
+// Stop stops consuming, indexes what is buffered and waits for the workers
+// to finish. Events still waiting for a worker are NAKed.
 func (idx *Indexer) Stop() {
-	if idx.cancel != nil {
-		idx.cancel()
+	if idx.cancel == nil {
+		return
 	}
+	idx.cancel()
 	if idx.sub != nil {
 		idx.sub.Unsubscribe()
 	}
-	idx.flush()
+	idx.dlq.Unsubscribe()
+	idx.changes.Unsubscribe()
+	if b, ok := idx.take(false); ok {
+		idx.index(b)
+	}
+	close(idx.quit)
+	idx.wg.Wait()
 }
 
 func (idx *Indexer) handleMessage(msg *nats.Msg) {
 	var event logmodel.LogEvent
-	if err := json.Unmarshal(msg.Data, &event); err != nil {
-		slog.Error("indexer: unmarshal failed", "error", err)
-		msg.Nak()
+	if err := bus.DecodeEvent(msg.Header, msg.Data, &event); err != nil {
+		slog.Error("indexer: decode failed", "error", err)
+		idx.stats.failed(bus.TenantFromSubject(msg.Subject), "decode_error", false)
+		idx.pub.Reject(msg, stageName, fmt.Errorf("decode: %w", err))
 		return
 	}
 
-	indexName := IndexName(event.TenantID, event.Timestamp)
+	indexName := WriteAlias(event.TenantID)
 	doc, _ := json.Marshal(event)
 
 	idx.mu.Lock()
 	idx.buffer = append(idx.buffer, bulkItem{
-		index: indexName,
-		id:    event.ID,
-		doc:   doc,
-		msg:   msg,
+		tenantID: event.TenantID,
+		index:    indexName,
+		id:       event.ID,
+		doc:      doc,
+		msg:      msg,
 	})
-	shouldFlush := len(idx.buffer) >= defaultBatchSize
+	idx.bufBytes += len(doc) + len(indexName) + len(event.ID) + 32
+	full := idx.bufBytes >= idx.batchBytes
 	idx.mu.Unlock()
 
-	if shouldFlush {
-		idx.flush()
+	if full {
+		if b, ok := idx.take(true); ok {
+			idx.dispatch(b)
+		}
 	}
 }
 
 func (idx *Indexer) flushLoop(ctx context.Context) {
-	ticker := time.NewTicker(defaultFlushEvery)
+	ticker := time.NewTicker(idx.flushEvery)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			idx.flush()
+			if b, ok := idx.take(false); ok {
+				idx.dispatch(b)
+			}
 		}
 	}
 }
 
-func (idx *Indexer) flush() {
+// take empties the buffer into a batch.
+func (idx *Indexer) take(full bool) (batch, bool) {
 	idx.mu.Lock()
+	defer idx.mu.Unlock()
 	if len(idx.buffer) == 0 {
-		idx.mu.Unlock()
-		return
+		return batch{}, false
+	}
+	b := batch{items: idx.buffer, bytes: idx.bufBytes, full: full}
+	idx.buffer = nil
+	idx.bufBytes = 0
+	return b, true
+}
+
+// dispatch waits for a free worker. Once the indexer stops, the batch is
+// NAKed for redelivery instead.
+func (idx *Indexer) dispatch(b batch) {
+	select {
+	case idx.batches <- b:
+	case <-idx.quit:
+		for _, item := range b.items {
+			item.msg.Nak()
+		}
+	}
+}
+
+func (idx *Indexer) work() {
+	defer idx.wg.Done()
+	for {
+		select {
+		case b := <-idx.batches:
+			idx.index(b)
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// index sends one bulk request, settles every message and adapts the batch
+// size to how it went.
+func (idx *Indexer) index(b batch) {
+	start := time.Now()
+	throttled := idx.bulk(b.items)
+	idx.adapt(b, time.Since(start), throttled)
+}
+
+func (idx *Indexer) adapt(b batch, took time.Duration, throttled bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	prev := idx.batchBytes
+	switch {
+	case throttled || took > 2*targetLatency:
+		idx.batchBytes = max(idx.batchBytes/2, idx.minBatch)
+	case b.full && took < targetLatency:
+		idx.batchBytes = min(idx.batchBytes+idx.batchBytes/4, idx.maxBatch)
+	}
+	if idx.batchBytes != prev {
+		slog.Debug("indexer: batch size changed", "bytes", idx.batchBytes, "took", took, "throttled", throttled)
+	}
+}
+
+// bulk indexes items and acks, retries or dead-letters each one. It reports
+// whether OpenSearch throttled the request.
+func (idx *Indexer) bulk(items []bulkItem) bool {
+	items = idx.ensureWriteIndices(items)
+	if len(items) == 0 {
+		return false
 	}
-	items := idx.buffer
-	idx.buffer = make([]bulkItem, 0, defaultBatchSize)
-	idx.mu.Unlock()
 
 	var body strings.Builder
 	for _, item := range items {
@@ -136,7 +300,7 @@ func (idx *Indexer) flush() {
 		body.WriteByte('\n')
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), bulkTimeout)
 	defer cancel()
 
 	resp, err := idx.client.Bulk(ctx, opensearchapi.BulkReq{
@@ -144,20 +308,161 @@ func (idx *Indexer) flush() {
 	})
 
 	if err != nil {
-		slog.Error("bulk index failed", "error", err, "count", len(items))
+		retryable, throttled := false, false
+		if resp != nil && resp.Inspect().Response != nil {
+			status := resp.Inspect().Response.StatusCode
+			retryable = status == http.StatusTooManyRequests || status >= 500
+			throttled = status == http.StatusTooManyRequests
+		}
+		slog.Error("bulk index failed", "error", err, "count", len(items), "retryable", retryable)
 		for _, item := range items {
-			item.msg.Nak()
+			idx.stats.failed(item.tenantID, "bulk_request", !lastDelivery(item.msg))
+			if retryable {
+				idx.pub.Retry(item.msg, stageName, fmt.Errorf("bulk: %w", err), backoff(item.msg))
+			} else {
+				idx.pub.Fail(item.msg, stageName, fmt.Errorf("bulk: %w", err))
+			}
 		}
-		return
+		return throttled
 	}
 
-	if resp.Errors {
-		slog.Warn("bulk index had errors", "count", len(items))
+	// Items come back in request order. A response that does not match the
+	// request cannot be attributed, so everything is retried.
+	if len(resp.Items) != len(items) {
+		slog.Error("bulk response item count mismatch", "sent", len(items), "received", len(resp.Items))
+		for _, item := range items {
+			idx.stats.failed(item.tenantID, "bulk_response", !lastDelivery(item.msg))
+			idx.pub.Retry(item.msg, stageName, fmt.Errorf("bulk: %d items in response to %d", len(resp.Items), len(items)), backoff(item.msg))
+		}
+		return false
 	}
 
+	var retried, rejected int
+	throttled := false
+	for i, item := range items {
+		itemErr := itemError(resp.Items[i])
+		switch {
+		case itemErr == nil:
+			idx.stats.indexed(item.tenantID)
+			item.msg.Ack()
+		case itemErr.MissingWriteIndex():
+			// The tenant's indices changed since its write alias was set
+			// up; set it up again before the retry.
+			retried++
+			idx.Forget(item.tenantID)
+			idx.stats.failed(item.tenantID, itemErr.Type, !lastDelivery(item.msg))
+			idx.pub.Retry(item.msg, stageName, itemErr, backoff(item.msg))
+		case itemErr.Retryable():
+			retried++
+			throttled = throttled || itemErr.Status == http.StatusTooManyRequests
+			idx.stats.failed(item.tenantID, itemErr.Type, !lastDelivery(item.msg))
+			idx.pub.Retry(item.msg, stageName, itemErr, backoff(item.msg))
+		default:
+			rejected++
+			idx.stats.failed(item.tenantID, itemErr.Type, false)
+			idx.pub.Reject(item.msg, stageName, itemErr)
+		}
+	}
+
+	if retried > 0 || rejected > 0 {
+		slog.Warn("bulk index had errors", "count", len(items), "retried", retried, "rejected", rejected)
+	}
+	slog.Debug("bulk indexed", "count", len(items)-retried-rejected)
+	return throttled
+}
+
+// ensureWriteIndices bootstraps the write alias of each tenant in items the
+// first time the indexer sees it, or again after Forget, placing a new
+// tenant per placement; indexing into a missing alias would create a plain
+// index by that name. Items of tenants that could not be set up are
+// retried and left out of the result.
+func (idx *Indexer) ensureWriteIndices(items []bulkItem) []bulkItem {
+	failed := make(map[string]error)
 	for _, item := range items {
-		item.msg.Ack()
+		if _, ok := idx.ready.Load(item.tenantID); ok {
+			continue
+		}
+		if _, ok := failed[item.tenantID]; ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), bulkTimeout)
+		err := idx.ensureWriteIndex(ctx, item.tenantID)
+		cancel()
+		if err != nil {
+			slog.Error("indexer: failed to set up write index", "tenant_id", item.tenantID, "error", err)
+			failed[item.tenantID] = err
+			continue
+		}
+		idx.ready.Store(item.tenantID, struct{}{})
+	}
+	if len(failed) == 0 {
+		return items
 	}
 
-	slog.Debug("bulk indexed", "count", len(items))
+	ok := make([]bulkItem, 0, len(items))
+	for _, item := range items {
+		err, bad := failed[item.tenantID]
+		if !bad {
+			ok = append(ok, item)
+			continue
+		}
+		idx.stats.failed(item.tenantID, "write_index", !lastDelivery(item.msg))
+		idx.pub.Retry(item.msg, stageName, err, backoff(item.msg))
+	}
+	return ok
+}
+
+func (idx *Indexer) ensureWriteIndex(ctx context.Context, tenantID string) error {
+	placement, err := idx.placement(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("placement: %w", err)
+	}
+	return EnsureWriteIndex(ctx, idx.client, tenantID, placement)
+}
+
+// Forget makes the indexer check tenantID's write alias again, and set it
+// up if missing, before indexing its next events. An empty tenantID
+// forgets every tenant.
+func (idx *Indexer) Forget(tenantID string) {
+	if tenantID == "" {
+		idx.ready.Clear()
+		return
+	}
+	idx.ready.Delete(tenantID)
+}
+
+// IndicesChangedSubject is where changes to a tenant's indices made outside
+// the indexer, such as a move or an index deletion, are announced with the
+// tenant ID as payload, or none for the shared indices. Indexers forget the
+// tenant on receipt.
+const IndicesChangedSubject = "opensearch.indices.changed"
+
+// AnnounceIndicesChanged tells running indexers that tenantID's indices
+// changed; see IndicesChangedSubject.
+func AnnounceIndicesChanged(nc *nats.Conn, tenantID string) error {
+	if err := nc.Publish(IndicesChangedSubject, []byte(tenantID)); err != nil {
+		return fmt.Errorf("publish %s: %w", IndicesChangedSubject, err)
+	}
+	return nc.Flush()
+}
+
+// Stats returns indexing counters per tenant since the indexer started.
+func (idx *Indexer) Stats() map[string]TenantIndexStats {
+	return idx.stats.snapshot()
+}
+
+// lastDelivery reports whether a failure dead-letters msg rather than
+// redelivering it.
+func lastDelivery(msg *nats.Msg) bool {
+	meta, err := msg.Metadata()
+	return err == nil && meta.NumDelivered >= bus.MaxDeliver
+}
+
+// backoff returns the redelivery delay for a message's next attempt.
+func backoff(msg *nats.Msg) time.Duration {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered < 1 {
+		return retryBase
+	}
+	return retryBase << min(meta.NumDelivered-1, 6)
 }
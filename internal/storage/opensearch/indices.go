@@ -2,19 +2,18 @@ package opensearch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 )
 
-// IndexName returns the per-tenant, date-partitioned index name.
-func IndexName(tenantID string, ts time.Time) string {
-	return fmt.Sprintf("mintlog-%s-%s", tenantID, ts.Format("2006.01.02"))
-}
-
-// EnsureIndex creates the index if it doesn't exist (the template handles mappings).
-func EnsureIndex(ctx context.Context, client *opensearchapi.Client, name string) error {
+// EnsureIndex creates the index if it doesn't exist (the template handles
+// mappings), adding it to aliases.
+func EnsureIndex(ctx context.Context, client *opensearchapi.Client, name string, aliases ...string) error {
 	resp, err := client.Indices.Exists(ctx, opensearchapi.IndicesExistsReq{
 		Indices: []string{name},
 	})
@@ -22,11 +21,191 @@ func EnsureIndex(ctx context.Context, client *opensearchapi.Client, name string)
 		return nil
 	}
 
-	_, err = client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{
-		Index: name,
-	})
+	req := opensearchapi.IndicesCreateReq{Index: name}
+	if len(aliases) > 0 {
+		body := map[string]map[string]any{"aliases": {}}
+		for _, a := range aliases {
+			body["aliases"][a] = map[string]any{}
+		}
+		b, _ := json.Marshal(body)
+		req.Body = strings.NewReader(string(b))
+	}
+	_, err = client.Indices.Create(ctx, req)
 	if err != nil {
 		return fmt.Errorf("create index %s: %w", name, err)
 	}
 	return nil
 }
+
+// IndexDate returns the day of a date-partitioned index name, as used
+// before indices were rolled over behind aliases.
+func IndexDate(name string) (time.Time, bool) {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return time.Time{}, false
+	}
+	day, err := time.Parse("2006.01.02", name[i+1:])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// NewestEvents returns the latest event timestamp in each non-empty index
+// matching pattern.
+func NewestEvents(ctx context.Context, client *opensearchapi.Client, pattern string) (map[string]time.Time, error) {
+	body := `{"size": 0, "aggs": {"indices": {"terms": {"field": "_index", "size": 10000}, "aggs": {"newest": {"max": {"field": "timestamp"}}}}}}`
+	resp, err := client.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{pattern},
+		Body:    strings.NewReader(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("newest events %s: %w", pattern, err)
+	}
+
+	var aggs struct {
+		Indices struct {
+			Buckets []struct {
+				Key    string `json:"key"`
+				Newest struct {
+					Value *float64 `json:"value"`
+				} `json:"newest"`
+			} `json:"buckets"`
+		} `json:"indices"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("decode newest events: %w", err)
+	}
+	newest := make(map[string]time.Time, len(aggs.Indices.Buckets))
+	for _, b := range aggs.Indices.Buckets {
+		if b.Newest.Value != nil {
+			newest[b.Key] = time.UnixMilli(int64(*b.Newest.Value)).UTC()
+		}
+	}
+	return newest, nil
+}
+
+// IndexStats describes one index's size and lifecycle state.
+type IndexStats struct {
+	Name          string
+	Docs          int64
+	Bytes         int64 // including replicas
+	PrimaryBytes  int64
+	Shards        int
+	Segments      int // primary segments
+	ReadOnly      bool
+	CreatedMillis int64
+}
+
+// ListIndices returns the indices matching pattern, sorted by name.
+func ListIndices(ctx context.Context, client *opensearchapi.Client, pattern string) ([]IndexStats, error) {
+	resp, err := client.Cat.Indices(ctx, &opensearchapi.CatIndicesReq{
+		Indices: []string{pattern},
+		Params: opensearchapi.CatIndicesParams{
+			Bytes: "b",
+			H:     []string{"index", "pri", "docs.count", "store.size", "pri.store.size", "pri.segments.count", "creation.date"},
+			Sort:  []string{"index"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list indices %s: %w", pattern, err)
+	}
+
+	stats := make([]IndexStats, 0, len(resp.Indices))
+	for _, idx := range resp.Indices {
+		s := IndexStats{
+			Name:          idx.Index,
+			Docs:          int64(deref(idx.DocsCount)),
+			Bytes:         parseBytes(idx.StoreSize),
+			PrimaryBytes:  parseBytes(idx.PrimaryStoreSize),
+			Shards:        deref(idx.Primary),
+			Segments:      deref(idx.PrimarySegmentsCount),
+			CreatedMillis: int64(idx.CreationDate),
+		}
+		stats = append(stats, s)
+	}
+	if len(stats) == 0 {
+		return stats, nil
+	}
+
+	blocked, err := writeBlocked(ctx, client, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].ReadOnly = blocked[stats[i].Name]
+	}
+	return stats, nil
+}
+
+func writeBlocked(ctx context.Context, client *opensearchapi.Client, pattern string) (map[string]bool, error) {
+	flat := true
+	resp, err := client.Indices.Settings.Get(ctx, &opensearchapi.SettingsGetReq{
+		Indices:  []string{pattern},
+		Settings: []string{"index.blocks.write"},
+		Params:   opensearchapi.SettingsGetParams{FlatSettings: &flat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get settings %s: %w", pattern, err)
+	}
+	blocked := make(map[string]bool, len(resp.Indices))
+	for name, idx := range resp.Indices {
+		var settings map[string]string
+		if err := json.Unmarshal(idx.Settings, &settings); err == nil {
+			blocked[name] = settings["index.blocks.write"] == "true"
+		}
+	}
+	return blocked, nil
+}
+
+// DeleteIndex deletes an index. A missing index is not an error.
+func DeleteIndex(ctx context.Context, client *opensearchapi.Client, name string) error {
+	resp, err := client.Indices.Delete(ctx, opensearchapi.IndicesDeleteReq{Indices: []string{name}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == 404 {
+			return nil
+		}
+		return fmt.Errorf("delete index %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetReadOnly blocks writes to an index. Reads and deletion still work.
+func SetReadOnly(ctx context.Context, client *opensearchapi.Client, name string) error {
+	_, err := client.Indices.Settings.Put(ctx, opensearchapi.SettingsPutReq{
+		Indices: []string{name},
+		Body:    strings.NewReader(`{"index": {"blocks": {"write": true}}}`),
+	})
+	if err != nil {
+		return fmt.Errorf("set %s read-only: %w", name, err)
+	}
+	return nil
+}
+
+// ForceMerge merges each shard of an index down to maxSegments segments. It
+// blocks until the merge finishes.
+func ForceMerge(ctx context.Context, client *opensearchapi.Client, name string, maxSegments int) error {
+	_, err := client.Indices.Forcemerge(ctx, &opensearchapi.IndicesForcemergeReq{
+		Indices: []string{name},
+		Params:  opensearchapi.IndicesForcemergeParams{MaxNumSegments: &maxSegments},
+	})
+	if err != nil {
+		return fmt.Errorf("force-merge %s: %w", name, err)
+	}
+	return nil
+}
+
+func deref(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func parseBytes(s *string) int64 {
+	if s == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(*s, 10, 64)
+	return n
+}
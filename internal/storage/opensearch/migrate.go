@@ -0,0 +1,237 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// MigrateReport says what Migrate changed.
+type MigrateReport struct {
+	Templates  int      `json:"templates"`   // templates written
+	Updated    []string `json:"updated"`     // indices brought up to date in place
+	RolledOver []string `json:"rolled_over"` // write indices replaced by a new index
+	Reindexed  []string `json:"reindexed"`   // indices copied to a new index and deleted
+	Backfilled []string `json:"backfilled"`  // indices whose events were indexed again for new fields
+	Pending    []string `json:"pending"`     // indices still drifting
+}
+
+// Migrate writes the current templates and brings the event indices up to
+// date, given the overrides by tenant ID.
+//
+// Replicas, refresh interval and new field mappings are applied in place.
+// New fields only cover events indexed from then on; with reindex, the
+// events already in the index are indexed again. An index whose shard
+// count or field mappings differ needs a new index: a drifting write index
+// is rolled over, so new events follow the template at once, and the
+// others are left to age out. With reindex, a tenant's other dedicated
+// indices are instead copied into new backing indices and deleted; the
+// shared indices and an index just rolled over are not, and stay pending.
+func Migrate(ctx context.Context, client *opensearchapi.Client, overrides map[string]IndexOverrides, reindex bool) (*MigrateReport, error) {
+	report := &MigrateReport{Updated: []string{}, RolledOver: []string{}, Reindexed: []string{}, Backfilled: []string{}, Pending: []string{}}
+
+	n, err := PutTemplates(ctx, client, overrides)
+	report.Templates = n
+	if err != nil {
+		return report, err
+	}
+
+	drift, err := indexDrift(ctx, client, overrides)
+	if err != nil {
+		return report, err
+	}
+	writes, err := WriteIndices(ctx, client, "mintlog-*-write")
+	if err != nil {
+		return report, err
+	}
+	blocked, err := writeBlocked(ctx, client, "mintlog-*")
+	if err != nil {
+		return report, err
+	}
+
+	byTenant := make(map[string][]Drift)
+	for _, d := range drift {
+		byTenant[d.TenantID] = append(byTenant[d.TenantID], d)
+	}
+	current := CurrentTemplate()
+	for _, tenantID := range sortedKeys(byTenant) {
+		var o *IndexOverrides
+		if ov, ok := overrides[tenantID]; ok {
+			o = &ov
+		}
+		settings, mappings := current.index(o)
+
+		var stale []string
+		for _, d := range byTenant[tenantID] {
+			if !d.Reindex {
+				err := updateIndex(ctx, client, d.Name, settings, mappings)
+				if err == nil {
+					report.Updated = append(report.Updated, d.Name)
+					if d.Backfill && reindex {
+						if err := backfill(ctx, client, d.Name, blocked[d.Name]); err != nil {
+							return report, err
+						}
+						report.Backfilled = append(report.Backfilled, d.Name)
+					}
+					continue
+				}
+				if !isErrorType(err, "illegal_argument_exception") {
+					return report, err
+				}
+				slog.Warn("index needs a new index", "index", d.Name, "error", err)
+			}
+			stale = append(stale, d.Name)
+		}
+		if len(stale) == 0 {
+			continue
+		}
+
+		if tenantID == "" {
+			err = migrateShared(ctx, client, stale, writes[SharedWriteAlias], report)
+		} else {
+			err = migrateTenant(ctx, client, tenantID, stale, writes[WriteAlias(tenantID)], reindex, report)
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// updateIndex applies the dynamic settings and the mappings in place.
+func updateIndex(ctx context.Context, client *opensearchapi.Client, name string, settings, mappings map[string]any) error {
+	flat := flatten(settings)
+	body, _ := json.Marshal(map[string]any{"index": map[string]any{
+		"number_of_replicas": flat["index.number_of_replicas"],
+		"refresh_interval":   flat["index.refresh_interval"],
+	}})
+	_, err := client.Indices.Settings.Put(ctx, opensearchapi.SettingsPutReq{
+		Indices: []string{name},
+		Body:    strings.NewReader(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("update settings of %s: %w", name, err)
+	}
+
+	body, _ = json.Marshal(mappings)
+	_, err = client.Indices.Mapping.Put(ctx, opensearchapi.MappingPutReq{
+		Indices: []string{name},
+		Body:    strings.NewReader(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("update mappings of %s: %w", name, err)
+	}
+	return nil
+}
+
+// backfill indexes an index's events again in place, so fields mapped
+// since they were indexed cover them. A read-only index is made writable
+// for the time it takes.
+func backfill(ctx context.Context, client *opensearchapi.Client, name string, readOnly bool) error {
+	if readOnly {
+		_, err := client.Indices.Settings.Put(ctx, opensearchapi.SettingsPutReq{
+			Indices: []string{name},
+			Body:    strings.NewReader(`{"index": {"blocks": {"write": false}}}`),
+		})
+		if err != nil {
+			return fmt.Errorf("unblock %s: %w", name, err)
+		}
+		defer func() {
+			if err := SetReadOnly(ctx, client, name); err != nil {
+				slog.Error("failed to make index read-only again", "index", name, "error", err)
+			}
+		}()
+	}
+
+	resp, err := client.UpdateByQuery(ctx, opensearchapi.UpdateByQueryReq{
+		Indices: []string{name},
+		Params:  opensearchapi.UpdateByQueryParams{Conflicts: "proceed"},
+	})
+	if err != nil {
+		return fmt.Errorf("backfill %s: %w", name, err)
+	}
+	if len(resp.Failures) > 0 {
+		return fmt.Errorf("backfill %s: %d failures", name, len(resp.Failures))
+	}
+	slog.Info("index backfilled", "index", name, "docs", resp.Updated)
+	return nil
+}
+
+// migrateShared rolls the shared indices over if their write index is
+// stale. Shared indices are never reindexed.
+func migrateShared(ctx context.Context, client *opensearchapi.Client, stale []string, write string, report *MigrateReport) error {
+	for _, name := range stale {
+		if name == write {
+			if _, err := rolloverTo(ctx, client, SharedWriteAlias, "", nil, nil); err != nil {
+				return err
+			}
+			if _, err := SyncShared(ctx, client); err != nil {
+				return err
+			}
+			report.RolledOver = append(report.RolledOver, name)
+		}
+		report.Pending = append(report.Pending, name)
+	}
+	return nil
+}
+
+// migrateTenant replaces a tenant's stale dedicated indices. Copies get
+// new backing index numbers, so the write alias is rolled over after them
+// to keep the write index the highest: rollover names the next index after
+// it.
+func migrateTenant(ctx context.Context, client *opensearchapi.Client, tenantID string, stale []string, write string, reindex bool, report *MigrateReport) error {
+	sort.Strings(stale)
+	writeStale := false
+	copied := false
+	for _, name := range stale {
+		if name == write {
+			writeStale = true
+			continue
+		}
+		if !reindex {
+			report.Pending = append(report.Pending, name)
+			continue
+		}
+
+		dest, err := nextBackingIndex(ctx, client, tenantID)
+		if err != nil {
+			return err
+		}
+		if err := EnsureIndex(ctx, client, dest, ReadAlias(tenantID)); err != nil {
+			return err
+		}
+		n, err := copyEvents(ctx, client, name, nil, dest, "")
+		if err != nil {
+			return err
+		}
+		if err := DeleteIndex(ctx, client, name); err != nil {
+			return err
+		}
+		copied = true
+		report.Reindexed = append(report.Reindexed, name)
+		slog.Info("index reindexed", "index", name, "into", dest, "docs", n)
+	}
+
+	// A tenant on the shared indices has no backing index to keep last.
+	if write == "" || SharedIndex(write) || !writeStale && !copied {
+		return nil
+	}
+	next, err := nextBackingIndex(ctx, client, tenantID)
+	if err != nil {
+		return err
+	}
+	aliases := map[string]any{ReadAlias(tenantID): map[string]any{}}
+	if _, err := rolloverTo(ctx, client, WriteAlias(tenantID), next, aliases, nil); err != nil {
+		return err
+	}
+	report.RolledOver = append(report.RolledOver, write)
+	if writeStale {
+		report.Pending = append(report.Pending, write)
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// Placement says where a tenant's events are indexed.
+type Placement string
+
+const (
+	// PlacementDedicated gives a tenant backing indices of its own.
+	PlacementDedicated Placement = "dedicated"
+	// PlacementShared writes a tenant's events to the shared indices, routed
+	// by tenant ID. Its aliases filter on tenant_id, so searches through
+	// them only see the tenant's events.
+	PlacementShared Placement = "shared"
+)
+
+// PlacementFor returns the placement for a new tenant on plan: shared if
+// the plan is one of sharedPlans.
+func PlacementFor(plan string, sharedPlans []string) Placement {
+	if slices.Contains(sharedPlans, plan) {
+		return PlacementShared
+	}
+	return PlacementDedicated
+}
+
+// The shared indices, mintlog-shared-NNNNNN, are rolled over behind their
+// own write alias like a tenant's. They never match a tenant's pattern.
+const (
+	SharedWriteAlias = "mintlog-shared-write"
+	SharedPattern    = "mintlog-shared-*"
+
+	firstSharedIndex = "mintlog-shared-000001"
+)
+
+// SharedIndex reports whether name is one of the shared indices.
+func SharedIndex(name string) bool {
+	return strings.HasPrefix(name, "mintlog-shared-")
+}
+
+// sharedAliasActions puts a tenant's aliases on a shared index. Writes are
+// routed by tenant ID, so each tenant's events sit on one shard.
+func sharedAliasActions(tenantID, index string) []map[string]any {
+	filter := map[string]any{"term": map[string]any{"tenant_id": tenantID}}
+	return []map[string]any{
+		{"add": map[string]any{"index": index, "alias": WriteAlias(tenantID), "is_write_index": true, "index_routing": tenantID, "filter": filter}},
+		{"add": map[string]any{"index": index, "alias": ReadAlias(tenantID), "search_routing": tenantID, "filter": filter}},
+	}
+}
+
+// ensureSharedIndex returns the shared write index, creating the first one
+// if there is none.
+func ensureSharedIndex(ctx context.Context, client *opensearchapi.Client) (string, error) {
+	writes, err := WriteIndices(ctx, client, SharedWriteAlias)
+	if err != nil {
+		return "", err
+	}
+	if index := writes[SharedWriteAlias]; index != "" {
+		return index, nil
+	}
+
+	body := fmt.Sprintf(`{"aliases": {%q: {"is_write_index": true}}}`, SharedWriteAlias)
+	_, err = client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{
+		Index: firstSharedIndex,
+		Body:  strings.NewReader(body),
+	})
+	if err != nil && !isErrorType(err, "resource_already_exists_exception") {
+		return "", fmt.Errorf("create index %s: %w", firstSharedIndex, err)
+	}
+	writes, err = WriteIndices(ctx, client, SharedWriteAlias)
+	if err != nil {
+		return "", err
+	}
+	return writes[SharedWriteAlias], nil
+}
+
+// joinShared puts a tenant without aliases on the shared write index.
+func joinShared(ctx context.Context, client *opensearchapi.Client, tenantID string) error {
+	index, err := ensureSharedIndex(ctx, client)
+	if err != nil {
+		return err
+	}
+	if index == "" {
+		return fmt.Errorf("%s has no write index", SharedWriteAlias)
+	}
+	return updateAliases(ctx, client, sharedAliasActions(tenantID, index))
+}
+
+// RolloverShared rolls the shared indices over if the write index meets any
+// condition, then moves every shared tenant onto the new index. It returns
+// the new index, or "" if nothing rolled over.
+func RolloverShared(ctx context.Context, client *opensearchapi.Client, cond RolloverConditions) (string, error) {
+	newIndex, err := rollover(ctx, client, SharedWriteAlias, nil, cond)
+	if err != nil || newIndex == "" {
+		return "", err
+	}
+	if _, err := SyncShared(ctx, client); err != nil {
+		return newIndex, err
+	}
+	return newIndex, nil
+}
+
+// SyncShared moves the write alias of every tenant still writing to an
+// older shared index onto the current one, adding its read alias there. It
+// returns how many tenants moved.
+func SyncShared(ctx context.Context, client *opensearchapi.Client) (int, error) {
+	current, err := WriteIndices(ctx, client, SharedWriteAlias)
+	if err != nil {
+		return 0, err
+	}
+	index := current[SharedWriteAlias]
+	if index == "" {
+		return 0, nil
+	}
+	writes, err := TenantWriteIndices(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+
+	var actions []map[string]any
+	moved := 0
+	for tenantID, from := range writes {
+		if !SharedIndex(from) || from == index {
+			continue
+		}
+		actions = append(actions, map[string]any{"remove": map[string]string{"index": from, "alias": WriteAlias(tenantID)}})
+		actions = append(actions, sharedAliasActions(tenantID, index)...)
+		moved++
+	}
+	if err := updateAliases(ctx, client, actions); err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// SharedTenants returns the tenants with events in the shared indices:
+// those placed there, and those moved off without their events.
+func SharedTenants(ctx context.Context, client *opensearchapi.Client) (map[string]bool, error) {
+	resp, err := client.Indices.Alias.Get(ctx, opensearchapi.AliasGetReq{Indices: []string{SharedPattern}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == 404 {
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("get aliases of %s: %w", SharedPattern, err)
+	}
+	tenants := make(map[string]bool)
+	for _, info := range resp.Indices {
+		for alias := range info.Aliases {
+			if strings.HasSuffix(alias, "-read") {
+				tenants[strings.TrimSuffix(strings.TrimPrefix(alias, "mintlog-"), "-read")] = true
+			}
+		}
+	}
+	return tenants, nil
+}
+
+// DeleteSharedEvents deletes a tenant's events older than before from the
+// shared indices, or all of them if before is zero. It returns how many
+// were deleted.
+func DeleteSharedEvents(ctx context.Context, client *opensearchapi.Client, tenantID string, before time.Time) (int, error) {
+	filter := []map[string]any{{"term": map[string]any{"tenant_id": tenantID}}}
+	if !before.IsZero() {
+		filter = append(filter, map[string]any{"range": map[string]any{"timestamp": map[string]any{"lt": before.UTC().Format(time.RFC3339)}}})
+	}
+	body, _ := json.Marshal(map[string]any{"query": map[string]any{"bool": map[string]any{"filter": filter}}})
+
+	resp, err := client.Document.DeleteByQuery(ctx, opensearchapi.DocumentDeleteByQueryReq{
+		Indices: []string{SharedPattern},
+		Body:    strings.NewReader(string(body)),
+		Params:  opensearchapi.DocumentDeleteByQueryParams{Routing: []string{tenantID}, Conflicts: "proceed"},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("delete events of %s: %w", tenantID, err)
+	}
+	return resp.Deleted, nil
+}
+
+// MoveTenant switches a tenant's placement. New events go to the new
+// placement at once; existing events stay searchable where they are and
+// age out there, unless reindex is set, in which case they are copied over
+// and removed from the old placement. It returns how many events were
+// copied.
+func MoveTenant(ctx context.Context, client *opensearchapi.Client, tenantID string, to Placement, reindex bool) (int, error) {
+	from, err := WriteIndex(ctx, client, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if from == "" {
+		return 0, EnsureWriteIndex(ctx, client, tenantID, to)
+	}
+	if SharedIndex(from) == (to == PlacementShared) {
+		return 0, nil
+	}
+
+	if to == PlacementShared {
+		return toShared(ctx, client, tenantID, from, reindex)
+	}
+	return toDedicated(ctx, client, tenantID, from, reindex)
+}
+
+func toShared(ctx context.Context, client *opensearchapi.Client, tenantID, from string, reindex bool) (int, error) {
+	shared, err := ensureSharedIndex(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+	actions := []map[string]any{{"remove": map[string]string{"index": from, "alias": WriteAlias(tenantID)}}}
+	if err := updateAliases(ctx, client, append(actions, sharedAliasActions(tenantID, shared)...)); err != nil {
+		return 0, err
+	}
+	slog.Info("tenant moved to shared indices", "tenant_id", tenantID, "index", shared)
+	if !reindex {
+		return 0, nil
+	}
+
+	indices, err := ListIndices(ctx, client, TenantPattern(tenantID))
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for _, idx := range indices {
+		if _, daily := IndexDate(idx.Name); !daily && !BackingIndex(idx.Name) {
+			continue
+		}
+		n, err := copyEvents(ctx, client, idx.Name, nil, shared, tenantID)
+		copied += n
+		if err != nil {
+			return copied, err
+		}
+		if err := DeleteIndex(ctx, client, idx.Name); err != nil {
+			return copied, err
+		}
+		slog.Info("index moved to shared indices", "index", idx.Name, "docs", n)
+	}
+	return copied, nil
+}
+
+func toDedicated(ctx context.Context, client *opensearchapi.Client, tenantID, from string, reindex bool) (int, error) {
+	index, err := nextBackingIndex(ctx, client, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{Index: index}); err != nil {
+		return 0, fmt.Errorf("create index %s: %w", index, err)
+	}
+	err = updateAliases(ctx, client, []map[string]any{
+		{"remove": map[string]string{"index": from, "alias": WriteAlias(tenantID)}},
+		{"add": map[string]any{"index": index, "alias": WriteAlias(tenantID), "is_write_index": true}},
+		{"add": map[string]any{"index": index, "alias": ReadAlias(tenantID)}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("tenant moved to dedicated indices", "tenant_id", tenantID, "index", index)
+	if !reindex {
+		return 0, nil
+	}
+
+	query := map[string]any{"term": map[string]any{"tenant_id": tenantID}}
+	copied, err := copyEvents(ctx, client, SharedPattern, query, index, "")
+	if err != nil {
+		return copied, err
+	}
+	aliased, err := Aliased(ctx, client, SharedPattern, ReadAlias(tenantID))
+	if err != nil {
+		return copied, err
+	}
+	var actions []map[string]any
+	for name, ok := range aliased {
+		if ok {
+			actions = append(actions, map[string]any{"remove": map[string]string{"index": name, "alias": ReadAlias(tenantID)}})
+		}
+	}
+	if err := updateAliases(ctx, client, actions); err != nil {
+		return copied, err
+	}
+	if _, err := DeleteSharedEvents(ctx, client, tenantID, time.Time{}); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+// nextBackingIndex names the backing index after the tenant's highest.
+func nextBackingIndex(ctx context.Context, client *opensearchapi.Client, tenantID string) (string, error) {
+	indices, err := ListIndices(ctx, client, TenantPattern(tenantID))
+	if err != nil {
+		return "", err
+	}
+	last := 0
+	for _, idx := range indices {
+		if BackingIndex(idx.Name) {
+			n, _ := strconv.Atoi(idx.Name[len(idx.Name)-6:])
+			last = max(last, n)
+		}
+	}
+	return fmt.Sprintf("mintlog-%s-%06d", tenantID, last+1), nil
+}
+
+// copyEvents reindexes the documents of source matching query (all if nil)
+// into dest, routed by routing if set. Documents already in dest are left
+// alone. It fails unless every document was copied or already there.
+func copyEvents(ctx context.Context, client *opensearchapi.Client, source string, query map[string]any, dest, routing string) (int, error) {
+	src := map[string]any{"index": source}
+	if query != nil {
+		src["query"] = query
+	}
+	dst := map[string]any{"index": dest, "op_type": "create"}
+	if routing != "" {
+		dst["routing"] = "=" + routing
+	}
+	body, _ := json.Marshal(map[string]any{"source": src, "dest": dst, "conflicts": "proceed"})
+
+	resp, err := client.Reindex(ctx, opensearchapi.ReindexReq{Body: strings.NewReader(string(body))})
+	if err != nil {
+		return 0, fmt.Errorf("reindex %s into %s: %w", source, dest, err)
+	}
+	if len(resp.Failures) > 0 || resp.Created+resp.VersionConflicts != resp.Total {
+		return resp.Created, fmt.Errorf("reindex %s into %s: copied %d of %d documents", source, dest, resp.Created+resp.VersionConflicts, resp.Total)
+	}
+	return resp.Created, nil
+}
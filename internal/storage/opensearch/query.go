@@ -0,0 +1,208 @@
+package opensearch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+)
+
+// boolQuery compiles a log store query to OpenSearch DSL.
+func boolQuery(q *logstore.Query) map[string]any {
+	must := []map[string]any{
+		{"term": map[string]any{"tenant_id": q.TenantID}},
+	}
+
+	if q.Text != "" {
+		must = append(must, textQuery(q))
+	}
+	if q.Level != "" {
+		must = append(must, map[string]any{"term": map[string]any{"level": q.Level}})
+	}
+	if q.Service != "" {
+		must = append(must, map[string]any{"term": map[string]any{"service": q.Service}})
+	}
+	if q.Host != "" {
+		must = append(must, map[string]any{"term": map[string]any{"host": q.Host}})
+	}
+	if len(q.TraceIDs) > 0 {
+		must = append(must, map[string]any{"terms": map[string]any{"trace_id": q.TraceIDs}})
+	}
+	for field, value := range q.Fields {
+		must = append(must, map[string]any{"term": map[string]any{field: value}})
+	}
+	if q.Expr != nil {
+		must = append(must, q.Expr.DSL())
+	}
+
+	timeRange := map[string]any{}
+	if !q.From.IsZero() {
+		timeRange["gte"] = q.From.Format(time.RFC3339Nano)
+	}
+	if !q.To.IsZero() {
+		timeRange["lte"] = q.To.Format(time.RFC3339Nano)
+	}
+	if !q.After.IsZero() {
+		timeRange["gt"] = q.After.Format(time.RFC3339Nano)
+	}
+	if len(timeRange) > 0 {
+		must = append(must, map[string]any{
+			"range": map[string]any{"timestamp": timeRange},
+		})
+	}
+
+	return map[string]any{
+		"bool": map[string]any{"must": must},
+	}
+}
+
+// textQuery matches the message by q.Text. Words and phrases are matched
+// on the analyzed message; the other modes on message.wildcard, which
+// holds the whole message.
+func textQuery(q *logstore.Query) map[string]any {
+	switch q.Match {
+	case logstore.MatchPhrase:
+		return map[string]any{"match_phrase": map[string]any{"message": q.Text}}
+	case logstore.MatchExact:
+		return onWildcardField(
+			map[string]any{"term": map[string]any{wildcardField: q.Text}},
+			map[string]any{"match_phrase": map[string]any{"message": q.Text}})
+	case logstore.MatchPrefix:
+		return onWildcardField(
+			map[string]any{"prefix": map[string]any{wildcardField: q.Text}},
+			map[string]any{"match_phrase_prefix": map[string]any{"message": q.Text}})
+	case logstore.MatchWildcard:
+		return onWildcardField(
+			map[string]any{"wildcard": map[string]any{wildcardField: q.Text}},
+			map[string]any{"wildcard": map[string]any{"message": map[string]any{"value": q.Text, "case_insensitive": true}}})
+	case logstore.MatchRegex:
+		// Without the optional operators, the syntax is the one the
+		// other backends check and match with.
+		return onWildcardField(
+			map[string]any{"regexp": map[string]any{wildcardField: map[string]any{"value": q.Text, "flags": "NONE"}}},
+			map[string]any{"regexp": map[string]any{"message": map[string]any{"value": q.Text, "flags": "NONE", "case_insensitive": true}}})
+	}
+	match := map[string]any{"query": q.Text}
+	if !q.AnyWord {
+		match["operator"] = "and"
+	}
+	return map[string]any{"match": map[string]any{"message": match}}
+}
+
+// wildcardField holds whole messages for the exact, prefix, wildcard and
+// regex modes. Templates map it from v2 on.
+const wildcardField = "message.wildcard"
+
+// onWildcardField runs query on wildcardField, and fallback on the words
+// of the message for events without it: in indices created before v2, or
+// indexed before the field was added and not yet backfilled. Those are
+// then found approximately rather than not at all.
+func onWildcardField(query, fallback map[string]any) map[string]any {
+	return map[string]any{"bool": map[string]any{
+		"should": []map[string]any{
+			query,
+			{"bool": map[string]any{
+				"filter":   []map[string]any{fallback},
+				"must_not": []map[string]any{{"exists": map[string]any{"field": wildcardField}}},
+			}},
+		},
+		"minimum_should_match": 1,
+	}}
+}
+
+// searchBody builds a search for a page of events.
+func searchBody(q *logstore.Query, opts logstore.SearchOptions) map[string]any {
+	order := "desc"
+	if opts.Asc {
+		order = "asc"
+	}
+	body := map[string]any{
+		"query": boolQuery(q),
+		"size":  opts.Size,
+		"sort": []map[string]any{
+			{"timestamp": map[string]any{"order": order}},
+			{"id": map[string]any{"order": order}},
+		},
+	}
+	if len(opts.SearchAfter) > 0 {
+		body["search_after"] = opts.SearchAfter
+	}
+	return body
+}
+
+// aggregateBody builds the aggregations for agg. Their names are decoded
+// by decodeAggregations.
+func aggregateBody(q *logstore.Query, agg *logstore.Aggregation) map[string]any {
+	sub := map[string]any{}
+	if agg.Weighted {
+		sub["estimated_count"] = estimatedCountAgg
+	}
+
+	aggs := map[string]any{}
+	groupBy := agg.GroupBy
+	if groupBy == "" && agg.Interval == 0 {
+		groupBy = "level"
+	}
+	if groupBy != "" {
+		size := agg.Size
+		if size <= 0 {
+			size = 20
+		}
+		groupSub := map[string]any{}
+		for k, v := range sub {
+			groupSub[k] = v
+		}
+		if agg.Seen {
+			groupSub["first_seen"] = map[string]any{"min": map[string]any{"field": "timestamp"}}
+			groupSub["last_seen"] = map[string]any{"max": map[string]any{"field": "timestamp"}}
+		}
+		if len(agg.Sample) > 0 {
+			groupSub["latest"] = map[string]any{
+				"top_hits": map[string]any{
+					"size":    1,
+					"sort":    []map[string]any{{"timestamp": map[string]any{"order": "desc"}}},
+					"_source": agg.Sample,
+				},
+			}
+		}
+		group := map[string]any{"terms": map[string]any{"field": groupBy, "size": size}}
+		if len(groupSub) > 0 {
+			group["aggregations"] = groupSub
+		}
+		aggs["group_by"] = group
+	}
+	if agg.Interval > 0 {
+		hist := map[string]any{
+			"date_histogram": map[string]any{
+				"field":          "timestamp",
+				"fixed_interval": fmt.Sprintf("%dms", agg.Interval.Milliseconds()),
+			},
+		}
+		if len(sub) > 0 {
+			hist["aggregations"] = sub
+		}
+		aggs["over_time"] = hist
+	}
+	if agg.Weighted {
+		aggs["estimated_total"] = estimatedCountAgg
+	}
+
+	return map[string]any{
+		"query":            boolQuery(q),
+		"size":             0,
+		"track_total_hits": true,
+		"aggregations":     aggs,
+	}
+}
+
+// estimatedCountAgg sums each event's weight: 1/sample_rate for sampled
+// events and 1 otherwise, estimating the count before sampling. Indices
+// that never mapped sample_rate have no such doc value at all.
+var estimatedCountAgg = map[string]any{
+	"sum": map[string]any{
+		"script": map[string]any{
+			"lang":   "painless",
+			"source": "!doc.containsKey('sample_rate') || doc['sample_rate'].size() == 0 || doc['sample_rate'].value <= 0 ? 1.0 : 1.0 / doc['sample_rate'].value",
+		},
+	},
+}
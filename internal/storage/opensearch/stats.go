@@ -0,0 +1,64 @@
+package opensearch
+
+import (
+	"maps"
+	"sync"
+)
+
+// TenantIndexStats counts one tenant's indexing outcomes.
+type TenantIndexStats struct {
+	Indexed      int64            `json:"indexed"`
+	Retried      int64            `json:"retried"`       // NAKed for redelivery
+	DeadLettered int64            `json:"dead_lettered"` // permanent failures and retries exhausted
+	Errors       map[string]int64 `json:"errors"`        // by OpenSearch error type
+}
+
+type indexStats struct {
+	mu      sync.Mutex
+	tenants map[string]*TenantIndexStats
+}
+
+func newIndexStats() *indexStats {
+	return &indexStats{tenants: make(map[string]*TenantIndexStats)}
+}
+
+func (s *indexStats) tenant(id string) *TenantIndexStats {
+	t, ok := s.tenants[id]
+	if !ok {
+		t = &TenantIndexStats{Errors: make(map[string]int64)}
+		s.tenants[id] = t
+	}
+	return t
+}
+
+func (s *indexStats) indexed(tenantID string) {
+	s.mu.Lock()
+	s.tenant(tenantID).Indexed++
+	s.mu.Unlock()
+}
+
+// failed counts a document that failed with errType and was either
+// redelivered or dead-lettered.
+func (s *indexStats) failed(tenantID, errType string, redelivered bool) {
+	s.mu.Lock()
+	t := s.tenant(tenantID)
+	t.Errors[errType]++
+	if redelivered {
+		t.Retried++
+	} else {
+		t.DeadLettered++
+	}
+	s.mu.Unlock()
+}
+
+func (s *indexStats) snapshot() map[string]TenantIndexStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]TenantIndexStats, len(s.tenants))
+	for id, t := range s.tenants {
+		c := *t
+		c.Errors = maps.Clone(t.Errors)
+		out[id] = c
+	}
+	return out
+}
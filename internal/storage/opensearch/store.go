@@ -0,0 +1,207 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	"github.com/felipemonteiro/mintlog/internal/logstore"
+	"github.com/felipemonteiro/mintlog/pkg/logmodel"
+)
+
+// Store is the OpenSearch log store. Queries go through each tenant's read
+// alias; Write indexes through the write alias, though events normally
+// reach OpenSearch through the Indexer.
+type Store struct {
+	client    *opensearchapi.Client
+	placement PlacementFunc
+}
+
+var _ logstore.LogStore = (*Store)(nil)
+
+// NewStore queries through client. placement places tenants Write sees
+// first; nil places them on dedicated indices.
+func NewStore(client *opensearchapi.Client, placement PlacementFunc) *Store {
+	return &Store{client: client, placement: placement}
+}
+
+// ignoreUnavailable lets a search name a tenant's read alias before the
+// tenant has indexed anything: a missing alias matches no documents.
+var ignoreUnavailable = true
+
+func (s *Store) search(ctx context.Context, tenantID string, body map[string]any) (*opensearchapi.SearchResp, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal query: %w", err)
+	}
+	return s.client.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{ReadAlias(tenantID)},
+		Body:    strings.NewReader(string(b)),
+		Params:  opensearchapi.SearchParams{IgnoreUnavailable: &ignoreUnavailable},
+	})
+}
+
+func (s *Store) Write(ctx context.Context, events []*logmodel.LogEvent) error {
+	byTenant := make(map[string][]*logmodel.LogEvent)
+	for _, e := range events {
+		byTenant[e.TenantID] = append(byTenant[e.TenantID], e)
+	}
+	for tenantID, events := range byTenant {
+		placement := PlacementDedicated
+		if s.placement != nil {
+			p, err := s.placement(ctx, tenantID)
+			if err != nil {
+				return fmt.Errorf("placement: %w", err)
+			}
+			placement = p
+		}
+		if err := EnsureWriteIndex(ctx, s.client, tenantID, placement); err != nil {
+			return err
+		}
+
+		ids := make([]string, len(events))
+		docs := make([]json.RawMessage, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+			docs[i], _ = json.Marshal(e)
+		}
+		if err := BulkIndex(ctx, s.client, WriteAlias(tenantID), ids, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) Search(ctx context.Context, q *logstore.Query, opts logstore.SearchOptions) (*logstore.SearchResult, error) {
+	resp, err := s.search(ctx, q.TenantID, searchBody(q, opts))
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	result := &logstore.SearchResult{
+		Total: resp.Hits.Total.Value,
+		Hits:  make([]json.RawMessage, 0, len(resp.Hits.Hits)),
+	}
+	for _, hit := range resp.Hits.Hits {
+		result.Hits = append(result.Hits, hit.Source)
+	}
+	if n := len(resp.Hits.Hits); n > 0 {
+		result.Cursor = resp.Hits.Hits[n-1].Sort
+	}
+	return result, nil
+}
+
+func (s *Store) Count(ctx context.Context, q *logstore.Query) (int64, error) {
+	resp, err := s.search(ctx, q.TenantID, map[string]any{
+		"query":            boolQuery(q),
+		"size":             0,
+		"track_total_hits": true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return int64(resp.Hits.Total.Value), nil
+}
+
+func (s *Store) Aggregate(ctx context.Context, q *logstore.Query, agg *logstore.Aggregation) (*logstore.AggregateResult, error) {
+	resp, err := s.search(ctx, q.TenantID, aggregateBody(q, agg))
+	if err != nil {
+		return nil, fmt.Errorf("aggregate: %w", err)
+	}
+	result, err := decodeAggregations(resp.Aggregations)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = int64(resp.Hits.Total.Value)
+	return result, nil
+}
+
+func (s *Store) Tail(ctx context.Context, q *logstore.Query, fn func(json.RawMessage) error) error {
+	return logstore.PollTail(ctx, s, q, fn)
+}
+
+type valueAgg struct {
+	Value *float64 `json:"value"`
+}
+
+type aggBucket struct {
+	Key            any       `json:"key"`
+	KeyAsString    string    `json:"key_as_string"`
+	DocCount       int64     `json:"doc_count"`
+	EstimatedCount *valueAgg `json:"estimated_count"`
+	FirstSeen      *valueAgg `json:"first_seen"`
+	LastSeen       *valueAgg `json:"last_seen"`
+	Latest         *struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	} `json:"latest"`
+}
+
+// decodeAggregations reads the aggregations built by aggregateBody.
+func decodeAggregations(raw json.RawMessage) (*logstore.AggregateResult, error) {
+	var aggs struct {
+		GroupBy *struct {
+			Buckets []aggBucket `json:"buckets"`
+		} `json:"group_by"`
+		OverTime *struct {
+			Buckets []aggBucket `json:"buckets"`
+		} `json:"over_time"`
+		EstimatedTotal *valueAgg `json:"estimated_total"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &aggs); err != nil {
+			return nil, fmt.Errorf("decode aggregations: %w", err)
+		}
+	}
+
+	result := &logstore.AggregateResult{}
+	if aggs.EstimatedTotal != nil {
+		result.EstimatedTotal = aggs.EstimatedTotal.Value
+	}
+	if aggs.GroupBy != nil {
+		result.Groups = make([]logstore.Bucket, 0, len(aggs.GroupBy.Buckets))
+		for _, b := range aggs.GroupBy.Buckets {
+			bucket := b.bucket()
+			bucket.Key = b.KeyAsString
+			if bucket.Key == "" {
+				bucket.Key = logstore.FormatValue(b.Key)
+			}
+			result.Groups = append(result.Groups, bucket)
+		}
+	}
+	if aggs.OverTime != nil {
+		result.OverTime = make([]logstore.Bucket, 0, len(aggs.OverTime.Buckets))
+		for _, b := range aggs.OverTime.Buckets {
+			bucket := b.bucket()
+			if ms, ok := b.Key.(float64); ok {
+				bucket.Time = time.UnixMilli(int64(ms)).UTC()
+			}
+			result.OverTime = append(result.OverTime, bucket)
+		}
+	}
+	return result, nil
+}
+
+func (b *aggBucket) bucket() logstore.Bucket {
+	bucket := logstore.Bucket{Count: b.DocCount}
+	if b.EstimatedCount != nil {
+		bucket.EstimatedCount = b.EstimatedCount.Value
+	}
+	if b.FirstSeen != nil && b.FirstSeen.Value != nil {
+		bucket.FirstSeen = time.UnixMilli(int64(*b.FirstSeen.Value)).UTC()
+	}
+	if b.LastSeen != nil && b.LastSeen.Value != nil {
+		bucket.LastSeen = time.UnixMilli(int64(*b.LastSeen.Value)).UTC()
+	}
+	if b.Latest != nil && len(b.Latest.Hits.Hits) > 0 {
+		bucket.Sample = b.Latest.Hits.Hits[0].Source
+	}
+	return bucket
+}
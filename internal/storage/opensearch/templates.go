@@ -0,0 +1,381 @@
+package opensearch
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// Index templates are versioned with the code: templates/vN.json holds the
+// settings and mappings of version N, and the highest version is current.
+// A change to either is a new file, applied to the cluster by
+// `indexctl templates migrate`. The version is recorded on the installed
+// templates and, through the mapping's _meta, on every index created from
+// them.
+//
+//go:embed templates/*.json
+var templateFiles embed.FS
+
+// Template is one version of the settings and mappings of event indices.
+type Template struct {
+	Version  int            `json:"version"`
+	Settings map[string]any `json:"settings"`
+	Mappings map[string]any `json:"mappings"`
+}
+
+const (
+	// indexTemplateName is the template of every event index. Tenants with
+	// overrides get a template of their own, of higher priority, matching
+	// only their dedicated indices.
+	indexTemplateName      = "mintlog-logs"
+	tenantTemplatePrefix   = "mintlog-tenant-"
+	indexTemplatePriority  = 100
+	tenantTemplatePriority = 200
+)
+
+var templates = loadTemplates()
+
+func loadTemplates() []Template {
+	entries, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	var out []Template
+	for _, entry := range entries {
+		var t Template
+		if _, err := fmt.Sscanf(entry.Name(), "v%d.json", &t.Version); err != nil {
+			panic(fmt.Sprintf("template file %s: want vN.json", entry.Name()))
+		}
+		data, err := templateFiles.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := json.Unmarshal(data, &t); err != nil {
+			panic(fmt.Sprintf("template file %s: %v", entry.Name(), err))
+		}
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		panic("no index templates")
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+// CurrentTemplate returns the latest template version.
+func CurrentTemplate() Template {
+	return templates[len(templates)-1]
+}
+
+// TenantTemplateName names the template holding a tenant's overrides.
+func TenantTemplateName(tenantID string) string {
+	return tenantTemplatePrefix + tenantID
+}
+
+// IndexOverrides adjusts the template for one tenant's dedicated indices.
+// Unset fields keep the template's. Shared indices always follow the
+// template.
+type IndexOverrides struct {
+	Shards          *int   `json:"shards,omitempty"`
+	Replicas        *int   `json:"replicas,omitempty"`
+	RefreshInterval string `json:"refresh_interval,omitempty"` // such as "1s", "30s" or "-1"
+
+	// MessageAnalyzer analyzes the message with "standard" (the default)
+	// or "code", which also splits paths, dotted names and camelCase
+	// identifiers into words while keeping them whole.
+	MessageAnalyzer string `json:"message_analyzer,omitempty"`
+
+	// Mappings maps custom fields, by path under fields, such as
+	// "fields.status": {"type": "integer"}.
+	Mappings map[string]json.RawMessage `json:"mappings,omitempty"`
+}
+
+var (
+	refreshPattern = regexp.MustCompile(`^(-1|[1-9][0-9]*(ms|s|m|h))$`)
+
+	// messageAnalyzers maps a MessageAnalyzer to the index and search
+	// analyzers of the message field, defined by the template's analysis
+	// settings.
+	messageAnalyzers = map[string][2]string{
+		"standard": {"standard", "standard"},
+		"code":     {"mintlog_code", "mintlog_code_search"},
+	}
+
+	// mappingTypes are the types a custom field can be mapped to, with the
+	// parameters allowed for each.
+	mappingTypes = map[string][]string{
+		"keyword": {"ignore_above"},
+		"text":    nil,
+		"long":    nil,
+		"integer": nil,
+		"short":   nil,
+		"double":  nil,
+		"float":   nil,
+		"boolean": nil,
+		"date":    {"format"},
+		"ip":      nil,
+	}
+)
+
+// Validate checks the overrides against the current template.
+func (o IndexOverrides) Validate() error {
+	if o.Shards != nil && (*o.Shards < 1 || *o.Shards > 32) {
+		return fmt.Errorf("shards must be between 1 and 32")
+	}
+	if o.Replicas != nil && (*o.Replicas < 0 || *o.Replicas > 5) {
+		return fmt.Errorf("replicas must be between 0 and 5")
+	}
+	if o.RefreshInterval != "" && !refreshPattern.MatchString(o.RefreshInterval) {
+		return fmt.Errorf("invalid refresh_interval %q", o.RefreshInterval)
+	}
+
+	if _, ok := messageAnalyzers[o.MessageAnalyzer]; !ok && o.MessageAnalyzer != "" {
+		return fmt.Errorf("message_analyzer must be standard or code")
+	}
+
+	base := CurrentTemplate().Mappings
+	paths := make([]string, 0, len(o.Mappings))
+	for path, raw := range o.Mappings {
+		name, ok := strings.CutPrefix(path, "fields.")
+		if !ok || name == "" || slices.Contains(strings.Split(name, "."), "") {
+			return fmt.Errorf("mapping %q: path must be under fields", path)
+		}
+		if strings.HasPrefix(name, "_") {
+			return fmt.Errorf("mapping %q: reserved field", path)
+		}
+		if mappingAt(base, path) != nil {
+			return fmt.Errorf("mapping %q: already mapped by the template", path)
+		}
+		for i := range len(path) {
+			if path[i] != '.' {
+				continue
+			}
+			if m := mappingAt(base, path[:i]); m != nil && m["properties"] == nil {
+				return fmt.Errorf("mapping %q: %q is not an object", path, path[:i])
+			}
+		}
+		var m map[string]any
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return fmt.Errorf("mapping %q: %w", path, err)
+		}
+		typ, _ := m["type"].(string)
+		params, ok := mappingTypes[typ]
+		if !ok {
+			return fmt.Errorf("mapping %q: unsupported type %q", path, typ)
+		}
+		for k := range m {
+			if k != "type" && !slices.Contains(params, k) {
+				return fmt.Errorf("mapping %q: parameter %q not allowed for %s", path, k, typ)
+			}
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for i := 1; i < len(paths); i++ {
+		if strings.HasPrefix(paths[i], paths[i-1]+".") {
+			return fmt.Errorf("mapping %q: %q is not an object", paths[i], paths[i-1])
+		}
+	}
+	return nil
+}
+
+// mappingAt returns the mapping of a field path, or nil.
+func mappingAt(mappings map[string]any, path string) map[string]any {
+	m := mappings
+	for _, name := range strings.Split(path, ".") {
+		props, _ := m["properties"].(map[string]any)
+		next, ok := props[name].(map[string]any)
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
+}
+
+// index returns the settings and mappings of an index created from t with
+// the overrides o, which may be nil.
+func (t Template) index(o *IndexOverrides) (settings, mappings map[string]any) {
+	settings = clone(t.Settings)
+	mappings = clone(t.Mappings)
+	if o != nil {
+		if o.Shards != nil {
+			settings["number_of_shards"] = *o.Shards
+		}
+		if o.Replicas != nil {
+			settings["number_of_replicas"] = *o.Replicas
+		}
+		if o.RefreshInterval != "" {
+			settings["refresh_interval"] = o.RefreshInterval
+		}
+		if a, ok := messageAnalyzers[o.MessageAnalyzer]; ok {
+			if message := mappingAt(mappings, "message"); message != nil {
+				message["analyzer"] = a[0]
+				if a[1] != a[0] {
+					message["search_analyzer"] = a[1]
+				}
+			}
+		}
+		for path, raw := range o.Mappings {
+			var m map[string]any
+			if json.Unmarshal(raw, &m) == nil {
+				setMapping(mappings, path, m)
+			}
+		}
+	}
+	mappings["_meta"] = map[string]any{"template_version": t.Version}
+	return settings, mappings
+}
+
+// setMapping maps a field path, adding the objects above it.
+func setMapping(mappings map[string]any, path string, m map[string]any) {
+	names := strings.Split(path, ".")
+	parent := mappings
+	for _, name := range names[:len(names)-1] {
+		props, ok := parent["properties"].(map[string]any)
+		if !ok {
+			props = map[string]any{}
+			parent["properties"] = props
+		}
+		next, ok := props[name].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			props[name] = next
+		}
+		parent = next
+	}
+	props, ok := parent["properties"].(map[string]any)
+	if !ok {
+		props = map[string]any{}
+		parent["properties"] = props
+	}
+	props[names[len(names)-1]] = m
+}
+
+// body renders t as an index template over patterns.
+func (t Template) body(patterns []string, priority int, o *IndexOverrides) map[string]any {
+	settings, mappings := t.index(o)
+	return map[string]any{
+		"index_patterns": patterns,
+		"priority":       priority,
+		"version":        t.Version,
+		"template": map[string]any{
+			"settings": settings,
+			"mappings": mappings,
+		},
+	}
+}
+
+func clone(m map[string]any) map[string]any {
+	b, _ := json.Marshal(m)
+	var out map[string]any
+	json.Unmarshal(b, &out)
+	return out
+}
+
+func putTemplate(ctx context.Context, client *opensearchapi.Client, name string, body map[string]any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal template %s: %w", name, err)
+	}
+	_, err = client.IndexTemplate.Create(ctx, opensearchapi.IndexTemplateCreateReq{
+		IndexTemplate: name,
+		Body:          strings.NewReader(string(b)),
+	})
+	if err != nil {
+		return fmt.Errorf("put index template %s: %w", name, err)
+	}
+	return nil
+}
+
+// installedTemplates returns Mintlog's index templates in the cluster, by
+// name.
+func installedTemplates(ctx context.Context, client *opensearchapi.Client) (map[string]opensearchapi.IndexTemplateGetDetails, error) {
+	resp, err := client.IndexTemplate.Get(ctx, &opensearchapi.IndexTemplateGetReq{IndexTemplates: []string{"mintlog-*"}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == 404 {
+			return map[string]opensearchapi.IndexTemplateGetDetails{}, nil
+		}
+		return nil, fmt.Errorf("get index templates: %w", err)
+	}
+	out := make(map[string]opensearchapi.IndexTemplateGetDetails, len(resp.IndexTemplates))
+	for _, t := range resp.IndexTemplates {
+		out[t.Name] = t
+	}
+	return out, nil
+}
+
+// EnsureIndexTemplate installs the current template unless the cluster
+// already has it or a later version, as during a rolling upgrade.
+// Tenant overrides are left to PutTemplates.
+func EnsureIndexTemplate(ctx context.Context, client *opensearchapi.Client) error {
+	installed, err := installedTemplates(ctx, client)
+	if err != nil {
+		return err
+	}
+	current := CurrentTemplate()
+	if t, ok := installed[indexTemplateName]; ok && t.IndexTemplate.Version >= current.Version {
+		return nil
+	}
+	return putTemplate(ctx, client, indexTemplateName, current.body([]string{"mintlog-*"}, indexTemplatePriority, nil))
+}
+
+// PutTenantTemplate applies a tenant's overrides to the indices it gets
+// from now on.
+func PutTenantTemplate(ctx context.Context, client *opensearchapi.Client, tenantID string, o IndexOverrides) error {
+	return putTemplate(ctx, client, TenantTemplateName(tenantID), CurrentTemplate().body([]string{TenantPattern(tenantID)}, tenantTemplatePriority, &o))
+}
+
+// DeleteTenantTemplate drops a tenant's overrides; its next indices follow
+// the template.
+func DeleteTenantTemplate(ctx context.Context, client *opensearchapi.Client, tenantID string) error {
+	name := TenantTemplateName(tenantID)
+	resp, err := client.IndexTemplate.Delete(ctx, opensearchapi.IndexTemplateDeleteReq{IndexTemplate: name})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == 404 {
+			return nil
+		}
+		return fmt.Errorf("delete index template %s: %w", name, err)
+	}
+	return nil
+}
+
+// PutTemplates writes the current template and every tenant's, given the
+// overrides by tenant ID, and deletes the templates of tenants without
+// overrides. It returns how many templates it wrote.
+func PutTemplates(ctx context.Context, client *opensearchapi.Client, overrides map[string]IndexOverrides) (int, error) {
+	installed, err := installedTemplates(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+	current := CurrentTemplate()
+	if err := putTemplate(ctx, client, indexTemplateName, current.body([]string{"mintlog-*"}, indexTemplatePriority, nil)); err != nil {
+		return 0, err
+	}
+	written := 1
+	for tenantID, o := range overrides {
+		if err := PutTenantTemplate(ctx, client, tenantID, o); err != nil {
+			return written, err
+		}
+		written++
+	}
+	for name := range installed {
+		tenantID, ok := strings.CutPrefix(name, tenantTemplatePrefix)
+		if _, kept := overrides[tenantID]; !ok || kept {
+			continue
+		}
+		if err := DeleteTenantTemplate(ctx, client, tenantID); err != nil {
+			return written, err
+		}
+		slog.Info("deleted tenant index template", "template", name)
+	}
+	return written, nil
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS lookup_tables (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    match_field VARCHAR(255) NOT NULL,
+    key_column VARCHAR(255) NOT NULL,
+    columns TEXT[] NOT NULL DEFAULT '{}',
+    rows JSONB NOT NULL DEFAULT '{}',
+    row_count INT NOT NULL DEFAULT 0,
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_lookup_tables_tenant_id ON lookup_tables(tenant_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS log_patterns (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    pattern_id VARCHAR(32) NOT NULL,
+    template TEXT NOT NULL,
+    token_count INT NOT NULL,
+    match_count BIGINT NOT NULL DEFAULT 0,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, pattern_id)
+);
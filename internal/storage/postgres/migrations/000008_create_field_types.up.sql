@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS field_types (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    source VARCHAR(16) NOT NULL DEFAULT 'inferred',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, name)
+);
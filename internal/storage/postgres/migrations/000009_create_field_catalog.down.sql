@@ -0,0 +1 @@
+DROP TABLE IF EXISTS field_catalog;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS field_catalog (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type_counts JSONB NOT NULL DEFAULT '{}',
+    event_count BIGINT NOT NULL DEFAULT 0,
+    sketch BYTEA NOT NULL,
+    samples TEXT[] NOT NULL DEFAULT '{}',
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, name)
+);
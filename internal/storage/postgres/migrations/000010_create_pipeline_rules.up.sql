@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS pipeline_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    match JSONB NOT NULL DEFAULT '{}',
+    sample_rate DOUBLE PRECISION NOT NULL DEFAULT 1,
+    hash_field VARCHAR(255) NOT NULL DEFAULT 'trace_id',
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_pipeline_rules_tenant_id ON pipeline_rules(tenant_id);
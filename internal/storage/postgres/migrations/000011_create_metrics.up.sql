@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS metrics (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    match JSONB NOT NULL DEFAULT '{}',
+    field VARCHAR(255) NOT NULL DEFAULT '',
+    group_by TEXT[] NOT NULL DEFAULT '{}',
+    buckets DOUBLE PRECISION[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS metric_points (
+    metric_id UUID NOT NULL REFERENCES metrics(id) ON DELETE CASCADE,
+    resolution INT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}',
+    labels_key TEXT NOT NULL,
+    count DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    min DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    bucket_counts DOUBLE PRECISION[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (metric_id, resolution, bucket_start, labels_key)
+);
+
+CREATE INDEX idx_metric_points_resolution_start ON metric_points(resolution, bucket_start);
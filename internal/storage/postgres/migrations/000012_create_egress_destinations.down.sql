@@ -0,0 +1 @@
+DROP TABLE IF EXISTS egress_destinations;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS egress_destinations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    match JSONB NOT NULL DEFAULT '{}',
+    batch_size INT NOT NULL DEFAULT 500,
+    flush_seconds INT NOT NULL DEFAULT 5,
+    max_retries INT NOT NULL DEFAULT 5,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_egress_destinations_tenant_id ON egress_destinations(tenant_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rehydration_jobs;
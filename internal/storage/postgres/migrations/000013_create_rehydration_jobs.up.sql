@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS rehydration_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    from_time TIMESTAMPTZ NOT NULL,
+    to_time TIMESTAMPTZ NOT NULL,
+    match JSONB NOT NULL DEFAULT '{}',
+    ttl_hours INT NOT NULL,
+    objects_total INT NOT NULL DEFAULT 0,
+    objects_done INT NOT NULL DEFAULT 0,
+    events_scanned BIGINT NOT NULL DEFAULT 0,
+    events_restored BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_rehydration_jobs_tenant_id ON rehydration_jobs(tenant_id);
+CREATE INDEX idx_rehydration_jobs_status ON rehydration_jobs(status, created_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS index_settings (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    shards INT,
+    replicas INT,
+    refresh_interval VARCHAR(20),
+    mappings JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE index_settings DROP COLUMN IF EXISTS message_analyzer;
@@ -0,0 +1 @@
+ALTER TABLE index_settings ADD COLUMN IF NOT EXISTS message_analyzer VARCHAR(20);
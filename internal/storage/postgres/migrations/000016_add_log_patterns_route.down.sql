@@ -0,0 +1 @@
+ALTER TABLE log_patterns DROP COLUMN IF EXISTS route;
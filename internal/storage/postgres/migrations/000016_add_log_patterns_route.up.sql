@@ -0,0 +1 @@
+ALTER TABLE log_patterns ADD COLUMN IF NOT EXISTS route TEXT;
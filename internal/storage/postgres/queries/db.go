@@ -20,3 +20,7 @@ type Queries struct {
 func New(db DBTX) *Queries {
 	return &Queries{db: db}
 }
+
+func (q *Queries) WithTx(tx pgx.Tx) *Queries {
+	return &Queries{db: tx}
+}
@@ -0,0 +1,103 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createEgressDestination = `
+INSERT INTO egress_destinations (tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries, is_active, created_at, updated_at
+`
+
+func (q *Queries) CreateEgressDestination(ctx context.Context, tenantID uuid.UUID, name, destType string, config, match []byte, batchSize, flushSeconds, maxRetries int32) (EgressDestination, error) {
+	row := q.db.QueryRow(ctx, createEgressDestination, tenantID, name, destType, config, match, batchSize, flushSeconds, maxRetries)
+	var d EgressDestination
+	err := row.Scan(&d.ID, &d.TenantID, &d.Name, &d.Type, &d.Config, &d.Match, &d.BatchSize, &d.FlushSeconds, &d.MaxRetries, &d.IsActive, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+const getEgressDestination = `
+SELECT id, tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries, is_active, created_at, updated_at
+FROM egress_destinations WHERE id = $1 AND tenant_id = $2
+`
+
+func (q *Queries) GetEgressDestination(ctx context.Context, id, tenantID uuid.UUID) (EgressDestination, error) {
+	row := q.db.QueryRow(ctx, getEgressDestination, id, tenantID)
+	var d EgressDestination
+	err := row.Scan(&d.ID, &d.TenantID, &d.Name, &d.Type, &d.Config, &d.Match, &d.BatchSize, &d.FlushSeconds, &d.MaxRetries, &d.IsActive, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+const listEgressDestinations = `
+SELECT id, tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries, is_active, created_at, updated_at
+FROM egress_destinations WHERE tenant_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListEgressDestinations(ctx context.Context, tenantID uuid.UUID) ([]EgressDestination, error) {
+	rows, err := q.db.Query(ctx, listEgressDestinations, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EgressDestination
+	for rows.Next() {
+		var d EgressDestination
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.Name, &d.Type, &d.Config, &d.Match, &d.BatchSize, &d.FlushSeconds, &d.MaxRetries, &d.IsActive, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+	if items == nil {
+		items = []EgressDestination{}
+	}
+	return items, rows.Err()
+}
+
+const updateEgressDestination = `
+UPDATE egress_destinations
+SET name = $3, type = $4, config = $5, match = $6, batch_size = $7, flush_seconds = $8, max_retries = $9, is_active = $10, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING id, tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries, is_active, created_at, updated_at
+`
+
+func (q *Queries) UpdateEgressDestination(ctx context.Context, id, tenantID uuid.UUID, name, destType string, config, match []byte, batchSize, flushSeconds, maxRetries int32, isActive bool) (EgressDestination, error) {
+	row := q.db.QueryRow(ctx, updateEgressDestination, id, tenantID, name, destType, config, match, batchSize, flushSeconds, maxRetries, isActive)
+	var d EgressDestination
+	err := row.Scan(&d.ID, &d.TenantID, &d.Name, &d.Type, &d.Config, &d.Match, &d.BatchSize, &d.FlushSeconds, &d.MaxRetries, &d.IsActive, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+const deleteEgressDestination = `DELETE FROM egress_destinations WHERE id = $1 AND tenant_id = $2`
+
+func (q *Queries) DeleteEgressDestination(ctx context.Context, id, tenantID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEgressDestination, id, tenantID)
+	return err
+}
+
+const listActiveEgressDestinations = `
+SELECT id, tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries, is_active, created_at, updated_at
+FROM egress_destinations WHERE is_active = true ORDER BY tenant_id, created_at
+`
+
+func (q *Queries) ListActiveEgressDestinations(ctx context.Context) ([]EgressDestination, error) {
+	rows, err := q.db.Query(ctx, listActiveEgressDestinations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EgressDestination
+	for rows.Next() {
+		var d EgressDestination
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.Name, &d.Type, &d.Config, &d.Match, &d.BatchSize, &d.FlushSeconds, &d.MaxRetries, &d.IsActive, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+	if items == nil {
+		items = []EgressDestination{}
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,72 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const listFieldCatalog = `
+SELECT tenant_id, name, type_counts, event_count, sketch, samples, first_seen, last_seen
+FROM field_catalog WHERE tenant_id = $1 AND name LIKE $2 || '%' ORDER BY name
+`
+
+func (q *Queries) ListFieldCatalog(ctx context.Context, tenantID uuid.UUID, prefix string) ([]FieldCatalogEntry, error) {
+	rows, err := q.db.Query(ctx, listFieldCatalog, tenantID, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FieldCatalogEntry
+	for rows.Next() {
+		var e FieldCatalogEntry
+		if err := rows.Scan(&e.TenantID, &e.Name, &e.TypeCounts, &e.EventCount, &e.Sketch, &e.Samples, &e.FirstSeen, &e.LastSeen); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	if items == nil {
+		items = []FieldCatalogEntry{}
+	}
+	return items, rows.Err()
+}
+
+const lockFieldCatalog = `
+SELECT tenant_id, name, type_counts, event_count, sketch, samples, first_seen, last_seen
+FROM field_catalog WHERE tenant_id = $1 AND name = ANY($2::text[]) FOR UPDATE
+`
+
+// LockFieldCatalog reads and locks the named entries for a read-modify-write
+// merge. It must run inside a transaction.
+func (q *Queries) LockFieldCatalog(ctx context.Context, tenantID uuid.UUID, names []string) ([]FieldCatalogEntry, error) {
+	rows, err := q.db.Query(ctx, lockFieldCatalog, tenantID, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FieldCatalogEntry
+	for rows.Next() {
+		var e FieldCatalogEntry
+		if err := rows.Scan(&e.TenantID, &e.Name, &e.TypeCounts, &e.EventCount, &e.Sketch, &e.Samples, &e.FirstSeen, &e.LastSeen); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	if items == nil {
+		items = []FieldCatalogEntry{}
+	}
+	return items, rows.Err()
+}
+
+const upsertFieldCatalog = `
+INSERT INTO field_catalog (tenant_id, name, type_counts, event_count, sketch, samples, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET type_counts = $3, event_count = $4, sketch = $5, samples = $6, first_seen = $7, last_seen = $8
+`
+
+func (q *Queries) UpsertFieldCatalog(ctx context.Context, tenantID uuid.UUID, name string, typeCounts []byte, eventCount int64, sketch []byte, samples []string, firstSeen, lastSeen time.Time) error {
+	_, err := q.db.Exec(ctx, upsertFieldCatalog, tenantID, name, typeCounts, eventCount, sketch, samples, firstSeen, lastSeen)
+	return err
+}
@@ -0,0 +1,83 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const lockFieldTypes = `SELECT pg_advisory_xact_lock(hashtextextended('field_types:' || $1::text, 0))`
+
+// LockFieldTypes serializes registrations of the tenant's field types until
+// the transaction ends. It must run inside a transaction.
+func (q *Queries) LockFieldTypes(ctx context.Context, tenantID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, lockFieldTypes, tenantID)
+	return err
+}
+
+// The no-op update makes RETURNING yield the existing row on conflict, so
+// concurrent writers agree on the first registered type. A new field is
+// inserted only while the tenant has fewer than maxFields; at the cap no
+// row is returned. Run it after LockFieldTypes so the count is exact.
+const registerFieldType = `
+INSERT INTO field_types (tenant_id, name, type)
+SELECT $1::uuid, $2::varchar, $3::varchar
+WHERE EXISTS (SELECT 1 FROM field_types WHERE tenant_id = $1 AND name = $2)
+   OR (SELECT count(*) FROM field_types WHERE tenant_id = $1) < $4
+ON CONFLICT (tenant_id, name) DO UPDATE SET name = field_types.name
+RETURNING tenant_id, name, type, source, created_at, updated_at
+`
+
+func (q *Queries) RegisterFieldType(ctx context.Context, tenantID uuid.UUID, name, fieldType string, maxFields int32) (FieldType, error) {
+	row := q.db.QueryRow(ctx, registerFieldType, tenantID, name, fieldType, maxFields)
+	var f FieldType
+	err := row.Scan(&f.TenantID, &f.Name, &f.Type, &f.Source, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+const setFieldType = `
+INSERT INTO field_types (tenant_id, name, type, source)
+VALUES ($1, $2, $3, 'manual')
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET type = $3, source = 'manual', updated_at = now()
+RETURNING tenant_id, name, type, source, created_at, updated_at
+`
+
+func (q *Queries) SetFieldType(ctx context.Context, tenantID uuid.UUID, name, fieldType string) (FieldType, error) {
+	row := q.db.QueryRow(ctx, setFieldType, tenantID, name, fieldType)
+	var f FieldType
+	err := row.Scan(&f.TenantID, &f.Name, &f.Type, &f.Source, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+const listFieldTypes = `
+SELECT tenant_id, name, type, source, created_at, updated_at
+FROM field_types WHERE tenant_id = $1 ORDER BY name
+`
+
+func (q *Queries) ListFieldTypes(ctx context.Context, tenantID uuid.UUID) ([]FieldType, error) {
+	rows, err := q.db.Query(ctx, listFieldTypes, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FieldType
+	for rows.Next() {
+		var f FieldType
+		if err := rows.Scan(&f.TenantID, &f.Name, &f.Type, &f.Source, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, f)
+	}
+	if items == nil {
+		items = []FieldType{}
+	}
+	return items, rows.Err()
+}
+
+const deleteFieldType = `DELETE FROM field_types WHERE tenant_id = $1 AND name = $2`
+
+func (q *Queries) DeleteFieldType(ctx context.Context, tenantID uuid.UUID, name string) error {
+	_, err := q.db.Exec(ctx, deleteFieldType, tenantID, name)
+	return err
+}
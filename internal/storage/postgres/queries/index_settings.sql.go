@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getIndexSettings = `
+SELECT tenant_id, shards, replicas, refresh_interval, mappings, message_analyzer, created_at, updated_at
+FROM index_settings WHERE tenant_id = $1
+`
+
+func (q *Queries) GetIndexSettings(ctx context.Context, tenantID uuid.UUID) (IndexSetting, error) {
+	row := q.db.QueryRow(ctx, getIndexSettings, tenantID)
+	var s IndexSetting
+	err := row.Scan(&s.TenantID, &s.Shards, &s.Replicas, &s.RefreshInterval, &s.Mappings, &s.MessageAnalyzer, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+const listIndexSettings = `
+SELECT tenant_id, shards, replicas, refresh_interval, mappings, message_analyzer, created_at, updated_at
+FROM index_settings ORDER BY tenant_id
+`
+
+func (q *Queries) ListIndexSettings(ctx context.Context) ([]IndexSetting, error) {
+	rows, err := q.db.Query(ctx, listIndexSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []IndexSetting
+	for rows.Next() {
+		var s IndexSetting
+		if err := rows.Scan(&s.TenantID, &s.Shards, &s.Replicas, &s.RefreshInterval, &s.Mappings, &s.MessageAnalyzer, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	if items == nil {
+		items = []IndexSetting{}
+	}
+	return items, rows.Err()
+}
+
+const upsertIndexSettings = `
+INSERT INTO index_settings (tenant_id, shards, replicas, refresh_interval, mappings, message_analyzer)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (tenant_id)
+DO UPDATE SET shards = $2, replicas = $3, refresh_interval = $4, mappings = $5, message_analyzer = $6, updated_at = now()
+RETURNING tenant_id, shards, replicas, refresh_interval, mappings, message_analyzer, created_at, updated_at
+`
+
+func (q *Queries) UpsertIndexSettings(ctx context.Context, tenantID uuid.UUID, shards, replicas pgtype.Int4, refreshInterval pgtype.Text, mappings []byte, messageAnalyzer pgtype.Text) (IndexSetting, error) {
+	row := q.db.QueryRow(ctx, upsertIndexSettings, tenantID, shards, replicas, refreshInterval, mappings, messageAnalyzer)
+	var s IndexSetting
+	err := row.Scan(&s.TenantID, &s.Shards, &s.Replicas, &s.RefreshInterval, &s.Mappings, &s.MessageAnalyzer, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+const deleteIndexSettings = `DELETE FROM index_settings WHERE tenant_id = $1`
+
+func (q *Queries) DeleteIndexSettings(ctx context.Context, tenantID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteIndexSettings, tenantID)
+	return err
+}
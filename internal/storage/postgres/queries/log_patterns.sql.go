@@ -0,0 +1,54 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const upsertLogPattern = `
+INSERT INTO log_patterns (tenant_id, pattern_id, template, route, token_count, match_count, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (tenant_id, pattern_id)
+DO UPDATE SET
+  template = CASE
+    WHEN length($3) - length(replace($3, '<*>', '')) >= length(log_patterns.template) - length(replace(log_patterns.template, '<*>', ''))
+    THEN $3 ELSE log_patterns.template END,
+  route = COALESCE(log_patterns.route, $4),
+  match_count = log_patterns.match_count + $6,
+  first_seen = LEAST(log_patterns.first_seen, $7), last_seen = GREATEST(log_patterns.last_seen, $8)
+`
+
+// UpsertLogPattern adds matches to a pattern's running count. The stored
+// template is replaced only by one at least as general, since replicas
+// flush the same pattern at different stages of generalization.
+func (q *Queries) UpsertLogPattern(ctx context.Context, tenantID uuid.UUID, patternID, template, route string, tokenCount int32, matches int64, firstSeen, lastSeen time.Time) error {
+	_, err := q.db.Exec(ctx, upsertLogPattern, tenantID, patternID, template, route, tokenCount, matches, firstSeen, lastSeen)
+	return err
+}
+
+const listLogPatterns = `
+SELECT tenant_id, pattern_id, template, token_count, match_count, first_seen, last_seen, route
+FROM log_patterns WHERE tenant_id = $1 ORDER BY match_count DESC
+`
+
+func (q *Queries) ListLogPatterns(ctx context.Context, tenantID uuid.UUID) ([]LogPattern, error) {
+	rows, err := q.db.Query(ctx, listLogPatterns, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LogPattern
+	for rows.Next() {
+		var p LogPattern
+		if err := rows.Scan(&p.TenantID, &p.PatternID, &p.Template, &p.TokenCount, &p.MatchCount, &p.FirstSeen, &p.LastSeen, &p.Route); err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+	if items == nil {
+		items = []LogPattern{}
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,101 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const upsertLookupTable = `
+INSERT INTO lookup_tables (tenant_id, name, match_field, key_column, columns, rows, row_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET match_field = $3, key_column = $4, columns = $5, rows = $6, row_count = $7,
+  version = lookup_tables.version + 1, updated_at = now()
+RETURNING id, tenant_id, name, match_field, key_column, columns, row_count, version, created_at, updated_at
+`
+
+func (q *Queries) UpsertLookupTable(ctx context.Context, tenantID uuid.UUID, name, matchField, keyColumn string, columns []string, rows []byte, rowCount int32) (LookupTableMeta, error) {
+	row := q.db.QueryRow(ctx, upsertLookupTable, tenantID, name, matchField, keyColumn, columns, rows, rowCount)
+	var t LookupTableMeta
+	err := row.Scan(&t.ID, &t.TenantID, &t.Name, &t.MatchField, &t.KeyColumn, &t.Columns, &t.RowCount, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+const getLookupTable = `
+SELECT id, tenant_id, name, match_field, key_column, columns, rows, row_count, version, created_at, updated_at
+FROM lookup_tables WHERE tenant_id = $1 AND name = $2
+`
+
+func (q *Queries) GetLookupTable(ctx context.Context, tenantID uuid.UUID, name string) (LookupTable, error) {
+	row := q.db.QueryRow(ctx, getLookupTable, tenantID, name)
+	var t LookupTable
+	err := row.Scan(&t.ID, &t.TenantID, &t.Name, &t.MatchField, &t.KeyColumn, &t.Columns, &t.Rows, &t.RowCount, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+const getLookupTableByID = `
+SELECT id, tenant_id, name, match_field, key_column, columns, rows, row_count, version, created_at, updated_at
+FROM lookup_tables WHERE id = $1
+`
+
+func (q *Queries) GetLookupTableByID(ctx context.Context, id uuid.UUID) (LookupTable, error) {
+	row := q.db.QueryRow(ctx, getLookupTableByID, id)
+	var t LookupTable
+	err := row.Scan(&t.ID, &t.TenantID, &t.Name, &t.MatchField, &t.KeyColumn, &t.Columns, &t.Rows, &t.RowCount, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+const listLookupTables = `
+SELECT id, tenant_id, name, match_field, key_column, columns, row_count, version, created_at, updated_at
+FROM lookup_tables WHERE tenant_id = $1 ORDER BY name
+`
+
+func (q *Queries) ListLookupTables(ctx context.Context, tenantID uuid.UUID) ([]LookupTableMeta, error) {
+	rows, err := q.db.Query(ctx, listLookupTables, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LookupTableMeta
+	for rows.Next() {
+		var t LookupTableMeta
+		if err := rows.Scan(&t.ID, &t.TenantID, &t.Name, &t.MatchField, &t.KeyColumn, &t.Columns, &t.RowCount, &t.Version, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	if items == nil {
+		items = []LookupTableMeta{}
+	}
+	return items, rows.Err()
+}
+
+const listLookupTableVersions = `SELECT id, tenant_id, version FROM lookup_tables`
+
+func (q *Queries) ListLookupTableVersions(ctx context.Context) ([]LookupTableVersion, error) {
+	rows, err := q.db.Query(ctx, listLookupTableVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LookupTableVersion
+	for rows.Next() {
+		var v LookupTableVersion
+		if err := rows.Scan(&v.ID, &v.TenantID, &v.Version); err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	if items == nil {
+		items = []LookupTableVersion{}
+	}
+	return items, rows.Err()
+}
+
+const deleteLookupTable = `DELETE FROM lookup_tables WHERE tenant_id = $1 AND name = $2`
+
+func (q *Queries) DeleteLookupTable(ctx context.Context, tenantID uuid.UUID, name string) error {
+	_, err := q.db.Exec(ctx, deleteLookupTable, tenantID, name)
+	return err
+}
@@ -0,0 +1,185 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createMetric = `
+INSERT INTO metrics (tenant_id, name, type, match, field, group_by, buckets)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+`
+
+func (q *Queries) CreateMetric(ctx context.Context, tenantID uuid.UUID, name, metricType string, match []byte, field string, groupBy []string, buckets []float64) (Metric, error) {
+	row := q.db.QueryRow(ctx, createMetric, tenantID, name, metricType, match, field, groupBy, buckets)
+	var m Metric
+	err := row.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+const getMetric = `
+SELECT id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+FROM metrics WHERE id = $1 AND tenant_id = $2
+`
+
+func (q *Queries) GetMetric(ctx context.Context, id, tenantID uuid.UUID) (Metric, error) {
+	row := q.db.QueryRow(ctx, getMetric, id, tenantID)
+	var m Metric
+	err := row.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+const getMetricByName = `
+SELECT id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+FROM metrics WHERE tenant_id = $1 AND name = $2
+`
+
+func (q *Queries) GetMetricByName(ctx context.Context, tenantID uuid.UUID, name string) (Metric, error) {
+	row := q.db.QueryRow(ctx, getMetricByName, tenantID, name)
+	var m Metric
+	err := row.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+const listMetrics = `
+SELECT id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+FROM metrics WHERE tenant_id = $1 ORDER BY name
+`
+
+func (q *Queries) ListMetrics(ctx context.Context, tenantID uuid.UUID) ([]Metric, error) {
+	rows, err := q.db.Query(ctx, listMetrics, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Metric
+	for rows.Next() {
+		var m Metric
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	if items == nil {
+		items = []Metric{}
+	}
+	return items, rows.Err()
+}
+
+const updateMetric = `
+UPDATE metrics
+SET name = $3, type = $4, match = $5, field = $6, group_by = $7, buckets = $8, is_active = $9, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+`
+
+func (q *Queries) UpdateMetric(ctx context.Context, id, tenantID uuid.UUID, name, metricType string, match []byte, field string, groupBy []string, buckets []float64, isActive bool) (Metric, error) {
+	row := q.db.QueryRow(ctx, updateMetric, id, tenantID, name, metricType, match, field, groupBy, buckets, isActive)
+	var m Metric
+	err := row.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+const deleteMetric = `DELETE FROM metrics WHERE id = $1 AND tenant_id = $2`
+
+func (q *Queries) DeleteMetric(ctx context.Context, id, tenantID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMetric, id, tenantID)
+	return err
+}
+
+const listActiveMetrics = `
+SELECT id, tenant_id, name, type, match, field, group_by, buckets, is_active, created_at, updated_at
+FROM metrics WHERE is_active = true ORDER BY tenant_id, name
+`
+
+func (q *Queries) ListActiveMetrics(ctx context.Context) ([]Metric, error) {
+	rows, err := q.db.Query(ctx, listActiveMetrics)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Metric
+	for rows.Next() {
+		var m Metric
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.Name, &m.Type, &m.Match, &m.Field, &m.GroupBy, &m.Buckets, &m.IsActive, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	if items == nil {
+		items = []Metric{}
+	}
+	return items, rows.Err()
+}
+
+// Counts and sums are added to an existing point so that flushes from
+// several pipelined replicas combine.
+const upsertMetricPoint = `
+INSERT INTO metric_points (metric_id, resolution, bucket_start, labels, labels_key, count, sum, min, max, bucket_counts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (metric_id, resolution, bucket_start, labels_key)
+DO UPDATE SET count = metric_points.count + $6, sum = metric_points.sum + $7,
+  min = LEAST(metric_points.min, $8), max = GREATEST(metric_points.max, $9),
+  bucket_counts = ARRAY(
+    SELECT COALESCE(a, 0) + COALESCE(b, 0)
+    FROM unnest(metric_points.bucket_counts, $10::double precision[]) AS t(a, b)
+  )
+`
+
+func (q *Queries) UpsertMetricPoint(ctx context.Context, p MetricPoint) error {
+	_, err := q.db.Exec(ctx, upsertMetricPoint, p.MetricID, p.Resolution, p.BucketStart, p.Labels, p.LabelsKey, p.Count, p.Sum, p.Min, p.Max, p.BucketCounts)
+	return err
+}
+
+const listMetricPoints = `
+SELECT metric_id, resolution, bucket_start, labels, labels_key, count, sum, min, max, bucket_counts
+FROM metric_points
+WHERE metric_id = $1 AND resolution = $2 AND bucket_start >= $3 AND bucket_start < $4 AND labels @> $5
+ORDER BY labels_key, bucket_start
+`
+
+func (q *Queries) ListMetricPoints(ctx context.Context, metricID uuid.UUID, resolution int32, from, to time.Time, labels []byte) ([]MetricPoint, error) {
+	rows, err := q.db.Query(ctx, listMetricPoints, metricID, resolution, from, to, labels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MetricPoint
+	for rows.Next() {
+		var p MetricPoint
+		if err := rows.Scan(&p.MetricID, &p.Resolution, &p.BucketStart, &p.Labels, &p.LabelsKey, &p.Count, &p.Sum, &p.Min, &p.Max, &p.BucketCounts); err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+	if items == nil {
+		items = []MetricPoint{}
+	}
+	return items, rows.Err()
+}
+
+const summarizeMetricPoints = `
+SELECT COALESCE(SUM(count), 0), COALESCE(SUM(sum), 0), COALESCE(MIN(min), 0), COALESCE(MAX(max), 0)
+FROM metric_points
+WHERE metric_id = $1 AND resolution = $2 AND bucket_start >= $3 AND bucket_start < $4 AND labels @> $5
+`
+
+func (q *Queries) SummarizeMetricPoints(ctx context.Context, metricID uuid.UUID, resolution int32, from, to time.Time, labels []byte) (MetricSummary, error) {
+	row := q.db.QueryRow(ctx, summarizeMetricPoints, metricID, resolution, from, to, labels)
+	var s MetricSummary
+	err := row.Scan(&s.Count, &s.Sum, &s.Min, &s.Max)
+	return s, err
+}
+
+const deleteMetricPointsBefore = `DELETE FROM metric_points WHERE resolution = $1 AND bucket_start < $2`
+
+func (q *Queries) DeleteMetricPointsBefore(ctx context.Context, resolution int32, before time.Time) (int64, error) {
+	tag, err := q.db.Exec(ctx, deleteMetricPointsBefore, resolution, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -101,3 +101,161 @@ type NotificationChannel struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+type LookupTable struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Name       string    `json:"name"`
+	MatchField string    `json:"match_field"`
+	KeyColumn  string    `json:"key_column"`
+	Columns    []string  `json:"columns"`
+	Rows       []byte    `json:"rows"`
+	RowCount   int32     `json:"row_count"`
+	Version    int32     `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type LookupTableMeta struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Name       string    `json:"name"`
+	MatchField string    `json:"match_field"`
+	KeyColumn  string    `json:"key_column"`
+	Columns    []string  `json:"columns"`
+	RowCount   int32     `json:"row_count"`
+	Version    int32     `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type LookupTableVersion struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	Version  int32     `json:"version"`
+}
+
+type LogPattern struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	PatternID  string    `json:"pattern_id"`
+	Template   string      `json:"template"`
+	TokenCount int32       `json:"token_count"`
+	MatchCount int64       `json:"match_count"`
+	FirstSeen  time.Time   `json:"first_seen"`
+	LastSeen   time.Time   `json:"last_seen"`
+	Route      pgtype.Text `json:"route"`
+}
+
+type FieldType struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FieldCatalogEntry struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Name       string    `json:"name"`
+	TypeCounts []byte    `json:"type_counts"`
+	EventCount int64     `json:"event_count"`
+	Sketch     []byte    `json:"sketch"`
+	Samples    []string  `json:"samples"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+type PipelineRule struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Name       string    `json:"name"`
+	Action     string    `json:"action"`
+	Match      []byte    `json:"match"`
+	SampleRate float64   `json:"sample_rate"`
+	HashField  string    `json:"hash_field"`
+	Priority   int32     `json:"priority"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Metric struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Match     []byte    `json:"match"`
+	Field     string    `json:"field"`
+	GroupBy   []string  `json:"group_by"`
+	Buckets   []float64 `json:"buckets"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MetricPoint struct {
+	MetricID     uuid.UUID `json:"metric_id"`
+	Resolution   int32     `json:"resolution"`
+	BucketStart  time.Time `json:"bucket_start"`
+	Labels       []byte    `json:"labels"`
+	LabelsKey    string    `json:"labels_key"`
+	Count        float64   `json:"count"`
+	Sum          float64   `json:"sum"`
+	Min          float64   `json:"min"`
+	Max          float64   `json:"max"`
+	BucketCounts []float64 `json:"bucket_counts"`
+}
+
+type MetricSummary struct {
+	Count float64 `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+type EgressDestination struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Config       []byte    `json:"config"`
+	Match        []byte    `json:"match"`
+	BatchSize    int32     `json:"batch_size"`
+	FlushSeconds int32     `json:"flush_seconds"`
+	MaxRetries   int32     `json:"max_retries"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RehydrationJob struct {
+	ID             uuid.UUID          `json:"id"`
+	TenantID       uuid.UUID          `json:"tenant_id"`
+	Status         string             `json:"status"`
+	FromTime       time.Time          `json:"from_time"`
+	ToTime         time.Time          `json:"to_time"`
+	Match          []byte             `json:"match"`
+	TtlHours       int32              `json:"ttl_hours"`
+	ObjectsTotal   int32              `json:"objects_total"`
+	ObjectsDone    int32              `json:"objects_done"`
+	EventsScanned  int64              `json:"events_scanned"`
+	EventsRestored int64              `json:"events_restored"`
+	Error          string             `json:"error"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	FinishedAt     pgtype.Timestamptz `json:"finished_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+}
+
+type IndexSetting struct {
+	TenantID        uuid.UUID   `json:"tenant_id"`
+	Shards          pgtype.Int4 `json:"shards"`
+	Replicas        pgtype.Int4 `json:"replicas"`
+	RefreshInterval pgtype.Text `json:"refresh_interval"`
+	Mappings        []byte      `json:"mappings"`
+	MessageAnalyzer pgtype.Text `json:"message_analyzer"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,103 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createPipelineRule = `
+INSERT INTO pipeline_rules (tenant_id, name, action, match, sample_rate, hash_field, priority)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, tenant_id, name, action, match, sample_rate, hash_field, priority, is_active, created_at, updated_at
+`
+
+func (q *Queries) CreatePipelineRule(ctx context.Context, tenantID uuid.UUID, name, action string, match []byte, sampleRate float64, hashField string, priority int32) (PipelineRule, error) {
+	row := q.db.QueryRow(ctx, createPipelineRule, tenantID, name, action, match, sampleRate, hashField, priority)
+	var r PipelineRule
+	err := row.Scan(&r.ID, &r.TenantID, &r.Name, &r.Action, &r.Match, &r.SampleRate, &r.HashField, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+const getPipelineRule = `
+SELECT id, tenant_id, name, action, match, sample_rate, hash_field, priority, is_active, created_at, updated_at
+FROM pipeline_rules WHERE id = $1 AND tenant_id = $2
+`
+
+func (q *Queries) GetPipelineRule(ctx context.Context, id, tenantID uuid.UUID) (PipelineRule, error) {
+	row := q.db.QueryRow(ctx, getPipelineRule, id, tenantID)
+	var r PipelineRule
+	err := row.Scan(&r.ID, &r.TenantID, &r.Name, &r.Action, &r.Match, &r.SampleRate, &r.HashField, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+const listPipelineRules = `
+SELECT id, tenant_id, name, action, match, sample_rate, hash_field, priority, is_active, created_at, updated_at
+FROM pipeline_rules WHERE tenant_id = $1 ORDER BY priority, created_at
+`
+
+func (q *Queries) ListPipelineRules(ctx context.Context, tenantID uuid.UUID) ([]PipelineRule, error) {
+	rows, err := q.db.Query(ctx, listPipelineRules, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PipelineRule
+	for rows.Next() {
+		var r PipelineRule
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.Name, &r.Action, &r.Match, &r.SampleRate, &r.HashField, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, r)
+	}
+	if items == nil {
+		items = []PipelineRule{}
+	}
+	return items, rows.Err()
+}
+
+const updatePipelineRule = `
+UPDATE pipeline_rules
+SET name = $3, action = $4, match = $5, sample_rate = $6, hash_field = $7, priority = $8, is_active = $9, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING id, tenant_id, name, action, match, sample_rate, hash_field, priority, is_active, created_at, updated_at
+`
+
+func (q *Queries) UpdatePipelineRule(ctx context.Context, id, tenantID uuid.UUID, name, action string, match []byte, sampleRate float64, hashField string, priority int32, isActive bool) (PipelineRule, error) {
+	row := q.db.QueryRow(ctx, updatePipelineRule, id, tenantID, name, action, match, sampleRate, hashField, priority, isActive)
+	var r PipelineRule
+	err := row.Scan(&r.ID, &r.TenantID, &r.Name, &r.Action, &r.Match, &r.SampleRate, &r.HashField, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+const deletePipelineRule = `DELETE FROM pipeline_rules WHERE id = $1 AND tenant_id = $2`
+
+func (q *Queries) DeletePipelineRule(ctx context.Context, id, tenantID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deletePipelineRule, id, tenantID)
+	return err
+}
+
+const listActivePipelineRules = `
+SELECT id, tenant_id, name, action, match, sample_rate, hash_field, priority, is_active, created_at, updated_at
+FROM pipeline_rules WHERE is_active = true ORDER BY tenant_id, priority, created_at
+`
+
+func (q *Queries) ListActivePipelineRules(ctx context.Context) ([]PipelineRule, error) {
+	rows, err := q.db.Query(ctx, listActivePipelineRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PipelineRule
+	for rows.Next() {
+		var r PipelineRule
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.Name, &r.Action, &r.Match, &r.SampleRate, &r.HashField, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, r)
+	}
+	if items == nil {
+		items = []PipelineRule{}
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,154 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRehydrationJob = `
+INSERT INTO rehydration_jobs (tenant_id, from_time, to_time, match, ttl_hours)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+`
+
+func (q *Queries) CreateRehydrationJob(ctx context.Context, tenantID uuid.UUID, from, to time.Time, match []byte, ttlHours int32) (RehydrationJob, error) {
+	row := q.db.QueryRow(ctx, createRehydrationJob, tenantID, from, to, match, ttlHours)
+	var j RehydrationJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt)
+	return j, err
+}
+
+const getRehydrationJob = `
+SELECT id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+FROM rehydration_jobs WHERE id = $1 AND tenant_id = $2
+`
+
+func (q *Queries) GetRehydrationJob(ctx context.Context, id, tenantID uuid.UUID) (RehydrationJob, error) {
+	row := q.db.QueryRow(ctx, getRehydrationJob, id, tenantID)
+	var j RehydrationJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt)
+	return j, err
+}
+
+const listRehydrationJobs = `
+SELECT id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+FROM rehydration_jobs WHERE tenant_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListRehydrationJobs(ctx context.Context, tenantID uuid.UUID) ([]RehydrationJob, error) {
+	rows, err := q.db.Query(ctx, listRehydrationJobs, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RehydrationJob
+	for rows.Next() {
+		var j RehydrationJob
+		if err := rows.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, j)
+	}
+	if items == nil {
+		items = []RehydrationJob{}
+	}
+	return items, rows.Err()
+}
+
+// claimRehydrationJob also picks up running jobs whose runner stopped
+// reporting progress.
+const claimRehydrationJob = `
+UPDATE rehydration_jobs
+SET status = 'running', started_at = COALESCE(started_at, now()), updated_at = now()
+WHERE id = (
+    SELECT id FROM rehydration_jobs
+    WHERE status = 'pending' OR (status = 'running' AND updated_at < now() - interval '10 minutes')
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+`
+
+func (q *Queries) ClaimRehydrationJob(ctx context.Context) (RehydrationJob, error) {
+	row := q.db.QueryRow(ctx, claimRehydrationJob)
+	var j RehydrationJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt)
+	return j, err
+}
+
+const updateRehydrationProgress = `
+UPDATE rehydration_jobs
+SET objects_total = $2, objects_done = $3, events_scanned = $4, events_restored = $5, updated_at = now()
+WHERE id = $1
+RETURNING status
+`
+
+// UpdateRehydrationProgress records progress and returns the job's status,
+// which is "cancelled" if it was cancelled meanwhile.
+func (q *Queries) UpdateRehydrationProgress(ctx context.Context, id uuid.UUID, objectsTotal, objectsDone int32, eventsScanned, eventsRestored int64) (string, error) {
+	row := q.db.QueryRow(ctx, updateRehydrationProgress, id, objectsTotal, objectsDone, eventsScanned, eventsRestored)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const finishRehydrationJob = `
+UPDATE rehydration_jobs
+SET status = $2, error = $3, expires_at = $4, finished_at = now(), updated_at = now()
+WHERE id = $1 AND status = 'running'
+`
+
+func (q *Queries) FinishRehydrationJob(ctx context.Context, id uuid.UUID, status, errMsg string, expiresAt pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, finishRehydrationJob, id, status, errMsg, expiresAt)
+	return err
+}
+
+const cancelRehydrationJob = `
+UPDATE rehydration_jobs
+SET status = 'cancelled', finished_at = now(), updated_at = now()
+WHERE id = $1 AND tenant_id = $2 AND status IN ('pending', 'running')
+RETURNING id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+`
+
+func (q *Queries) CancelRehydrationJob(ctx context.Context, id, tenantID uuid.UUID) (RehydrationJob, error) {
+	row := q.db.QueryRow(ctx, cancelRehydrationJob, id, tenantID)
+	var j RehydrationJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt)
+	return j, err
+}
+
+const listExpiredRehydrationJobs = `
+SELECT id, tenant_id, status, from_time, to_time, match, ttl_hours, objects_total, objects_done, events_scanned, events_restored, error, created_at, updated_at, started_at, finished_at, expires_at
+FROM rehydration_jobs WHERE status = 'completed' AND expires_at < now() ORDER BY expires_at
+`
+
+func (q *Queries) ListExpiredRehydrationJobs(ctx context.Context) ([]RehydrationJob, error) {
+	rows, err := q.db.Query(ctx, listExpiredRehydrationJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RehydrationJob
+	for rows.Next() {
+		var j RehydrationJob
+		if err := rows.Scan(&j.ID, &j.TenantID, &j.Status, &j.FromTime, &j.ToTime, &j.Match, &j.TtlHours, &j.ObjectsTotal, &j.ObjectsDone, &j.EventsScanned, &j.EventsRestored, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, j)
+	}
+	if items == nil {
+		items = []RehydrationJob{}
+	}
+	return items, rows.Err()
+}
+
+const markRehydrationExpired = `UPDATE rehydration_jobs SET status = 'expired', updated_at = now() WHERE id = $1`
+
+func (q *Queries) MarkRehydrationExpired(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markRehydrationExpired, id)
+	return err
+}
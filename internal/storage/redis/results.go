@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ResultStore keeps the state and results of background jobs, so any API
+// replica can serve them while one runs the job. Both expire ttl after the
+// last write.
+type ResultStore struct {
+	rdb    *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewResultStore(rdb *redis.Client, prefix string, ttl time.Duration) *ResultStore {
+	return &ResultStore{rdb: rdb, prefix: prefix, ttl: ttl}
+}
+
+func (s *ResultStore) stateKey(id string) string   { return fmt.Sprintf("%s:%s", s.prefix, id) }
+func (s *ResultStore) resultsKey(id string) string { return fmt.Sprintf("%s:%s:results", s.prefix, id) }
+
+// SetState stores a job's state as JSON.
+func (s *ResultStore) SetState(ctx context.Context, id string, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.Pipeline()
+	pipe.Set(ctx, s.stateKey(id), data, s.ttl)
+	pipe.Expire(ctx, s.resultsKey(id), s.ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetState loads a job's state. It returns redis.Nil for an unknown or
+// expired job.
+func (s *ResultStore) GetState(ctx context.Context, id string, dest any) error {
+	val, err := s.rdb.Get(ctx, s.stateKey(id)).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, dest)
+}
+
+// Append adds results in order.
+func (s *ResultStore) Append(ctx context.Context, id string, results []json.RawMessage) error {
+	if len(results) == 0 {
+		return nil
+	}
+	values := make([]any, len(results))
+	for i, r := range results {
+		values[i] = []byte(r)
+	}
+	pipe := s.rdb.Pipeline()
+	pipe.RPush(ctx, s.resultsKey(id), values...)
+	pipe.Expire(ctx, s.resultsKey(id), s.ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Results returns up to count results starting at offset.
+func (s *ResultStore) Results(ctx context.Context, id string, offset, count int) ([]json.RawMessage, error) {
+	vals, err := s.rdb.LRange(ctx, s.resultsKey(id), int64(offset), int64(offset+count-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]json.RawMessage, len(vals))
+	for i, v := range vals {
+		results[i] = json.RawMessage(v)
+	}
+	return results, nil
+}
+
+// Cancel asks the job's runner to stop.
+func (s *ResultStore) Cancel(ctx context.Context, id string) error {
+	return s.rdb.Set(ctx, s.stateKey(id)+":cancel", 1, s.ttl).Err()
+}
+
+// Cancelled reports whether Cancel was called for the job.
+func (s *ResultStore) Cancelled(ctx context.Context, id string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, s.stateKey(id)+":cancel").Result()
+	return n > 0, err
+}
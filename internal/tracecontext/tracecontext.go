@@ -0,0 +1,186 @@
+// Package tracecontext parses the trace propagation formats our services log
+// (W3C traceparent, B3, AWS X-Ray and Datadog) into one canonical form:
+// 32 lowercase hex digits for trace IDs and 16 for span IDs. 64-bit trace IDs
+// are left-padded with zeros, as OpenTelemetry does when bridging B3 and
+// Datadog to W3C.
+package tracecontext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FromTraceparent parses a W3C traceparent header:
+// "00-<32 hex trace id>-<16 hex span id>-<2 hex flags>".
+func FromTraceparent(s string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || !isHex(parts[0]) || parts[0] == "ff" {
+		return "", "", false
+	}
+	// Version 00 has exactly four parts; later versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	traceID, ok = hexID(parts[1], 32)
+	if !ok || len(parts[1]) != 32 {
+		return "", "", false
+	}
+	spanID, ok = hexID(parts[2], 16)
+	if !ok || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// FromB3 parses a B3 single header: "<trace id>-<span id>[-<sampled>[-<parent>]]".
+// A lone sampling decision ("0", "1", "d") carries no IDs.
+func FromB3(s string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	traceID, ok = TraceID(parts[0])
+	if !ok {
+		return "", "", false
+	}
+	spanID, ok = SpanID(parts[1])
+	if !ok {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// FromXRay parses an AWS X-Ray trace header
+// ("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+// or a bare X-Ray trace ID ("1-5759e988-bd862e3fe1be46a994272793"). The
+// trace ID is the epoch and unique parts joined; spanID is empty when the
+// header has no Parent.
+func FromXRay(s string) (traceID, spanID string, ok bool) {
+	root := ""
+	for _, kv := range strings.Split(strings.TrimSpace(s), ";") {
+		k, v, found := strings.Cut(strings.TrimSpace(kv), "=")
+		switch {
+		case !found:
+			if root == "" {
+				root = k
+			}
+		case strings.EqualFold(k, "Root"):
+			root = v
+		case strings.EqualFold(k, "Parent"):
+			spanID, _ = SpanID(v)
+		}
+	}
+
+	parts := strings.Split(root, "-")
+	if len(parts) != 3 || parts[0] != "1" || len(parts[1]) != 8 || len(parts[2]) != 24 {
+		return "", "", false
+	}
+	traceID, ok = hexID(parts[1]+parts[2], 32)
+	if !ok {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// FromDatadog converts Datadog's decimal 64-bit IDs. high is the optional
+// upper half of a 128-bit trace ID as 16 hex digits (the _dd.p.tid tag).
+func FromDatadog(trace, span, high string) (traceID, spanID string, ok bool) {
+	lo, err := strconv.ParseUint(strings.TrimSpace(trace), 10, 64)
+	if err != nil || lo == 0 {
+		return "", "", false
+	}
+	var hi uint64
+	if high != "" {
+		if hi, err = strconv.ParseUint(strings.TrimSpace(high), 16, 64); err != nil {
+			hi = 0
+		}
+	}
+	traceID = fmt.Sprintf("%016x%016x", hi, lo)
+
+	if s, err := strconv.ParseUint(strings.TrimSpace(span), 10, 64); err == nil && s != 0 {
+		spanID = fmt.Sprintf("%016x", s)
+	}
+	return traceID, spanID, true
+}
+
+// TraceID normalizes a trace ID given as 16 or 32 hex digits.
+func TraceID(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) != 16 && len(s) != 32 {
+		return "", false
+	}
+	return hexID(s, 32)
+}
+
+// SpanID normalizes a span ID given as 16 hex digits.
+func SpanID(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) != 16 {
+		return "", false
+	}
+	return hexID(s, 16)
+}
+
+// Parse recognizes a trace ID in any supported format: bare hex, W3C
+// traceparent, X-Ray, B3 single or Datadog decimal. spanID is set when the
+// format carries one.
+func Parse(s string) (traceID, spanID string, ok bool) {
+	if id, ok := TraceID(s); ok {
+		return id, "", true
+	}
+	if traceID, spanID, ok = FromTraceparent(s); ok {
+		return traceID, spanID, true
+	}
+	if traceID, spanID, ok = FromXRay(s); ok {
+		return traceID, spanID, true
+	}
+	if traceID, spanID, ok = FromB3(s); ok {
+		return traceID, spanID, true
+	}
+	return FromDatadog(s, "", "")
+}
+
+// Normalize returns the canonical form of a trace ID in any format Parse
+// accepts, or s unchanged with ok false.
+func Normalize(s string) (string, bool) {
+	if id, _, ok := Parse(s); ok {
+		return id, true
+	}
+	return s, false
+}
+
+// NormalizeSpan returns the canonical form of a span ID given as hex or as a
+// Datadog decimal, or s unchanged with ok false.
+func NormalizeSpan(s string) (string, bool) {
+	if id, ok := SpanID(s); ok {
+		return id, true
+	}
+	if n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64); err == nil && n != 0 {
+		return fmt.Sprintf("%016x", n), true
+	}
+	return s, false
+}
+
+// hexID lowercases s and left-pads it with zeros to width digits. All-zero
+// IDs are invalid in every format.
+func hexID(s string, width int) (string, bool) {
+	if s == "" || len(s) > width || !isHex(s) {
+		return "", false
+	}
+	s = strings.ToLower(s)
+	if strings.Trim(s, "0") == "" {
+		return "", false
+	}
+	return strings.Repeat("0", width-len(s)) + s, true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
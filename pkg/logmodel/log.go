@@ -4,18 +4,21 @@ import "time"
 
 // LogEvent is the canonical log event structure used throughout the system.
 type LogEvent struct {
-	ID        string            `json:"id"`
-	TenantID  string            `json:"tenant_id"`
-	Timestamp time.Time         `json:"timestamp"`
-	Level     string            `json:"level"`
-	Message   string            `json:"message"`
-	Service   string            `json:"service"`
-	Host      string            `json:"host,omitempty"`
-	TraceID   string            `json:"trace_id,omitempty"`
-	SpanID    string            `json:"span_id,omitempty"`
-	Fields    map[string]any    `json:"fields,omitempty"`
-	Tags      []string          `json:"tags,omitempty"`
-	Raw       string            `json:"raw,omitempty"`
+	ID         string         `json:"id"`
+	TenantID   string         `json:"tenant_id"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Level      string         `json:"level"`
+	Message    string         `json:"message"`
+	Service    string         `json:"service"`
+	Host       string         `json:"host,omitempty"`
+	TraceID    string         `json:"trace_id,omitempty"`
+	SpanID     string         `json:"span_id,omitempty"`
+	PatternID  string         `json:"pattern_id,omitempty"`
+	Pattern    string         `json:"pattern,omitempty"`
+	SampleRate float64        `json:"sample_rate,omitempty"` // fraction kept by a sampling rule; 0 when not sampled
+	Fields     map[string]any `json:"fields,omitempty"`
+	Tags       []string       `json:"tags,omitempty"`
+	Raw        string         `json:"raw,omitempty"`
 }
 
 // IngestRequest is the payload for POST /v1/ingest/logs.
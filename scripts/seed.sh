@@ -22,7 +22,7 @@ echo "==> Creating API key: $KEY_NAME"
 psql "$PG_URL" -c "
   INSERT INTO api_keys (tenant_id, key_hash, key_prefix, name, scopes, rate_limit)
   VALUES ('$TENANT_ID', '$KEY_HASH', '$KEY_PREFIX', '$KEY_NAME',
-    ARRAY['ingest:logs','search:logs','alerts:read','alerts:write','incidents:read','incidents:write','notifications:read','notifications:write','admin'],
+    ARRAY['ingest:logs','search:logs','alerts:read','alerts:write','incidents:read','incidents:write','notifications:read','notifications:write','lookups:read','lookups:write','dlq:read','dlq:write','pipeline:read','pipeline:write','fields:read','fields:write','metrics:read','metrics:write','egress:read','egress:write','storage:read','archive:read','archive:write','admin'],
     10000)
   ON CONFLICT (key_hash) DO NOTHING;
 "
@@ -0,0 +1,22 @@
+-- name: CreateEgressDestination :one
+INSERT INTO egress_destinations (tenant_id, name, type, config, match, batch_size, flush_seconds, max_retries)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetEgressDestination :one
+SELECT * FROM egress_destinations WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListEgressDestinations :many
+SELECT * FROM egress_destinations WHERE tenant_id = $1 ORDER BY created_at;
+
+-- name: UpdateEgressDestination :one
+UPDATE egress_destinations
+SET name = $3, type = $4, config = $5, match = $6, batch_size = $7, flush_seconds = $8, max_retries = $9, is_active = $10, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeleteEgressDestination :exec
+DELETE FROM egress_destinations WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListActiveEgressDestinations :many
+SELECT * FROM egress_destinations WHERE is_active = true ORDER BY tenant_id, created_at;
@@ -0,0 +1,11 @@
+-- name: ListFieldCatalog :many
+SELECT * FROM field_catalog WHERE tenant_id = $1 AND name LIKE $2 || '%' ORDER BY name;
+
+-- name: LockFieldCatalog :many
+SELECT * FROM field_catalog WHERE tenant_id = $1 AND name = ANY($2::text[]) FOR UPDATE;
+
+-- name: UpsertFieldCatalog :exec
+INSERT INTO field_catalog (tenant_id, name, type_counts, event_count, sketch, samples, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET type_counts = $3, event_count = $4, sketch = $5, samples = $6, first_seen = $7, last_seen = $8;
@@ -0,0 +1,23 @@
+-- name: LockFieldTypes :exec
+SELECT pg_advisory_xact_lock(hashtextextended('field_types:' || $1::text, 0));
+
+-- name: RegisterFieldType :one
+INSERT INTO field_types (tenant_id, name, type)
+SELECT $1::uuid, $2::varchar, $3::varchar
+WHERE EXISTS (SELECT 1 FROM field_types WHERE tenant_id = $1 AND name = $2)
+   OR (SELECT count(*) FROM field_types WHERE tenant_id = $1) < $4
+ON CONFLICT (tenant_id, name) DO UPDATE SET name = field_types.name
+RETURNING *;
+
+-- name: SetFieldType :one
+INSERT INTO field_types (tenant_id, name, type, source)
+VALUES ($1, $2, $3, 'manual')
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET type = $3, source = 'manual', updated_at = now()
+RETURNING *;
+
+-- name: ListFieldTypes :many
+SELECT * FROM field_types WHERE tenant_id = $1 ORDER BY name;
+
+-- name: DeleteFieldType :exec
+DELETE FROM field_types WHERE tenant_id = $1 AND name = $2;
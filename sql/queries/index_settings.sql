@@ -0,0 +1,15 @@
+-- name: GetIndexSettings :one
+SELECT * FROM index_settings WHERE tenant_id = $1;
+
+-- name: ListIndexSettings :many
+SELECT * FROM index_settings ORDER BY tenant_id;
+
+-- name: UpsertIndexSettings :one
+INSERT INTO index_settings (tenant_id, shards, replicas, refresh_interval, mappings, message_analyzer)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (tenant_id)
+DO UPDATE SET shards = $2, replicas = $3, refresh_interval = $4, mappings = $5, message_analyzer = $6, updated_at = now()
+RETURNING *;
+
+-- name: DeleteIndexSettings :exec
+DELETE FROM index_settings WHERE tenant_id = $1;
@@ -0,0 +1,14 @@
+-- name: UpsertLogPattern :exec
+INSERT INTO log_patterns (tenant_id, pattern_id, template, route, token_count, match_count, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (tenant_id, pattern_id)
+DO UPDATE SET
+  template = CASE
+    WHEN length($3) - length(replace($3, '<*>', '')) >= length(log_patterns.template) - length(replace(log_patterns.template, '<*>', ''))
+    THEN $3 ELSE log_patterns.template END,
+  route = COALESCE(log_patterns.route, $4),
+  match_count = log_patterns.match_count + $6,
+  first_seen = LEAST(log_patterns.first_seen, $7), last_seen = GREATEST(log_patterns.last_seen, $8);
+
+-- name: ListLogPatterns :many
+SELECT * FROM log_patterns WHERE tenant_id = $1 ORDER BY match_count DESC;
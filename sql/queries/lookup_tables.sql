@@ -0,0 +1,23 @@
+-- name: UpsertLookupTable :one
+INSERT INTO lookup_tables (tenant_id, name, match_field, key_column, columns, rows, row_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id, name)
+DO UPDATE SET match_field = $3, key_column = $4, columns = $5, rows = $6, row_count = $7,
+  version = lookup_tables.version + 1, updated_at = now()
+RETURNING id, tenant_id, name, match_field, key_column, columns, row_count, version, created_at, updated_at;
+
+-- name: GetLookupTable :one
+SELECT * FROM lookup_tables WHERE tenant_id = $1 AND name = $2;
+
+-- name: GetLookupTableByID :one
+SELECT * FROM lookup_tables WHERE id = $1;
+
+-- name: ListLookupTables :many
+SELECT id, tenant_id, name, match_field, key_column, columns, row_count, version, created_at, updated_at
+FROM lookup_tables WHERE tenant_id = $1 ORDER BY name;
+
+-- name: ListLookupTableVersions :many
+SELECT id, tenant_id, version FROM lookup_tables;
+
+-- name: DeleteLookupTable :exec
+DELETE FROM lookup_tables WHERE tenant_id = $1 AND name = $2;
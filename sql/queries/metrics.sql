@@ -0,0 +1,49 @@
+-- name: CreateMetric :one
+INSERT INTO metrics (tenant_id, name, type, match, field, group_by, buckets)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetMetric :one
+SELECT * FROM metrics WHERE id = $1 AND tenant_id = $2;
+
+-- name: GetMetricByName :one
+SELECT * FROM metrics WHERE tenant_id = $1 AND name = $2;
+
+-- name: ListMetrics :many
+SELECT * FROM metrics WHERE tenant_id = $1 ORDER BY name;
+
+-- name: UpdateMetric :one
+UPDATE metrics
+SET name = $3, type = $4, match = $5, field = $6, group_by = $7, buckets = $8, is_active = $9, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeleteMetric :exec
+DELETE FROM metrics WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListActiveMetrics :many
+SELECT * FROM metrics WHERE is_active = true ORDER BY tenant_id, name;
+
+-- name: UpsertMetricPoint :exec
+INSERT INTO metric_points (metric_id, resolution, bucket_start, labels, labels_key, count, sum, min, max, bucket_counts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (metric_id, resolution, bucket_start, labels_key)
+DO UPDATE SET count = metric_points.count + $6, sum = metric_points.sum + $7,
+  min = LEAST(metric_points.min, $8), max = GREATEST(metric_points.max, $9),
+  bucket_counts = ARRAY(
+    SELECT COALESCE(a, 0) + COALESCE(b, 0)
+    FROM unnest(metric_points.bucket_counts, $10::double precision[]) AS t(a, b)
+  );
+
+-- name: ListMetricPoints :many
+SELECT * FROM metric_points
+WHERE metric_id = $1 AND resolution = $2 AND bucket_start >= $3 AND bucket_start < $4 AND labels @> $5
+ORDER BY labels_key, bucket_start;
+
+-- name: SummarizeMetricPoints :one
+SELECT COALESCE(SUM(count), 0), COALESCE(SUM(sum), 0), COALESCE(MIN(min), 0), COALESCE(MAX(max), 0)
+FROM metric_points
+WHERE metric_id = $1 AND resolution = $2 AND bucket_start >= $3 AND bucket_start < $4 AND labels @> $5;
+
+-- name: DeleteMetricPointsBefore :execrows
+DELETE FROM metric_points WHERE resolution = $1 AND bucket_start < $2;
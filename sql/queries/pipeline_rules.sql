@@ -0,0 +1,22 @@
+-- name: CreatePipelineRule :one
+INSERT INTO pipeline_rules (tenant_id, name, action, match, sample_rate, hash_field, priority)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetPipelineRule :one
+SELECT * FROM pipeline_rules WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListPipelineRules :many
+SELECT * FROM pipeline_rules WHERE tenant_id = $1 ORDER BY priority, created_at;
+
+-- name: UpdatePipelineRule :one
+UPDATE pipeline_rules
+SET name = $3, action = $4, match = $5, sample_rate = $6, hash_field = $7, priority = $8, is_active = $9, updated_at = now()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeletePipelineRule :exec
+DELETE FROM pipeline_rules WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListActivePipelineRules :many
+SELECT * FROM pipeline_rules WHERE is_active = true ORDER BY tenant_id, priority, created_at;
@@ -0,0 +1,45 @@
+-- name: CreateRehydrationJob :one
+INSERT INTO rehydration_jobs (tenant_id, from_time, to_time, match, ttl_hours)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetRehydrationJob :one
+SELECT * FROM rehydration_jobs WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListRehydrationJobs :many
+SELECT * FROM rehydration_jobs WHERE tenant_id = $1 ORDER BY created_at DESC;
+
+-- name: ClaimRehydrationJob :one
+UPDATE rehydration_jobs
+SET status = 'running', started_at = COALESCE(started_at, now()), updated_at = now()
+WHERE id = (
+    SELECT id FROM rehydration_jobs
+    WHERE status = 'pending' OR (status = 'running' AND updated_at < now() - interval '10 minutes')
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateRehydrationProgress :one
+UPDATE rehydration_jobs
+SET objects_total = $2, objects_done = $3, events_scanned = $4, events_restored = $5, updated_at = now()
+WHERE id = $1
+RETURNING status;
+
+-- name: FinishRehydrationJob :exec
+UPDATE rehydration_jobs
+SET status = $2, error = $3, expires_at = $4, finished_at = now(), updated_at = now()
+WHERE id = $1 AND status = 'running';
+
+-- name: CancelRehydrationJob :one
+UPDATE rehydration_jobs
+SET status = 'cancelled', finished_at = now(), updated_at = now()
+WHERE id = $1 AND tenant_id = $2 AND status IN ('pending', 'running')
+RETURNING *;
+
+-- name: ListExpiredRehydrationJobs :many
+SELECT * FROM rehydration_jobs WHERE status = 'completed' AND expires_at < now() ORDER BY expires_at;
+
+-- name: MarkRehydrationExpired :exec
+UPDATE rehydration_jobs SET status = 'expired', updated_at = now() WHERE id = $1;